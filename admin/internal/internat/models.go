@@ -29,6 +29,7 @@ type Messages_ModelsI18nModuleKey struct {
 	BannersBgroupID     string
 	BannersBgroup       string
 	BannersPrice        string
+	BannersPricing      string
 	BannersActive       string
 	BannersTitle        string
 	BannersLabel        string
@@ -40,6 +41,10 @@ type Messages_ModelsI18nModuleKey struct {
 	BannersDescription  string
 	BannersTimetable    string
 	BannersMacros       string
+	BannersVideo        string
+	BannersMediaFiles   string
+	BannersDuration     string
+	BannersSkipOffset   string
 
 	// Bgroup
 	Bgroup           string
@@ -164,6 +169,7 @@ var Messages_ru_RU_ModelsI18nModuleKey = &Messages_ModelsI18nModuleKey{
 	BannersBgroupID:     "Группа",
 	BannersBgroup:       "Группа",
 	BannersPrice:        "Цена",
+	BannersPricing:      "Модель оплаты",
 	BannersActive:       "Включен",
 	BannersTitle:        "Заголовок",
 	BannersLabel:        "Лейбл",
@@ -175,6 +181,10 @@ var Messages_ru_RU_ModelsI18nModuleKey = &Messages_ModelsI18nModuleKey{
 	BannersDescription:  "Описание",
 	BannersTimetable:    "Расписание активности",
 	BannersMacros:       "Макросы",
+	BannersVideo:        "Видео",
+	BannersMediaFiles:   "Дополнительные файлы",
+	BannersDuration:     "Длительность, сек",
+	BannersSkipOffset:   "Пропуск через, сек",
 
 	Bgroup:           "Группа",
 	BgroupsInfo:      "Общая информация",
@@ -233,6 +233,7 @@ func (m *Advertiser) Configure(b *presets.Builder) {
 			Title: "Targeting",
 			Rows: [][]string{
 				{"Timetable"},
+				{"Timezone"},
 				{"Targeting"},
 			},
 		},
@@ -275,6 +276,16 @@ func (m *Advertiser) Configure(b *presets.Builder) {
 	mae.Field("Capping").
 		ComponentFunc(capping.Component).
 		SetterFunc(capping.Setter)
+
+	mae.ValidateFunc(func(obj interface{}, ctx *web.EventContext) (err web.ValidationErrors) {
+		u := obj.(*models.Advertiser)
+
+		// с неизвестной зоной сервер не сможет загрузить баннеры рекламодателя
+		if _, e := time.LoadLocation(u.Timezone); e != nil {
+			err.FieldError("Timezone", "Unknown timezone")
+		}
+		return
+	})
 }
 
 func (m *Advertiser) copyAdvertiser(ctx *web.EventContext) (r web.EventResponse, err error) {
@@ -296,6 +307,7 @@ func (m *Advertiser) copyAdvertiser(ctx *web.EventContext) (r web.EventResponse,
 		Start:     original.Start,
 		End:       original.End,
 		Timetable: original.Timetable,
+		Timezone:  original.Timezone,
 		Targeting: original.Targeting,
 		Budget:    original.Budget,
 		Capping:   original.Capping,
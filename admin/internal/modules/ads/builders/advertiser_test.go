@@ -108,8 +108,8 @@ func TestCopyAdvertiser(t *testing.T) {
 	now := time.Now()
 
 	// Mock the database calls for finding the original advertiser
-	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at", "title", "info", "active", "start", "end", "targeting", "budget", "capping", "timetable", "ord_contract", "archived_at"}).
-		AddRow(1, now, now, nil, "Test Advertiser", "Test Info", true, now, now.Add(24*time.Hour), "", "", "", "", "", nil)
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at", "title", "info", "active", "start", "end", "targeting", "budget", "capping", "timetable", "timezone", "ord_contract", "archived_at"}).
+		AddRow(1, now, now, nil, "Test Advertiser", "Test Info", true, now, now.Add(24*time.Hour), "", "", "", "", "", "", nil)
 	mock.ExpectQuery(`SELECT \* FROM "advertisers" WHERE "advertisers"\."id" = \$1 AND "advertisers"\."deleted_at" IS NULL ORDER BY "advertisers"\."id" LIMIT \$2`).
 		WithArgs("1", 1).
 		WillReturnRows(rows)
@@ -119,7 +119,7 @@ func TestCopyAdvertiser(t *testing.T) {
 
 	// Используем ExpectQuery вместо ExpectExec для INSERT с RETURNING
 	rowsInsert := sqlmock.NewRows([]string{"id"}).AddRow(2)
-	mock.ExpectQuery(`INSERT INTO "advertisers" \("created_at","updated_at","deleted_at","title","info","active","start","end","targeting","budget","capping","timetable","ord_contract","archived_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14\) RETURNING "id"`).
+	mock.ExpectQuery(`INSERT INTO "advertisers" \("created_at","updated_at","deleted_at","title","info","active","start","end","targeting","budget","capping","timetable","timezone","ord_contract","archived_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$15\) RETURNING "id"`).
 		WithArgs(
 			sqlmock.AnyArg(),          // created_at
 			sqlmock.AnyArg(),          // updated_at
@@ -133,6 +133,7 @@ func TestCopyAdvertiser(t *testing.T) {
 			sqlmock.AnyArg(),          // budget
 			sqlmock.AnyArg(),          // capping
 			sqlmock.AnyArg(),          // timetable
+			sqlmock.AnyArg(),          // timezone
 			sqlmock.AnyArg(),          // ord_contract
 			sqlmock.AnyArg(),          // archived_at
 		).
@@ -189,15 +190,15 @@ func TestArchiveAdvertiser(t *testing.T) {
 	now := time.Now()
 
 	// Mock the database calls
-	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at", "title", "info", "active", "start", "end", "targeting", "budget", "capping", "timetable", "ord_contract", "archived_at"}).
-		AddRow(1, now, now, nil, "Test Advertiser", "", true, time.Time{}, time.Time{}, "", "", "", "", "", nil)
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at", "title", "info", "active", "start", "end", "targeting", "budget", "capping", "timetable", "timezone", "ord_contract", "archived_at"}).
+		AddRow(1, now, now, nil, "Test Advertiser", "", true, time.Time{}, time.Time{}, "", "", "", "", "", "", nil)
 	mock.ExpectQuery(`SELECT \* FROM "advertisers" WHERE "advertisers"\."id" = \$1 AND "advertisers"\."deleted_at" IS NULL ORDER BY "advertisers"\."id" LIMIT \$2`).
 		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnRows(rows)
 
 	mock.ExpectBegin()
 
-	mock.ExpectExec(`UPDATE "advertisers" SET .* WHERE "advertisers"\."deleted_at" IS NULL AND "id" = \$16`).
+	mock.ExpectExec(`UPDATE "advertisers" SET .* WHERE "advertisers"\."deleted_at" IS NULL AND "id" = \$17`).
 		WithArgs(
 			sqlmock.AnyArg(), // id
 			sqlmock.AnyArg(), // created_at
@@ -212,6 +213,7 @@ func TestArchiveAdvertiser(t *testing.T) {
 			sqlmock.AnyArg(), // budget
 			sqlmock.AnyArg(), // capping
 			sqlmock.AnyArg(), // timetable
+			sqlmock.AnyArg(), // timezone
 			sqlmock.AnyArg(), // ord_contract
 			sqlmock.AnyArg(), // archived_at
 			int64(1),         // WHERE id = 1
@@ -275,15 +277,15 @@ func TestUnarchiveAdvertiser(t *testing.T) {
 	now := time.Now()
 
 	// Mock the database calls
-	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at", "title", "info", "active", "start", "end", "targeting", "budget", "capping", "timetable", "ord_contract", "archived_at"}).
-		AddRow(1, now, now, nil, "Test Advertiser", "", true, time.Time{}, time.Time{}, "", "", "", "", "", nil)
+	rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at", "title", "info", "active", "start", "end", "targeting", "budget", "capping", "timetable", "timezone", "ord_contract", "archived_at"}).
+		AddRow(1, now, now, nil, "Test Advertiser", "", true, time.Time{}, time.Time{}, "", "", "", "", "", "", nil)
 	mock.ExpectQuery(`SELECT \* FROM "advertisers" WHERE "advertisers"\."id" = \$1 AND "advertisers"\."deleted_at" IS NULL ORDER BY "advertisers"\."id" LIMIT \$2`).
 		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnRows(rows)
 
 	mock.ExpectBegin()
 
-	mock.ExpectExec(`UPDATE "advertisers" SET .* WHERE "advertisers"\."deleted_at" IS NULL AND "id" = \$16`).
+	mock.ExpectExec(`UPDATE "advertisers" SET .* WHERE "advertisers"\."deleted_at" IS NULL AND "id" = \$17`).
 		WithArgs(
 			sqlmock.AnyArg(), // id
 			sqlmock.AnyArg(), // created_at
@@ -298,6 +300,7 @@ func TestUnarchiveAdvertiser(t *testing.T) {
 			sqlmock.AnyArg(), // budget
 			sqlmock.AnyArg(), // capping
 			sqlmock.AnyArg(), // timetable
+			sqlmock.AnyArg(), // timezone
 			sqlmock.AnyArg(), // ord_contract
 			sqlmock.AnyArg(), // archived_at
 			int64(1),         // WHERE id = 1
@@ -157,7 +157,7 @@ func (m *Banner) Configure(b *presets.Builder) {
 		return h.Td().Text(group.Title)
 	})
 
-	mbl.Field("Price").Label("CPM")
+	mbl.Field("Price").Label("Цена")
 
 	mbl.Field("Active").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
 		c := obj.(*models.Banner)
@@ -234,10 +234,18 @@ func (m *Banner) Configure(b *presets.Builder) {
 				{"Active"},
 			},
 		},
+		&presets.FieldsSection{
+			Title: "Video",
+			Rows: [][]string{
+				{"Video"},
+				{"MediaFiles"},
+				{"Duration", "SkipOffset"},
+			},
+		},
 		&presets.FieldsSection{
 			Title: "Price",
 			Rows: [][]string{
-				{"Price"},
+				{"Pricing", "Price"},
 			},
 		},
 		&presets.FieldsSection{
@@ -281,7 +289,29 @@ func (m *Banner) Configure(b *presets.Builder) {
 		},
 	)
 
-	mbe.Field("Price").Label("CPM")
+	mbe.Field("Price").Label("Цена")
+
+	// за что платит рекламодатель, от этого сервер считает eCPM
+	mbe.Field("Pricing").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
+		c := obj.(*models.Banner)
+
+		pricing := c.Pricing
+		if pricing == "" {
+			pricing = "cpm"
+		}
+
+		return v.VSelect().
+			Label(field.Label).
+			Items([]map[string]string{
+				{"Title": "CPM - за тысячу показов", "Value": "cpm"},
+				{"Title": "CPC - за клик", "Value": "cpc"},
+				{"Title": "CPA - за конверсию", "Value": "cpa"},
+			}).
+			ItemTitle("Title").
+			ItemValue("Value").
+			Attr(web.VField(field.FormKey, pricing)...).
+			ErrorMessages(field.Errors...)
+	})
 
 	mbe.Field("Macros").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
 		return h.Div(
@@ -316,6 +346,23 @@ func (m *Banner) Configure(b *presets.Builder) {
 				},
 			})
 
+	mbe.Field("Video").
+		WithContextValue(
+			media.MediaBoxConfig,
+			&media_library.MediaBoxConfig{
+				AllowType: media_library.ALLOW_TYPE_VIDEO,
+			})
+
+	// дополнительные кодировки видео, по одной ссылке на строку
+	mbe.Field("MediaFiles").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
+		return v.VTextarea().
+			Label(field.Label).
+			Hint("По одной ссылке на строку").
+			Attr(web.VField(field.FormKey, fmt.Sprint(reflectutils.MustGet(obj, field.Name)))...).
+			Disabled(field.Disabled).
+			ErrorMessages(field.Errors...)
+	})
+
 	mbe.Field("BgroupID").ComponentFunc(func(obj interface{}, field *presets.FieldContext, ctx *web.EventContext) h.HTMLComponent {
 		c := obj.(*models.Banner)
 
@@ -110,13 +110,14 @@ func TestCopyBanner(t *testing.T) {
 	// Mock the database calls for finding the original banner
 	rows := sqlmock.NewRows([]string{
 		"id", "created_at", "updated_at", "deleted_at", "title", "label", "description", "active",
-		"erid", "ord_category", "ord_targeting", "ord_format", "ord_kktu", "price", "image", "icon",
+		"erid", "ord_category", "ord_targeting", "ord_format", "ord_kktu", "price", "pricing", "image", "icon",
+		"video", "media_files", "duration", "skip_offset",
 		"start", "end", "clicktracker", "imptracker", "target", "targeting", "budget", "capping",
 		"bgroup_id", "timetable", "archived_at",
 	}).AddRow(
 		1, now, now, nil, "Test Banner", "Test Label", "Test Description", true,
-		"test-erid", "category", "targeting", "format", "kktu", 1000,
-		"{}", "{}", now, now.Add(24*time.Hour), "clicktracker", "imptracker", "target",
+		"test-erid", "category", "targeting", "format", "kktu", 1000, "cpc",
+		"{}", "{}", "{}", "[]", 15, 5, now, now.Add(24*time.Hour), "clicktracker", "imptracker", "target",
 		"targeting", "budget", "capping", 1, "timetable", nil,
 	)
 
@@ -129,7 +130,7 @@ func TestCopyBanner(t *testing.T) {
 
 	// Use ExpectQuery instead of ExpectExec for INSERT with RETURNING
 	rowsInsert := sqlmock.NewRows([]string{"id"}).AddRow(2)
-	mock.ExpectQuery(`INSERT INTO "banners" \("created_at","updated_at","deleted_at","title","label","description","active","erid","ord_category","ord_targeting","ord_format","ord_kktu","price","image","icon","start","end","clicktracker","imptracker","target","targeting","budget","capping","bgroup_id","timetable","archived_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$15,\$16,\$17,\$18,\$19,\$20,\$21,\$22,\$23,\$24,\$25,\$26\) RETURNING "id"`).
+	mock.ExpectQuery(`INSERT INTO "banners" \("created_at","updated_at","deleted_at","title","label","description","active","erid","ord_category","ord_targeting","ord_format","ord_kktu","price","pricing","image","icon","video","media_files","duration","skip_offset","start","end","clicktracker","imptracker","target","targeting","budget","capping","bgroup_id","timetable","archived_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$15,\$16,\$17,\$18,\$19,\$20,\$21,\$22,\$23,\$24,\$25,\$26,\$27,\$28,\$29,\$30,\$31\) RETURNING "id"`).
 		WithArgs(
 			sqlmock.AnyArg(),      // created_at
 			sqlmock.AnyArg(),      // updated_at
@@ -144,8 +145,13 @@ func TestCopyBanner(t *testing.T) {
 			"format",              // ord_format
 			"kktu",                // ord_kktu
 			1000,                  // price
+			"cpc",                 // pricing
 			sqlmock.AnyArg(),      // image
 			sqlmock.AnyArg(),      // icon
+			sqlmock.AnyArg(),      // video
+			"[]",                  // media_files
+			15,                    // duration
+			5,                     // skip_offset
 			sqlmock.AnyArg(),      // start
 			sqlmock.AnyArg(),      // end
 			"clicktracker",        // clicktracker
@@ -214,13 +220,14 @@ func TestArchiveBanner(t *testing.T) {
 	// Mock the database calls for finding the original banner
 	rows := sqlmock.NewRows([]string{
 		"id", "created_at", "updated_at", "deleted_at", "title", "label", "description", "active",
-		"erid", "ord_category", "ord_targeting", "ord_format", "ord_kktu", "price", "image", "icon",
+		"erid", "ord_category", "ord_targeting", "ord_format", "ord_kktu", "price", "pricing", "image", "icon",
+		"video", "media_files", "duration", "skip_offset",
 		"start", "end", "clicktracker", "imptracker", "target", "targeting", "budget", "capping",
 		"bgroup_id", "timetable", "archived_at",
 	}).AddRow(
 		1, now, now, nil, "Test Banner", "Test Label", "Test Description", true,
-		"test-erid", "category", "targeting", "format", "kktu", 1000,
-		"{}", "{}", now, now.Add(24*time.Hour), "clicktracker", "imptracker", "target",
+		"test-erid", "category", "targeting", "format", "kktu", 1000, "cpc",
+		"{}", "{}", "{}", "[]", 15, 5, now, now.Add(24*time.Hour), "clicktracker", "imptracker", "target",
 		"targeting", "budget", "capping", 1, "timetable", nil,
 	)
 
@@ -230,7 +237,7 @@ func TestArchiveBanner(t *testing.T) {
 
 	// Mock the database calls for updating the banner
 	mock.ExpectBegin()
-	mock.ExpectExec(`UPDATE "banners" SET "id"=\$1,"created_at"=\$2,"updated_at"=\$3,"deleted_at"=\$4,"title"=\$5,"label"=\$6,"description"=\$7,"active"=\$8,"erid"=\$9,"ord_category"=\$10,"ord_targeting"=\$11,"ord_format"=\$12,"ord_kktu"=\$13,"price"=\$14,"image"=\$15,"icon"=\$16,"start"=\$17,"end"=\$18,"clicktracker"=\$19,"imptracker"=\$20,"target"=\$21,"targeting"=\$22,"budget"=\$23,"capping"=\$24,"bgroup_id"=\$25,"timetable"=\$26,"archived_at"=\$27 WHERE "banners"\."deleted_at" IS NULL AND "id" = \$28`).
+	mock.ExpectExec(`UPDATE "banners" SET "id"=\$1,"created_at"=\$2,"updated_at"=\$3,"deleted_at"=\$4,"title"=\$5,"label"=\$6,"description"=\$7,"active"=\$8,"erid"=\$9,"ord_category"=\$10,"ord_targeting"=\$11,"ord_format"=\$12,"ord_kktu"=\$13,"price"=\$14,"pricing"=\$15,"image"=\$16,"icon"=\$17,"video"=\$18,"media_files"=\$19,"duration"=\$20,"skip_offset"=\$21,"start"=\$22,"end"=\$23,"clicktracker"=\$24,"imptracker"=\$25,"target"=\$26,"targeting"=\$27,"budget"=\$28,"capping"=\$29,"bgroup_id"=\$30,"timetable"=\$31,"archived_at"=\$32 WHERE "banners"\."deleted_at" IS NULL AND "id" = \$33`).
 		WithArgs(
 			sqlmock.AnyArg(), // id
 			sqlmock.AnyArg(), // created_at
@@ -246,8 +253,13 @@ func TestArchiveBanner(t *testing.T) {
 			sqlmock.AnyArg(), // ord_format
 			sqlmock.AnyArg(), // ord_kktu
 			sqlmock.AnyArg(), // price
+			sqlmock.AnyArg(), // pricing
 			sqlmock.AnyArg(), // image
 			sqlmock.AnyArg(), // icon
+			sqlmock.AnyArg(), // video
+			sqlmock.AnyArg(), // media_files
+			sqlmock.AnyArg(), // duration
+			sqlmock.AnyArg(), // skip_offset
 			sqlmock.AnyArg(), // start
 			sqlmock.AnyArg(), // end
 			sqlmock.AnyArg(), // clicktracker
@@ -317,13 +329,14 @@ func TestUnarchiveBanner(t *testing.T) {
 	// Mock the database calls for finding the original banner
 	rows := sqlmock.NewRows([]string{
 		"id", "created_at", "updated_at", "deleted_at", "title", "label", "description", "active",
-		"erid", "ord_category", "ord_targeting", "ord_format", "ord_kktu", "price", "image", "icon",
+		"erid", "ord_category", "ord_targeting", "ord_format", "ord_kktu", "price", "pricing", "image", "icon",
+		"video", "media_files", "duration", "skip_offset",
 		"start", "end", "clicktracker", "imptracker", "target", "targeting", "budget", "capping",
 		"bgroup_id", "timetable", "archived_at",
 	}).AddRow(
 		1, now, now, nil, "Test Banner", "Test Label", "Test Description", true,
-		"test-erid", "category", "targeting", "format", "kktu", 1000,
-		"{}", "{}", now, now.Add(24*time.Hour), "clicktracker", "imptracker", "target",
+		"test-erid", "category", "targeting", "format", "kktu", 1000, "cpc",
+		"{}", "{}", "{}", "[]", 15, 5, now, now.Add(24*time.Hour), "clicktracker", "imptracker", "target",
 		"targeting", "budget", "capping", 1, "timetable", &archivedTime,
 	)
 
@@ -333,7 +346,7 @@ func TestUnarchiveBanner(t *testing.T) {
 
 	// Mock the database calls for updating the banner
 	mock.ExpectBegin()
-	mock.ExpectExec(`UPDATE "banners" SET "id"=\$1,"created_at"=\$2,"updated_at"=\$3,"deleted_at"=\$4,"title"=\$5,"label"=\$6,"description"=\$7,"active"=\$8,"erid"=\$9,"ord_category"=\$10,"ord_targeting"=\$11,"ord_format"=\$12,"ord_kktu"=\$13,"price"=\$14,"image"=\$15,"icon"=\$16,"start"=\$17,"end"=\$18,"clicktracker"=\$19,"imptracker"=\$20,"target"=\$21,"targeting"=\$22,"budget"=\$23,"capping"=\$24,"bgroup_id"=\$25,"timetable"=\$26,"archived_at"=\$27 WHERE "banners"\."deleted_at" IS NULL AND "id" = \$28`).
+	mock.ExpectExec(`UPDATE "banners" SET "id"=\$1,"created_at"=\$2,"updated_at"=\$3,"deleted_at"=\$4,"title"=\$5,"label"=\$6,"description"=\$7,"active"=\$8,"erid"=\$9,"ord_category"=\$10,"ord_targeting"=\$11,"ord_format"=\$12,"ord_kktu"=\$13,"price"=\$14,"pricing"=\$15,"image"=\$16,"icon"=\$17,"video"=\$18,"media_files"=\$19,"duration"=\$20,"skip_offset"=\$21,"start"=\$22,"end"=\$23,"clicktracker"=\$24,"imptracker"=\$25,"target"=\$26,"targeting"=\$27,"budget"=\$28,"capping"=\$29,"bgroup_id"=\$30,"timetable"=\$31,"archived_at"=\$32 WHERE "banners"\."deleted_at" IS NULL AND "id" = \$33`).
 		WithArgs(
 			sqlmock.AnyArg(), // id
 			sqlmock.AnyArg(), // created_at
@@ -349,8 +362,13 @@ func TestUnarchiveBanner(t *testing.T) {
 			sqlmock.AnyArg(), // ord_format
 			sqlmock.AnyArg(), // ord_kktu
 			sqlmock.AnyArg(), // price
+			sqlmock.AnyArg(), // pricing
 			sqlmock.AnyArg(), // image
 			sqlmock.AnyArg(), // icon
+			sqlmock.AnyArg(), // video
+			sqlmock.AnyArg(), // media_files
+			sqlmock.AnyArg(), // duration
+			sqlmock.AnyArg(), // skip_offset
 			sqlmock.AnyArg(), // start
 			sqlmock.AnyArg(), // end
 			sqlmock.AnyArg(), // clicktracker
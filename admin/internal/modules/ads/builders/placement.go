@@ -27,7 +27,7 @@ func (n *Placement) Configure(b *presets.Builder) {
 		// Label("Рекламодатели").
 		RightDrawerWidth("1000")
 
-	mn.Listing("ID", "Name")
+	mn.Listing("ID", "Name", "Formats", "Floor", "Rewarded")
 
 	mn.Editing().ValidateFunc(func(obj interface{}, ctx *web.EventContext) (err web.ValidationErrors) {
 		u := obj.(*models.Placement)
@@ -35,6 +35,10 @@ func (n *Placement) Configure(b *presets.Builder) {
 		if u.Name == "" {
 			err.FieldError("Name", "Name is required")
 		}
+
+		if u.Rewarded && u.Callback != "" && u.Secret == "" {
+			err.FieldError("Secret", "Secret is required for callback")
+		}
 		return
 	})
 }
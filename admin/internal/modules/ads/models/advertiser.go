@@ -21,6 +21,7 @@ type Advertiser struct {
 	Budget    string
 	Capping   string
 	Timetable string
+	Timezone  string // например Europe/Moscow, пустой - UTC
 
 	OrdContract string
 
@@ -22,11 +22,18 @@ type Banner struct {
 	OrdFormat    string
 	OrdKktu      string
 
-	Price int
+	Price   int
+	Pricing string // cpm, cpc или cpa, пустая - cpm
 
 	Image media_library.MediaBox `sql:"type:text;"`
 	Icon  media_library.MediaBox `sql:"type:text;"`
 
+	// видеокреатив, длительность и пропуск в секундах
+	Video      media_library.MediaBox `sql:"type:text;"`
+	MediaFiles string
+	Duration   int
+	SkipOffset int
+
 	Start time.Time
 	End   time.Time
 
@@ -59,6 +66,7 @@ func (original Banner) Copy(db *gorm.DB, group int) (Banner, error) {
 		Budget:      original.Budget,
 		Capping:     original.Capping,
 		Price:       original.Price,
+		Pricing:     original.Pricing,
 		Active:      false,
 
 		Erid:         original.Erid,
@@ -70,6 +78,11 @@ func (original Banner) Copy(db *gorm.DB, group int) (Banner, error) {
 		Image: original.Image,
 		Icon:  original.Icon,
 
+		Video:      original.Video,
+		MediaFiles: original.MediaFiles,
+		Duration:   original.Duration,
+		SkipOffset: original.SkipOffset,
+
 		Clicktracker: original.Clicktracker,
 		Imptracker:   original.Imptracker,
 		Target:       original.Target,
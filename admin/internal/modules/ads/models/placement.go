@@ -7,5 +7,17 @@ type Placement struct {
 
 	Name string
 
+	Formats  string  // допустимые форматы через запятую: banner,native,video
+	Floor    float64 // минимальная цена
+	Timezone string  // часовой пояс для расписания, например Europe/Moscow
+
+	// наградная реклама: после досмотра видео сервер
+	// вызывает колбэк паблишера с подписью секретом
+	Rewarded     bool
+	RewardName   string // название награды, например coins
+	RewardAmount int
+	Callback     string // ссылка для server-side verification
+	Secret       string
+
 	Units []Unit `gorm:"many2many:placement_units;"`
 }
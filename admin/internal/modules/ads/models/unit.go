@@ -5,8 +5,9 @@ import "gorm.io/gorm"
 type Unit struct {
 	gorm.Model
 
-	Name      string
-	
+	Name   string // идентификатор блока в рекламной сети
+	Format string
+	Price  int
 
 	NetworkID int
 	Network   Network
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Часовой пояс, в котором заданы расписание и даты рекламодателя
+ALTER TABLE public.advertisers
+ADD COLUMN timezone text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+
+ALTER TABLE public.advertisers
+DROP COLUMN IF EXISTS timezone;
+-- +goose StatementEnd
@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Уведомляем сервер об изменениях баннеров и их родителей,
+-- чтобы кеш перезагружал только затронутое поддерево
+CREATE OR REPLACE FUNCTION public.banners_notify() RETURNS trigger AS $$
+DECLARE
+    row_id bigint;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        row_id := OLD.id;
+    ELSE
+        row_id := NEW.id;
+    END IF;
+
+    PERFORM pg_notify('banners', json_build_object(
+        'table', TG_TABLE_NAME,
+        'id', row_id::text,
+        'op', TG_OP
+    )::text);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER banners_notify AFTER INSERT OR UPDATE OR DELETE ON public.banners
+FOR EACH ROW EXECUTE FUNCTION public.banners_notify();
+
+CREATE TRIGGER bgroups_notify AFTER INSERT OR UPDATE OR DELETE ON public.bgroups
+FOR EACH ROW EXECUTE FUNCTION public.banners_notify();
+
+CREATE TRIGGER campaigns_notify AFTER INSERT OR UPDATE OR DELETE ON public.campaigns
+FOR EACH ROW EXECUTE FUNCTION public.banners_notify();
+
+CREATE TRIGGER advertisers_notify AFTER INSERT OR UPDATE OR DELETE ON public.advertisers
+FOR EACH ROW EXECUTE FUNCTION public.banners_notify();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+
+DROP TRIGGER IF EXISTS banners_notify ON public.banners;
+DROP TRIGGER IF EXISTS bgroups_notify ON public.bgroups;
+DROP TRIGGER IF EXISTS campaigns_notify ON public.campaigns;
+DROP TRIGGER IF EXISTS advertisers_notify ON public.advertisers;
+
+DROP FUNCTION IF EXISTS public.banners_notify();
+-- +goose StatementEnd
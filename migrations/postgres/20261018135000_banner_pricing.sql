@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Модель оплаты баннера: cpm, cpc или cpa, пустая - cpm
+ALTER TABLE public.banners
+ADD COLUMN pricing text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+
+ALTER TABLE public.banners
+DROP COLUMN IF EXISTS pricing;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Видеокреатив: загруженный файл, дополнительные кодировки,
+-- длительность и пропуск в секундах
+ALTER TABLE public.banners
+ADD COLUMN video text,
+ADD COLUMN media_files text,
+ADD COLUMN duration bigint,
+ADD COLUMN skip_offset bigint;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+
+ALTER TABLE public.banners
+DROP COLUMN IF EXISTS video,
+DROP COLUMN IF EXISTS media_files,
+DROP COLUMN IF EXISTS duration,
+DROP COLUMN IF EXISTS skip_offset;
+-- +goose StatementEnd
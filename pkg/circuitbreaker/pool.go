@@ -1,6 +1,8 @@
 package circuitbreaker
 
 import (
+	"sync"
+
 	"github.com/cep21/circuit/v4"
 	"github.com/cep21/circuit/v4/closers/hystrix"
 	"go.uber.org/zap"
@@ -9,13 +11,25 @@ import (
 type Pool struct {
 	logger  *zap.Logger
 	manager *circuit.Manager
+
+	lock    sync.RWMutex
+	configs map[string]*Config
 }
 
 func NewPool(logger *zap.Logger, metrics *Metrics, configs map[string]*Config) (*Pool, error) {
+	p := &Pool{
+		logger:  logger,
+		configs: make(map[string]*Config, len(configs)),
+	}
+
+	for name, c := range configs {
+		p.configs[name] = c
+	}
+
 	hystrixConf := hystrix.Factory{
 		CreateConfigureCloser: []func(circuitName string) hystrix.ConfigureCloser{
 			func(circuitName string) hystrix.ConfigureCloser {
-				c, ok := configs[circuitName]
+				c, ok := p.config(circuitName)
 				if !ok {
 					return hystrix.ConfigureCloser{}
 				}
@@ -30,7 +44,7 @@ func NewPool(logger *zap.Logger, metrics *Metrics, configs map[string]*Config) (
 
 		CreateConfigureOpener: []func(circuitName string) hystrix.ConfigureOpener{
 			func(circuitName string) hystrix.ConfigureOpener {
-				c, ok := configs[circuitName]
+				c, ok := p.config(circuitName)
 				if !ok {
 					return hystrix.ConfigureOpener{}
 				}
@@ -45,11 +59,11 @@ func NewPool(logger *zap.Logger, metrics *Metrics, configs map[string]*Config) (
 		},
 	}
 
-	manager := &circuit.Manager{
+	p.manager = &circuit.Manager{
 		DefaultCircuitProperties: []circuit.CommandPropertiesConstructor{
 			hystrixConf.Configure,
 			func(circuitName string) circuit.Config {
-				c, ok := configs[circuitName]
+				c, ok := p.config(circuitName)
 				if !ok {
 					return circuit.Config{}
 				}
@@ -60,15 +74,12 @@ func NewPool(logger *zap.Logger, metrics *Metrics, configs map[string]*Config) (
 	}
 
 	for name := range configs {
-		if _, err := manager.CreateCircuit(name); err != nil {
+		if _, err := p.manager.CreateCircuit(name); err != nil {
 			return nil, err
 		}
 	}
 
-	return &Pool{
-		logger:  logger,
-		manager: manager,
-	}, nil
+	return p, nil
 }
 
 func (p *Pool) Get(name string) Circuit {
@@ -83,3 +94,54 @@ func (p *Pool) Get(name string) Circuit {
 
 	return c
 }
+
+// Derive возвращает circuit name с настройками template и создает
+// его при первом обращении. Так у каждого партнера свой circuit,
+// а конфиг общий. Без конфига template вызовы идут без circuit
+func (p *Pool) Derive(name string, template string) Circuit {
+	if p == nil {
+		return &noopCircuit{}
+	}
+
+	if c := p.manager.GetCircuit(name); c != nil {
+		return c
+	}
+
+	p.lock.Lock()
+
+	c, ok := p.configs[template]
+	if ok {
+		if _, exists := p.configs[name]; !exists {
+			p.configs[name] = c
+		}
+	}
+
+	p.lock.Unlock()
+
+	if !ok {
+		return &noopCircuit{}
+	}
+
+	created, err := p.manager.CreateCircuit(name)
+	if err != nil {
+		// circuit успели создать в соседней горутине
+		if c := p.manager.GetCircuit(name); c != nil {
+			return c
+		}
+
+		p.logger.Warn("error on create circuit", zap.String("name", name), zap.Error(err))
+
+		return &noopCircuit{}
+	}
+
+	return created
+}
+
+func (p *Pool) config(name string) (*Config, bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	c, ok := p.configs[name]
+
+	return c, ok
+}
@@ -105,6 +105,43 @@ func TestPool_Get(t *testing.T) {
 	})
 }
 
+func TestPool_Derive(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	metrics := &Metrics{}
+
+	t.Run("derive from template", func(t *testing.T) {
+		pool, err := NewPool(logger, metrics, map[string]*Config{
+			"callbacks": NewConfig(),
+		})
+		require.NoError(t, err)
+
+		circuit := pool.Derive("callbacks.example.com", "callbacks")
+		assert.NotNil(t, circuit)
+		assert.NotEqual(t, &noopCircuit{}, circuit)
+
+		// повторный вызов отдает тот же circuit
+		assert.Same(t, circuit, pool.Derive("callbacks.example.com", "callbacks"))
+		assert.Same(t, circuit, pool.Get("callbacks.example.com"))
+
+		// у другого партнера свой circuit
+		assert.NotSame(t, circuit, pool.Derive("callbacks.example.org", "callbacks"))
+	})
+
+	t.Run("derive without template", func(t *testing.T) {
+		pool, err := NewPool(logger, metrics, nil)
+		require.NoError(t, err)
+
+		circuit := pool.Derive("callbacks.example.com", "callbacks")
+		assert.IsType(t, &noopCircuit{}, circuit)
+	})
+
+	t.Run("derive from nil pool", func(t *testing.T) {
+		var pool *Pool
+
+		assert.IsType(t, &noopCircuit{}, pool.Derive("callbacks.example.com", "callbacks"))
+	})
+}
+
 func TestNewPool_WithCircuitConfigurations(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	metrics := &Metrics{}
@@ -0,0 +1,31 @@
+package backends
+
+import (
+	"fmt"
+
+	"go.uber.org/fx"
+
+	"go.ads.coffee/platform/pkg/geoip"
+	"go.ads.coffee/platform/pkg/geoip/ip2location"
+	"go.ads.coffee/platform/pkg/geoip/maxmind"
+)
+
+var Module = fx.Module(
+	"geoip.backends",
+	fx.Provide(
+		New,
+	),
+)
+
+// New выбирает базу для geoip по конфигу,
+// по умолчанию используется ip2location
+func New(cfg geoip.Config) (geoip.Backend, error) {
+	switch cfg.Backend {
+	case "", geoip.BackendIP2Location:
+		return ip2location.New(cfg.Database)
+	case geoip.BackendMaxMind:
+		return maxmind.Open(cfg.Database)
+	default:
+		return nil, fmt.Errorf("unknown geoip backend: %s", cfg.Backend)
+	}
+}
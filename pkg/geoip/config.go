@@ -1,5 +1,17 @@
 package geoip
 
+const (
+	BackendIP2Location = "ip2location"
+	BackendMaxMind     = "maxmind"
+
+	defaultCacheSize = 100_000
+)
+
 type Config struct {
-	Database string `yaml:"database"`
+	// ip2location или maxmind
+	Backend string `yaml:"backend"`
+	// путь до файла базы, для ip2location можно
+	// не указывать - тогда берется встроенная база
+	Database  string `yaml:"database"`
+	CacheSize int    `yaml:"cache_size"`
 }
@@ -1,10 +1,19 @@
 package geoip
 
-import "go.uber.org/fx"
+import (
+	"context"
+
+	"go.uber.org/fx"
+)
 
 var Module = fx.Module(
 	"geoip",
 	fx.Provide(
 		New,
 	),
+	fx.Invoke(func(lc fx.Lifecycle, g *Geoip) {
+		lc.Append(fx.StopHook(func(ctx context.Context) error {
+			return g.Close()
+		}))
+	}),
 )
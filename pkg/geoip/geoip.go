@@ -1,37 +1,62 @@
 package geoip
 
 import (
-	"github.com/ip2location/ip2location-go/v9"
+	"net"
 )
 
+// Location - результат поиска по ip
+type Location struct {
+	Country string
+	Region  string
+	City    string
+}
+
+// Backend - база для поиска локации по ip.
+// Реализации лежат в ip2location и maxmind.
+type Backend interface {
+	Lookup(ip net.IP) (Location, error)
+	Close() error
+}
+
 type Geoip struct {
-	DBv4 *ip2location.DB
-	DBv6 *ip2location.DB
+	backend Backend
+	cache   *LRU[string, Location]
 }
 
-func New() (*Geoip, error) {
-	filev4, err := NewEmbeddedFile(location, "IP2LOCATION-LITE-DB3.BIN")
-	if err != nil {
-		panic(err)
+func New(cfg Config, backend Backend) *Geoip {
+	size := cfg.CacheSize
+	if size <= 0 {
+		size = defaultCacheSize
 	}
 
-	dbv4, err := ip2location.OpenDBWithReader(filev4)
-	if err != nil {
-		return nil, err
+	return &Geoip{
+		backend: backend,
+		cache:   NewLRU[string, Location](size),
 	}
+}
 
-	filev6, err := NewEmbeddedFile(location, "IP2LOCATION-LITE-DB3.IPV6.BIN")
-	if err != nil {
-		panic(err)
+// Lookup ищет локацию по ip. Результаты кешируются,
+// в том числе пустые, чтобы не ходить в базу повторно.
+func (g *Geoip) Lookup(ip string) (Location, bool) {
+	if location, ok := g.cache.Get(ip); ok {
+		return location, location != Location{}
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Location{}, false
 	}
 
-	dbv6, err := ip2location.OpenDBWithReader(filev6)
+	location, err := g.backend.Lookup(parsed)
 	if err != nil {
-		return nil, err
+		location = Location{}
 	}
 
-	return &Geoip{
-		DBv4: dbv4,
-		DBv6: dbv6,
-	}, nil
+	g.cache.Add(ip, location)
+
+	return location, location != Location{}
+}
+
+func (g *Geoip) Close() error {
+	return g.backend.Close()
 }
@@ -0,0 +1,85 @@
+package geoip
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBackend struct {
+	calls     int
+	locations map[string]Location
+}
+
+func (m *mockBackend) Lookup(ip net.IP) (Location, error) {
+	m.calls++
+
+	location, ok := m.locations[ip.String()]
+	if !ok {
+		return Location{}, errors.New("not found")
+	}
+
+	return location, nil
+}
+
+func (m *mockBackend) Close() error {
+	return nil
+}
+
+func TestGeoip_Lookup(t *testing.T) {
+	backend := &mockBackend{
+		locations: map[string]Location{
+			"1.2.3.4": {Country: "RU", Region: "Moscow", City: "Moscow"},
+		},
+	}
+
+	g := New(Config{CacheSize: 10}, backend)
+
+	location, ok := g.Lookup("1.2.3.4")
+	assert.True(t, ok)
+	assert.Equal(t, "RU", location.Country)
+
+	// второй раз берем из кеша
+	_, ok = g.Lookup("1.2.3.4")
+	assert.True(t, ok)
+	assert.Equal(t, 1, backend.calls)
+
+	// пустой результат тоже кешируется
+	_, ok = g.Lookup("8.8.8.8")
+	assert.False(t, ok)
+	_, ok = g.Lookup("8.8.8.8")
+	assert.False(t, ok)
+	assert.Equal(t, 2, backend.calls)
+
+	// невалидный ip не уходит в базу
+	_, ok = g.Lookup("invalid")
+	assert.False(t, ok)
+	assert.Equal(t, 2, backend.calls)
+}
+
+func TestLRU(t *testing.T) {
+	c := NewLRU[string, int](2)
+
+	c.Add("a", 1)
+	c.Add("b", 2)
+
+	// a становится самым свежим
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	c.Add("c", 3)
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+
+	c.Add("a", 10)
+	v, _ = c.Get("a")
+	assert.Equal(t, 10, v)
+	assert.Equal(t, 2, c.Len())
+}
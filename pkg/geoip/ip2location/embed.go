@@ -1,4 +1,4 @@
-package geoip
+package ip2location
 
 import "embed"
 
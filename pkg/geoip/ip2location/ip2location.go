@@ -0,0 +1,101 @@
+package ip2location
+
+import (
+	"net"
+
+	"github.com/ip2location/ip2location-go/v9"
+
+	"go.ads.coffee/platform/pkg/geoip"
+)
+
+const (
+	fileV4 = "IP2LOCATION-LITE-DB3.BIN"
+	fileV6 = "IP2LOCATION-LITE-DB3.IPV6.BIN"
+
+	// так ip2location отдает пустые поля
+	empty = "-"
+)
+
+// Backend ищет по базам IP2Location DB3 отдельно
+// для v4 и v6 адресов
+type Backend struct {
+	DBv4 *ip2location.DB
+	DBv6 *ip2location.DB
+}
+
+// New открывает встроенные базы. Если указан database,
+// то v4 и v6 адреса ищутся в одной базе из файла.
+func New(database string) (*Backend, error) {
+	if database != "" {
+		db, err := ip2location.OpenDB(database)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Backend{
+			DBv4: db,
+			DBv6: db,
+		}, nil
+	}
+
+	filev4, err := NewEmbeddedFile(location, fileV4)
+	if err != nil {
+		return nil, err
+	}
+
+	dbv4, err := ip2location.OpenDBWithReader(filev4)
+	if err != nil {
+		return nil, err
+	}
+
+	filev6, err := NewEmbeddedFile(location, fileV6)
+	if err != nil {
+		return nil, err
+	}
+
+	dbv6, err := ip2location.OpenDBWithReader(filev6)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Backend{
+		DBv4: dbv4,
+		DBv6: dbv6,
+	}, nil
+}
+
+func (b *Backend) Lookup(ip net.IP) (geoip.Location, error) {
+	db := b.DBv6
+	if ip.To4() != nil {
+		db = b.DBv4
+	}
+
+	record, err := db.Get_all(ip.String())
+	if err != nil {
+		return geoip.Location{}, err
+	}
+
+	return geoip.Location{
+		Country: value(record.Country_short),
+		Region:  value(record.Region),
+		City:    value(record.City),
+	}, nil
+}
+
+func (b *Backend) Close() error {
+	b.DBv4.Close()
+
+	if b.DBv6 != b.DBv4 {
+		b.DBv6.Close()
+	}
+
+	return nil
+}
+
+func value(v string) string {
+	if v == empty {
+		return ""
+	}
+
+	return v
+}
@@ -0,0 +1,93 @@
+package maxmind
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+
+	"go.ads.coffee/platform/pkg/geoip"
+)
+
+// Backend для баз MaxMind DB (.mmdb) в формате GeoIP2/GeoLite2
+// City. Базу читает github.com/oschwald/maxminddb-golang
+
+var (
+	ErrInvalidDatabase = errors.New("invalid maxmind database")
+	ErrNotFound        = errors.New("ip not found")
+)
+
+type Reader struct {
+	db *maxminddb.Reader
+}
+
+// record - поля записи City, которые нужны для geoip
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+}
+
+// Open читает базу из файла
+func Open(path string) (*Reader, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDatabase, err)
+	}
+
+	return &Reader{db: db}, nil
+}
+
+func FromBytes(buffer []byte) (*Reader, error) {
+	db, err := maxminddb.OpenBytes(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDatabase, err)
+	}
+
+	return &Reader{db: db}, nil
+}
+
+// Lookup ищет ip и возвращает страну, регион и город
+func (r *Reader) Lookup(ip net.IP) (geoip.Location, error) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return geoip.Location{}, fmt.Errorf("%w: invalid ip %q", ErrNotFound, ip)
+	}
+
+	result := r.db.Lookup(addr.Unmap())
+	if err := result.Err(); err != nil {
+		return geoip.Location{}, err
+	}
+
+	if !result.Found() {
+		return geoip.Location{}, ErrNotFound
+	}
+
+	rec := record{}
+	if err := result.Decode(&rec); err != nil {
+		return geoip.Location{}, err
+	}
+
+	location := geoip.Location{
+		Country: rec.Country.ISOCode,
+		City:    rec.City.Names["en"],
+	}
+
+	if len(rec.Subdivisions) > 0 {
+		location.Region = rec.Subdivisions[0].Names["en"]
+	}
+
+	return location, nil
+}
+
+func (r *Reader) Close() error {
+	return r.db.Close()
+}
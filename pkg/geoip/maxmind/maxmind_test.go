@@ -0,0 +1,161 @@
+package maxmind
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/pkg/geoip"
+)
+
+// простые энкодеры для сборки тестовой базы по спецификации
+// https://maxmind.github.io/MaxMind-DB/
+
+var metadataStart = []byte("\xAB\xCD\xEFMaxMind.com")
+
+const dataSectionSeparator = 16
+
+// типы данных в секции данных
+const (
+	typePointer = 1
+	typeString  = 2
+	typeUint16  = 5
+	typeUint32  = 6
+	typeMap     = 7
+	typeSlice   = 11
+)
+
+func encString(s string) []byte {
+	return append([]byte{byte(typeString<<5 | len(s))}, s...)
+}
+
+func encUint16(v uint16) []byte {
+	return []byte{byte(typeUint16<<5 | 2), byte(v >> 8), byte(v)}
+}
+
+func encUint32(v uint32) []byte {
+	return []byte{byte(typeUint32<<5 | 4), byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+func encMap(pairs ...[]byte) []byte {
+	b := []byte{byte(typeMap<<5 | len(pairs)/2)}
+	for _, p := range pairs {
+		b = append(b, p...)
+	}
+
+	return b
+}
+
+func encSlice(items ...[]byte) []byte {
+	b := []byte{byte(len(items)), typeSlice - 7}
+	for _, i := range items {
+		b = append(b, i...)
+	}
+
+	return b
+}
+
+func encPointer(p int) []byte {
+	return []byte{byte(typePointer<<5 | (p>>8)&0x7), byte(p)}
+}
+
+// database собирает v4 базу с одной сетью 1.2.3.0/24
+func database(t *testing.T) []byte {
+	t.Helper()
+
+	// строка лежит в начале секции данных и на нее
+	// ссылается указатель из записи
+	city := encString("Moscow")
+
+	data := append([]byte{}, city...)
+	data = append(data, encMap(
+		encString("country"), encMap(encString("iso_code"), encString("RU")),
+		encString("city"), encMap(encString("names"), encMap(encString("en"), encPointer(0))),
+		encString("subdivisions"), encSlice(encMap(encString("names"), encMap(encString("en"), encString("Moscow Oblast")))),
+	)...)
+
+	const nodeCount = 24
+
+	record := uint32(nodeCount + dataSectionSeparator + len(city))
+	prefix := net.ParseIP("1.2.3.0").To4()
+
+	tree := make([]byte, 0, nodeCount*6)
+	for i := 0; i < nodeCount; i++ {
+		bit := (prefix[i/8] >> (7 - i%8)) & 1
+
+		next := uint32(i + 1)
+		if i == nodeCount-1 {
+			next = record
+		}
+
+		records := [2]uint32{nodeCount, nodeCount}
+		records[bit] = next
+
+		for _, r := range records {
+			tree = append(tree, byte(r>>16), byte(r>>8), byte(r))
+		}
+	}
+
+	buffer := append(tree, make([]byte, dataSectionSeparator)...)
+	buffer = append(buffer, data...)
+	buffer = append(buffer, metadataStart...)
+	buffer = append(buffer, encMap(
+		encString("node_count"), encUint32(nodeCount),
+		encString("record_size"), encUint16(24),
+		encString("ip_version"), encUint16(4),
+		encString("binary_format_major_version"), encUint16(2),
+		encString("database_type"), encString("GeoLite2-City"),
+	)...)
+
+	return buffer
+}
+
+func TestReader_Lookup(t *testing.T) {
+	reader, err := FromBytes(database(t))
+	require.NoError(t, err)
+
+	location, err := reader.Lookup(net.ParseIP("1.2.3.4"))
+	require.NoError(t, err)
+
+	assert.Equal(t, geoip.Location{
+		Country: "RU",
+		Region:  "Moscow Oblast",
+		City:    "Moscow",
+	}, location)
+}
+
+func TestReader_Lookup_NotFound(t *testing.T) {
+	reader, err := FromBytes(database(t))
+	require.NoError(t, err)
+
+	_, err = reader.Lookup(net.ParseIP("8.8.8.8"))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// v6 адрес в v4 базе
+	_, err = reader.Lookup(net.ParseIP("2001:db8::1"))
+	assert.Error(t, err)
+}
+
+func TestFromBytes_Invalid(t *testing.T) {
+	_, err := FromBytes([]byte("not a database"))
+	assert.ErrorIs(t, err, ErrInvalidDatabase)
+
+	_, err = FromBytes(append(append([]byte{}, metadataStart...), encMap(
+		encString("node_count"), encUint32(1),
+		encString("record_size"), encUint16(20),
+	)...))
+	assert.ErrorIs(t, err, ErrInvalidDatabase)
+
+	// обрезанная база не должна ронять поиск
+	db := database(t)
+	for _, size := range []int{10, 100, len(db) - 10} {
+		reader, err := FromBytes(db[:size])
+		if err != nil {
+			continue
+		}
+
+		assert.NotPanics(t, func() { _, _ = reader.Lookup(net.ParseIP("1.2.3.4")) })
+	}
+}
@@ -0,0 +1,86 @@
+// Package ssv - подпись колбэков наградной рекламы (server-side
+// verification). Сервер вызывает колбэк паблишера после досмотра
+// видео, паблишер проверяет подпись и начисляет награду.
+//
+// Подпись - HMAC-SHA256 в hex от всех параметров колбэка, кроме
+// signature, отсортированных по имени и закодированных как query
+// string. Пакет использует только стандартную библиотеку, его можно
+// скопировать на сервер паблишера или повторить на другом языке.
+package ssv
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Параметры колбэка
+const (
+	ParamUserID        = "user_id"
+	ParamPlacementID   = "placement_id"
+	ParamRewardName    = "reward_name"
+	ParamRewardAmount  = "reward_amount"
+	ParamTransactionID = "transaction_id"
+	ParamTimestamp     = "timestamp"
+	ParamSignature     = "signature"
+)
+
+var (
+	ErrNoSecret         = errors.New("ssv secret is empty")
+	ErrInvalidSignature = errors.New("invalid ssv signature")
+	ErrExpired          = errors.New("ssv callback is expired")
+)
+
+// Sign считает подпись параметров. Параметр signature
+// в подпись не входит
+func Sign(values url.Values, secret string) string {
+	signed := url.Values{}
+	for k, v := range values {
+		if k != ParamSignature {
+			signed[k] = v
+		}
+	}
+
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(signed.Encode()))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify проверяет подпись колбэка. Если maxAge больше нуля,
+// колбэк старше maxAge отклоняется. Повторы одной награды
+// паблишер отсекает сам по transaction_id
+func Verify(values url.Values, secret string, maxAge time.Duration) error {
+	if secret == "" {
+		return ErrNoSecret
+	}
+
+	signature, err := hex.DecodeString(values.Get(ParamSignature))
+	if err != nil || len(signature) == 0 {
+		return ErrInvalidSignature
+	}
+
+	expected, _ := hex.DecodeString(Sign(values, secret))
+	if !hmac.Equal(signature, expected) {
+		return ErrInvalidSignature
+	}
+
+	if maxAge <= 0 {
+		return nil
+	}
+
+	ts, err := strconv.ParseInt(values.Get(ParamTimestamp), 10, 64)
+	if err != nil {
+		return ErrExpired
+	}
+
+	if time.Since(time.Unix(ts, 0)) > maxAge {
+		return ErrExpired
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package ssv
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func callback(ts time.Time) url.Values {
+	values := url.Values{
+		ParamUserID:        {"user-1"},
+		ParamPlacementID:   {"placement-1"},
+		ParamRewardName:    {"coins"},
+		ParamRewardAmount:  {"10"},
+		ParamTransactionID: {"tx-1"},
+		ParamTimestamp:     {strconv.FormatInt(ts.Unix(), 10)},
+	}
+
+	values.Set(ParamSignature, Sign(values, "secret"))
+
+	return values
+}
+
+func TestSign(t *testing.T) {
+	values := url.Values{"b": {"2"}, "a": {"1"}}
+
+	// значение можно проверить любой библиотекой HMAC-SHA256:
+	// echo -n "a=1&b=2" | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "604fe97c66c6393ff22e3cae366eee1131e351ebc736bf12f5d62e1755b7a233", Sign(values, "secret"))
+
+	// подпись не зависит от старой подписи в параметрах
+	values.Set(ParamSignature, "old")
+	assert.Equal(t, "604fe97c66c6393ff22e3cae366eee1131e351ebc736bf12f5d62e1755b7a233", Sign(values, "secret"))
+}
+
+func TestVerify(t *testing.T) {
+	values := callback(time.Now())
+
+	assert.NoError(t, Verify(values, "secret", time.Hour))
+	assert.NoError(t, Verify(values, "secret", 0))
+	assert.ErrorIs(t, Verify(values, "", 0), ErrNoSecret)
+	assert.ErrorIs(t, Verify(values, "other", 0), ErrInvalidSignature)
+
+	// награду подменили
+	values.Set(ParamRewardAmount, "1000")
+	assert.ErrorIs(t, Verify(values, "secret", 0), ErrInvalidSignature)
+
+	values = callback(time.Now())
+	values.Del(ParamSignature)
+	assert.ErrorIs(t, Verify(values, "secret", 0), ErrInvalidSignature)
+}
+
+func TestVerify_Expired(t *testing.T) {
+	values := callback(time.Now().Add(-2 * time.Hour))
+
+	assert.ErrorIs(t, Verify(values, "secret", time.Hour), ErrExpired)
+	assert.NoError(t, Verify(values, "secret", 0))
+}
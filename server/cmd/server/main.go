@@ -7,23 +7,36 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/urfave/cli/v3"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 
 	"go.ads.coffee/platform/pkg/circuitbreaker"
 	"go.ads.coffee/platform/pkg/database"
+	"go.ads.coffee/platform/pkg/geoip"
+	"go.ads.coffee/platform/pkg/geoip/backends"
 	"go.ads.coffee/platform/pkg/health"
 	"go.ads.coffee/platform/pkg/kafkapool"
 	"go.ads.coffee/platform/pkg/logger"
 	"go.ads.coffee/platform/pkg/redispool"
 	"go.ads.coffee/platform/pkg/telemetry"
 	"go.ads.coffee/platform/server/internal/analytics"
+	"go.ads.coffee/platform/server/internal/budgets"
+	"go.ads.coffee/platform/server/internal/capping"
 	"go.ads.coffee/platform/server/internal/config"
+	"go.ads.coffee/platform/server/internal/conversions"
+	"go.ads.coffee/platform/server/internal/decisions"
+	"go.ads.coffee/platform/server/internal/pipeline"
 	"go.ads.coffee/platform/server/internal/repos/banners"
 	"go.ads.coffee/platform/server/internal/repos/placements"
+	"go.ads.coffee/platform/server/internal/rewards"
 	"go.ads.coffee/platform/server/internal/server"
 	"go.ads.coffee/platform/server/internal/sessions"
+	"go.ads.coffee/platform/server/internal/stats"
+	"go.ads.coffee/platform/server/internal/tracking"
 	"go.ads.coffee/platform/server/plugins"
 )
 
+const defaultConfig = "server/configs/config.yaml"
+
 func main() {
 	cmd := &cli.Command{
 		Name: "kodikapusta",
@@ -35,48 +48,32 @@ func main() {
 				Name:    "serve",
 				Aliases: []string{"s"},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
+					file := configFile(cmd)
+
 					fx.New(
-						fx.Provide(
-							func() prometheus.Registerer {
-								// default prometheus
-								return prometheus.DefaultRegisterer
-							},
-						),
-						fx.Provide(
-							func() (config.Config, error) {
-								cfg := cmd.String("config")
-								if cfg == "" {
-									cfg = "server/configs/config.yaml"
-								}
-
-								return config.New(cfg)
-							},
-						),
-						logger.Module,
-						server.Module,
+						modules(file),
 						database.Module,
-						sessions.Module,
-						analytics.Module,
-						telemetry.Module,
-						health.Module,
-						circuitbreaker.Module,
-						redispool.Module,
-						kafkapool.Module,
-						plugins.Module,
-
-						// repos
-						banners.Module,
-						placements.Module,
+						backends.Module,
 
 						fx.Invoke(
 							start,
 							caches,
+							func(lc fx.Lifecycle, logger *zap.Logger, cfg config.Watch, manager *pipeline.Manager) {
+								watch(lc, logger, file, cfg, manager)
+							},
 						),
 					).Run()
 
 					return nil
 				},
 			},
+			{
+				Name:  "validate",
+				Usage: "check pipelines config without connecting to external services",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return validate(cmd.Root().Writer, configFile(cmd))
+				},
+			},
 		},
 	}
 
@@ -96,17 +93,119 @@ func main() {
 	// }
 }
 
-func start(lc fx.Lifecycle, server *server.Server) {
+func configFile(cmd *cli.Command) string {
+	file := cmd.String("config")
+	if file == "" {
+		file = defaultConfig
+	}
+
+	return file
+}
+
+// modules - все модули сервера, кроме подключения к базе и geoip,
+// которые нужны только при запуске
+func modules(file string) fx.Option {
+	return fx.Options(
+		fx.Provide(
+			func() prometheus.Registerer {
+				// default prometheus
+				return prometheus.DefaultRegisterer
+			},
+		),
+		fx.Provide(
+			func() (config.Config, error) {
+				return config.New(file)
+			},
+		),
+		logger.Module,
+		server.Module,
+		sessions.Module,
+		analytics.Module,
+		budgets.Module,
+		capping.Module,
+		conversions.Module,
+		decisions.Module,
+		stats.Module,
+		rewards.Module,
+		tracking.Module,
+		telemetry.Module,
+		health.Module,
+		circuitbreaker.Module,
+		redispool.Module,
+		kafkapool.Module,
+		geoip.Module,
+		plugins.Module,
+
+		// repos
+		banners.Module,
+		placements.Module,
+	)
+}
+
+func start(lc fx.Lifecycle, server *server.Server, health *health.Health) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			return server.Start(ctx)
 		},
 		OnStop: func(ctx context.Context) error {
+			// балансер перестает слать запросы до остановки сервера
+			health.SetReady(false)
+
 			return server.Shutdown(ctx)
 		},
 	})
 }
 
-func caches(banners *banners.Cache) {
-	go banners.Start(context.Background())
+// caches запускает обновление кешей. Сервер готов принимать
+// запросы только после первой загрузки баннеров и плейсментов,
+// статистику для ротации не ждем: до нее работают априорные оценки.
+func caches(
+	lc fx.Lifecycle,
+	banners *banners.Cache,
+	placements *placements.Cache,
+	stats *stats.Stats,
+	health *health.Health,
+) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			cancel()
+
+			return nil
+		},
+	})
+
+	go banners.Start(ctx)
+	go placements.Start(ctx)
+	go stats.Start(ctx)
+
+	go func() {
+		<-banners.Loaded()
+		<-placements.Loaded()
+
+		health.SetReady(true)
+	}()
+}
+
+// watch перезагружает пайплайны при изменении конфига или по SIGHUP
+func watch(lc fx.Lifecycle, logger *zap.Logger, file string, cfg config.Watch, manager *pipeline.Manager) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	watcher := config.NewWatcher(logger, file, cfg, func(cfg config.Config) error {
+		return manager.Reload(cfg.Pipelines)
+	})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go watcher.Start(ctx)
+
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+
+			return nil
+		},
+	})
 }
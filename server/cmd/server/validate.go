@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/urfave/cli/v3"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+
+	"go.ads.coffee/platform/pkg/geoip"
+	"go.ads.coffee/platform/server/internal/inputs"
+	"go.ads.coffee/platform/server/internal/outputs"
+	"go.ads.coffee/platform/server/internal/pipeline"
+	"go.ads.coffee/platform/server/internal/stages"
+	"go.ads.coffee/platform/server/internal/targetings"
+)
+
+// validate собирает реестры плагинов и проверяет пайплайны из конфига.
+// Сервер не запускается, вместо базы и geoip используются заглушки,
+// поэтому проверку можно запускать без окружения, например на ревью.
+func validate(out io.Writer, file string) error {
+	var (
+		cfg []pipeline.Config
+		ii  *inputs.Inputs
+		oo  *outputs.Outputs
+		ss  *stages.Stages
+		tt  *targetings.Targetings
+	)
+
+	app := fx.New(
+		fx.NopLogger,
+		modules(file),
+		offline,
+		fx.Populate(&cfg, &ii, &oo, &ss, &tt),
+	)
+
+	if err := app.Err(); err != nil {
+		return cli.Exit(fmt.Sprintf("%s: %v", file, err), 1)
+	}
+
+	if err := pipeline.Validate(cfg, ii, oo, ss, tt); err != nil {
+		errs := unwrap(err)
+
+		for _, e := range errs {
+			fmt.Fprintf(out, "%s: %v\n", file, e)
+		}
+
+		return cli.Exit(fmt.Sprintf("%s: %d problems found", file, len(errs)), 1)
+	}
+
+	fmt.Fprintf(out, "%s: %d pipelines ok\n", file, len(cfg))
+
+	return nil
+}
+
+// offline заменяет модули, которые подключаются к внешним сервисам
+// при создании. Запросы через них при проверке не выполняются.
+var offline = fx.Options(
+	fx.Supply(&gorm.DB{}),
+	fx.Provide(func() geoip.Backend { return noGeoip{} }),
+)
+
+type noGeoip struct{}
+
+func (noGeoip) Lookup(ip net.IP) (geoip.Location, error) {
+	return geoip.Location{}, nil
+}
+
+func (noGeoip) Close() error {
+	return nil
+}
+
+func unwrap(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint
+		return joined.Unwrap()
+	}
+
+	return []error{err}
+}
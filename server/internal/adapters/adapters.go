@@ -0,0 +1,36 @@
+package adapters
+
+import "go.ads.coffee/platform/server/internal/domain/plugins"
+
+type Adapters struct {
+	list map[string]plugins.Adapter
+}
+
+func New(list []plugins.Adapter) *Adapters {
+	adapters := map[string]plugins.Adapter{}
+	for _, adapter := range list {
+		adapters[adapter.Name()] = adapter
+	}
+
+	return &Adapters{
+		list: adapters,
+	}
+}
+
+// Get возвращает копию адаптера с конфигом сети,
+// false - адаптер не зарегистрирован
+func (a *Adapters) Get(name string, cfg map[string]any) (plugins.Adapter, bool) {
+	adapter, ok := a.list[name]
+	if !ok {
+		return nil, false
+	}
+
+	return adapter.Copy(cfg), true
+}
+
+// Has проверяет, что адаптер зарегистрирован
+func (a *Adapters) Has(name string) bool {
+	_, ok := a.list[name]
+
+	return ok
+}
@@ -0,0 +1,22 @@
+package adapters
+
+import (
+	"go.uber.org/fx"
+
+	"go.ads.coffee/platform/server/plugins/adapters"
+)
+
+var Module = fx.Module(
+	"adapters",
+
+	adapters.Module,
+
+	fx.Provide(
+		fx.Annotate(
+			New,
+			fx.ParamTags(
+				`group:"adapters"`,
+			),
+		),
+	),
+)
@@ -2,21 +2,45 @@ package analytics
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
 
 	"go.ads.coffee/platform/pkg/kafkapool"
 	"go.ads.coffee/platform/pkg/telemetry"
+	"go.ads.coffee/platform/server/internal/budgets"
+	"go.ads.coffee/platform/server/internal/capping"
+	"go.ads.coffee/platform/server/internal/conversions"
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 )
 
+type Budgets interface {
+	Spend(ctx context.Context, action string, event ads.Event) error
+}
+
+type Capping interface {
+	Record(ctx context.Context, action string, event ads.Event) error
+}
+
+type Conversions interface {
+	Record(ctx context.Context, action string, event ads.Event) error
+}
+
 type Analytics struct {
 	tel      *telemetry.Telemetry
 	producer *kafkapool.Producer
+	budgets  Budgets
+	capping  Capping
+	clicks   Conversions
 }
 
-func New(pool *kafkapool.Pool, tel *telemetry.Telemetry) (*Analytics, error) {
+func New(
+	pool *kafkapool.Pool,
+	tel *telemetry.Telemetry,
+	budgets *budgets.Budgets,
+	capping *capping.Capping,
+	clicks *conversions.Conversions,
+) (*Analytics, error) {
 	if err := tel.Register(actions, money); err != nil {
 		return nil, err
 	}
@@ -34,6 +58,9 @@ func New(pool *kafkapool.Pool, tel *telemetry.Telemetry) (*Analytics, error) {
 	return &Analytics{
 		tel:      tel,
 		producer: producer,
+		budgets:  budgets,
+		capping:  capping,
+		clicks:   clicks,
 	}, nil
 }
 
@@ -53,52 +80,39 @@ func (r *Analytics) Log(ctx context.Context, name string, event ads.Event) error
 		data,
 	)
 
-	return nil
+	// счетчики пишем независимо: ошибка бюджета
+	// не должна терять показ в каппинге и клик
+	var errs []error
+
+	// счетчики для лимитов
+	if err := r.budgets.Spend(ctx, name, event); err != nil {
+		errs = append(errs, fmt.Errorf("budgets error: %w", err))
+	}
+
+	if err := r.capping.Record(ctx, name, event); err != nil {
+		errs = append(errs, fmt.Errorf("capping error: %w", err))
+	}
+
+	// контекст клика для атрибуции постбеков
+	if err := r.clicks.Record(ctx, name, event); err != nil {
+		errs = append(errs, fmt.Errorf("conversions error: %w", err))
+	}
+
+	return errors.Join(errs...)
 }
 
 func (r *Analytics) LogRequest(ctx context.Context, state *plugins.State) error {
-	return r.Log(
-		ctx,
-		ads.ActionRequest,
-		ads.Event{
-			RequestID: state.RequestID,
-			Timestamp: time.Now().Unix(),
-			Action:    ads.ActionRequest,
-			GAID:      "",
-			OAID:      "",
-			// Country:   rc.Request.Country(),
-			// Region:    rc.Request.Region(),
-			// City:      rc.Request.City(),
-			// Network:   rc.Network,
-			// Make: rc.Request.Make(),
-		},
-	)
+	data := state.TrackerInfo(ads.Banner{})
+	data.Action = ads.ActionRequest
+
+	return r.Log(ctx, ads.ActionRequest, ads.Event(data))
 }
 
 func (r *Analytics) LogResponse(ctx context.Context, w ads.Banner, state *plugins.State) error {
-	return r.Log(
-		ctx,
-		ads.ActionResponse,
-		ads.Event{
-			RequestID: state.RequestID,
-			ClickID:   state.ClickID,
-			Timestamp: time.Now().Unix(),
-			Action:    ads.ActionResponse,
-
-			BannerID:     w.ID,
-			GroupID:      w.GroupID,
-			CampaignID:   w.CampaignID,
-			AdvertiserID: w.AdvertiserID,
-
-			GAID: "",
-			OAID: "",
-			// Country: rc.Request.Country(),
-			// Region:  rc.Request.Region(),
-			// City:    rc.Request.City(),
-			// Network:   rc.Network,
-			Price: float64(w.Price),
-		},
-	)
+	data := state.TrackerInfo(w)
+	data.Action = ads.ActionResponse
+
+	return r.Log(ctx, ads.ActionResponse, ads.Event(data))
 }
 
 func (r *Analytics) LogWin(ctx context.Context, data ads.TrackerInfo) error {
@@ -106,6 +120,20 @@ func (r *Analytics) LogWin(ctx context.Context, data ads.TrackerInfo) error {
 	return r.Log(ctx, ads.ActionWin, ads.Event(data))
 }
 
+func (r *Analytics) LogBilling(ctx context.Context, data ads.TrackerInfo) error {
+	money.WithLabelValues(
+		ads.ActionBilling,
+	).Add(data.Price)
+
+	data.Action = ads.ActionBilling
+	return r.Log(ctx, ads.ActionBilling, ads.Event(data))
+}
+
+func (r *Analytics) LogLoss(ctx context.Context, data ads.TrackerInfo) error {
+	data.Action = ads.ActionLoose
+	return r.Log(ctx, ads.ActionLoose, ads.Event(data))
+}
+
 func (r *Analytics) LogConversion(ctx context.Context, data ads.TrackerInfo) error {
 	data.Action = ads.ActionConversion
 	return r.Log(ctx, ads.ActionConversion, ads.Event(data))
@@ -124,3 +152,16 @@ func (r *Analytics) LogClick(ctx context.Context, data ads.TrackerInfo) error {
 	data.Action = ads.ActionCLick
 	return r.Log(ctx, ads.ActionCLick, ads.Event(data))
 }
+
+// LogVideo пишет события видеоплеера: старт, квартили,
+// досмотр, пропуск и ошибки VAST
+func (r *Analytics) LogVideo(ctx context.Context, action string, data ads.TrackerInfo) error {
+	data.Action = action
+	return r.Log(ctx, action, ads.Event(data))
+}
+
+// LogReward пишет выданную награду за досмотр видео
+func (r *Analytics) LogReward(ctx context.Context, data ads.TrackerInfo) error {
+	data.Action = ads.ActionReward
+	return r.Log(ctx, ads.ActionReward, ads.Event(data))
+}
@@ -0,0 +1,300 @@
+package budgets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"go.ads.coffee/platform/pkg/redispool"
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+// Уровни иерархии, на которых задаются бюджеты
+const (
+	LevelBanner     = "banner"
+	LevelGroup      = "group"
+	LevelCampaign   = "campaign"
+	LevelAdvertiser = "advertiser"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	// дневные счетчики нужны только на текущие сутки,
+	// запас на разницу часовых поясов
+	dailyTTL = 48 * time.Hour
+
+	day = 24 * time.Hour
+)
+
+// Budgets ведет счетчики показов, кликов, конверсий и денег
+// в redis и проверяет, не исчерпаны ли лимиты баннера.
+// Сутки считаются по локальному времени сервера.
+type Budgets struct {
+	redis *redispool.Redis
+	now   func() time.Time
+}
+
+func New(pool *redispool.Pool) (*Budgets, error) {
+	rds, err := pool.GetPool("main")
+	if err != nil {
+		return nil, fmt.Errorf("redis pool error: %w", err)
+	}
+
+	return &Budgets{
+		redis: rds,
+		now:   time.Now,
+	}, nil
+}
+
+// Spend увеличивает счетчики по событию на всех уровнях иерархии.
+// Показ в rtb подтверждается billing нотификацией, поэтому она
+// считается как показ. Цена в billing приходит за тысячу показов.
+func (b *Budgets) Spend(ctx context.Context, action string, event ads.Event) error {
+	incs := increments(action, event)
+	if len(incs) == 0 {
+		return nil
+	}
+
+	date := b.now().Format(dateLayout)
+	ids := levels(event)
+
+	return b.redis.Call(ctx, "budgets_spend", func(ctx context.Context, clu *redis.ClusterClient, kf redispool.KeyFormatter) error {
+		_, err := clu.Pipelined(ctx, func(p redis.Pipeliner) error {
+			for _, l := range ids {
+				if l.id == "" {
+					continue
+				}
+
+				for _, inc := range incs {
+					total := kf.FormatKey(TotalKey(l.level, inc.item, l.id))
+					daily := kf.FormatKey(DailyKey(l.level, inc.item, date, l.id))
+
+					p.IncrByFloat(ctx, total, inc.value)
+					p.IncrByFloat(ctx, daily, inc.value)
+					p.Expire(ctx, daily, dailyTTL)
+				}
+			}
+
+			return nil
+		})
+
+		return err
+	})
+}
+
+// Exhausted возвращает баннеры, у которых исчерпан хотя бы один
+// лимит, вместе с причиной. Счетчики всех кандидатов читаются
+// одним пайплайном.
+func (b *Budgets) Exhausted(ctx context.Context, banners []ads.Banner) (map[string]string, error) {
+	now := b.now()
+	date := now.Format(dateLayout)
+
+	all := map[string][]check{}
+	keys := []string{}
+	seen := map[string]struct{}{}
+
+	for _, banner := range banners {
+		cc := checks(banner, date)
+		if len(cc) == 0 {
+			continue
+		}
+
+		all[banner.ID] = cc
+
+		for _, c := range cc {
+			if _, ok := seen[c.key]; ok {
+				continue
+			}
+
+			seen[c.key] = struct{}{}
+			keys = append(keys, c.key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	values := map[string]float64{}
+
+	err := b.redis.Call(ctx, "budgets_exhausted", func(ctx context.Context, clu *redis.ClusterClient, kf redispool.KeyFormatter) error {
+		cmds := make([]*redis.StringCmd, len(keys))
+
+		// mget не работает с ключами из разных слотов,
+		// пайплайн кластерного клиента сам раскладывает команды по нодам
+		_, err := clu.Pipelined(ctx, func(p redis.Pipeliner) error {
+			for i, key := range keys {
+				cmds[i] = p.Get(ctx, kf.FormatKey(key))
+			}
+
+			return nil
+		})
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+
+		for i, cmd := range cmds {
+			v, err := cmd.Float64()
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+
+			if err != nil {
+				return fmt.Errorf("error on read counter %s: %w", keys[i], err)
+			}
+
+			values[keys[i]] = v
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exhausted := map[string]string{}
+
+	for id, cc := range all {
+		if ok, reason := evaluate(cc, values, now); !ok {
+			exhausted[id] = reason
+		}
+	}
+
+	return exhausted, nil
+}
+
+func TotalKey(level, item, id string) string {
+	return fmt.Sprintf(ads.LimitTotalKeyTemplate, level, item, id)
+}
+
+func DailyKey(level, item, date, id string) string {
+	return fmt.Sprintf(ads.LimitDailyKeyTemplate, level, item, date, id)
+}
+
+type level struct {
+	level  string
+	id     string
+	budget ads.Budget
+}
+
+func levels(event ads.Event) []level {
+	return []level{
+		{level: LevelBanner, id: event.BannerID},
+		{level: LevelGroup, id: event.GroupID},
+		{level: LevelCampaign, id: event.CampaignID},
+		{level: LevelAdvertiser, id: event.AdvertiserID},
+	}
+}
+
+type increment struct {
+	item  string
+	value float64
+}
+
+// increments - на сколько увеличить счетчики. Цена в событии
+// задана за тысячу показов, в бюджет списываем цену одного
+func increments(action string, event ads.Event) []increment {
+	switch action {
+	case ads.ActionImpression, ads.ActionBilling:
+		return []increment{
+			{item: ads.ActionImpression, value: 1},
+			{item: ads.ActionMoney, value: event.Price / 1000},
+		}
+	case ads.ActionCLick:
+		return []increment{{item: ads.ActionCLick, value: 1}}
+	case ads.ActionConversion:
+		return []increment{{item: ads.ActionConversion, value: 1}}
+	default:
+		return nil
+	}
+}
+
+type check struct {
+	key     string
+	reason  string
+	limit   float64
+	uniform bool
+}
+
+// checks собирает ключи счетчиков, для которых у баннера
+// задан ненулевой лимит
+func checks(banner ads.Banner, date string) []check {
+	ll := []level{
+		{level: LevelBanner, id: banner.ID, budget: banner.BannerBudget},
+		{level: LevelGroup, id: banner.GroupID, budget: banner.GroupBudget},
+		{level: LevelCampaign, id: banner.CampaignID, budget: banner.CampaignBudget},
+		{level: LevelAdvertiser, id: banner.AdvertiserID, budget: banner.AdvertiserBudget},
+	}
+
+	cc := []check{}
+
+	for _, l := range ll {
+		if l.id == "" {
+			continue
+		}
+
+		items := []struct {
+			item  string
+			limit ads.Limit
+		}{
+			{item: ads.ActionImpression, limit: l.budget.Impressions},
+			{item: ads.ActionCLick, limit: l.budget.Clicks},
+			{item: ads.ActionMoney, limit: l.budget.Money},
+			{item: ads.ActionConversion, limit: l.budget.Conversions},
+		}
+
+		for _, it := range items {
+			if it.limit.Total > 0 {
+				cc = append(cc, check{
+					key:    TotalKey(l.level, it.item, l.id),
+					reason: fmt.Sprintf("limits.%s.%s.total", l.level, it.item),
+					limit:  float64(it.limit.Total),
+				})
+			}
+
+			if it.limit.Daily > 0 {
+				cc = append(cc, check{
+					key:     DailyKey(l.level, it.item, date, l.id),
+					reason:  fmt.Sprintf("limits.%s.%s.daily", l.level, it.item),
+					limit:   float64(it.limit.Daily),
+					uniform: it.limit.Uniform,
+				})
+			}
+		}
+	}
+
+	return cc
+}
+
+// evaluate проверяет счетчики. При равномерном расходе дневной
+// лимит делится по минутам: к текущему моменту можно потратить
+// долю лимита, пропорциональную прошедшей части суток.
+func evaluate(cc []check, values map[string]float64, now time.Time) (bool, string) {
+	for _, c := range cc {
+		value := values[c.key]
+
+		if value >= c.limit {
+			return false, c.reason
+		}
+
+		if c.uniform && value >= Pace(c.limit, now) {
+			return false, c.reason + ".pacing"
+		}
+	}
+
+	return true, ""
+}
+
+// Pace возвращает часть дневного лимита, доступную к моменту now.
+// Текущая минута считается целиком, чтобы в начале суток
+// лимит не был нулевым.
+func Pace(limit float64, now time.Time) float64 {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	elapsed := now.Sub(start).Truncate(time.Minute) + time.Minute
+
+	return limit * float64(elapsed) / float64(day)
+}
@@ -0,0 +1,115 @@
+package budgets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+func TestKeys(t *testing.T) {
+	assert.Equal(t, "total:banner:impression:1", TotalKey(LevelBanner, ads.ActionImpression, "1"))
+	assert.Equal(t, "daily:group:impression:2025-12-06:3", DailyKey(LevelGroup, ads.ActionImpression, "2025-12-06", "3"))
+}
+
+func TestIncrements(t *testing.T) {
+	incs := increments(ads.ActionImpression, ads.Event{Price: 2000})
+	assert.Equal(t, []increment{
+		{item: ads.ActionImpression, value: 1},
+		{item: ads.ActionMoney, value: 2},
+	}, incs)
+
+	incs = increments(ads.ActionBilling, ads.Event{Price: 1500})
+	assert.Equal(t, []increment{
+		{item: ads.ActionImpression, value: 1},
+		{item: ads.ActionMoney, value: 1.5},
+	}, incs)
+
+	assert.Equal(t, []increment{{item: ads.ActionCLick, value: 1}}, increments(ads.ActionCLick, ads.Event{}))
+	assert.Nil(t, increments(ads.ActionRequest, ads.Event{}))
+}
+
+func TestIncrements_SameCPM(t *testing.T) {
+	// показ из web и billing биржи по одному CPM стоят одинаково
+	event := ads.Event{Price: 150}
+
+	assert.Equal(t, increments(ads.ActionBilling, event), increments(ads.ActionImpression, event))
+	assert.Equal(t, 0.15, increments(ads.ActionImpression, event)[1].value)
+}
+
+func TestChecks(t *testing.T) {
+	banner := ads.Banner{
+		ID:         "1",
+		CampaignID: "2",
+		BannerBudget: ads.Budget{
+			Impressions: ads.Limit{Total: 100},
+		},
+		CampaignBudget: ads.Budget{
+			Money: ads.Limit{Daily: 50, Uniform: true},
+		},
+		// без id уровень не проверяется
+		AdvertiserBudget: ads.Budget{
+			Clicks: ads.Limit{Total: 10},
+		},
+	}
+
+	cc := checks(banner, "2025-12-06")
+	require.Len(t, cc, 2)
+
+	assert.Equal(t, check{
+		key:    "total:banner:impression:1",
+		reason: "limits.banner.impression.total",
+		limit:  100,
+	}, cc[0])
+
+	assert.Equal(t, check{
+		key:     "daily:campaign:money:2025-12-06:2",
+		reason:  "limits.campaign.money.daily",
+		limit:   50,
+		uniform: true,
+	}, cc[1])
+}
+
+func TestEvaluate(t *testing.T) {
+	noon := time.Date(2025, 12, 6, 12, 0, 0, 0, time.UTC)
+
+	cc := []check{
+		{key: "total", reason: "total", limit: 100},
+		{key: "daily", reason: "daily", limit: 48, uniform: true},
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]float64
+		ok     bool
+		reason string
+	}{
+		{name: "empty", values: map[string]float64{}, ok: true},
+		{name: "under limits", values: map[string]float64{"total": 99, "daily": 20}, ok: true},
+		{name: "total exhausted", values: map[string]float64{"total": 100}, reason: "total"},
+		{name: "daily exhausted", values: map[string]float64{"daily": 48}, reason: "daily"},
+		{name: "pacing", values: map[string]float64{"daily": 25}, reason: "daily.pacing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason := evaluate(cc, tt.values, noon)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+func TestPace(t *testing.T) {
+	start := time.Date(2025, 12, 6, 0, 0, 30, 0, time.UTC)
+	assert.InDelta(t, 1440.0/1440, Pace(1440, start), 0.0001)
+
+	noon := time.Date(2025, 12, 6, 12, 0, 0, 0, time.UTC)
+	assert.InDelta(t, 721.0, Pace(1440, noon), 0.0001)
+
+	end := time.Date(2025, 12, 6, 23, 59, 59, 0, time.UTC)
+	assert.InDelta(t, 1440.0, Pace(1440, end), 0.0001)
+}
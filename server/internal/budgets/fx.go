@@ -0,0 +1,13 @@
+package budgets
+
+import (
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module(
+	"budgets",
+
+	fx.Provide(
+		New,
+	),
+)
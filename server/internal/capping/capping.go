@@ -0,0 +1,221 @@
+package capping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"go.ads.coffee/platform/pkg/redispool"
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/repos/banners"
+)
+
+// Уровни иерархии, на которых задается капинг
+const (
+	LevelBanner     = "banner"
+	LevelGroup      = "group"
+	LevelCampaign   = "campaign"
+	LevelAdvertiser = "advertiser"
+)
+
+// поля хеша со счетчиком, повторяют ads.CappingInfo
+const (
+	fieldCount    = "count"
+	fieldLastSeen = "last_seen"
+)
+
+type BannersCache interface {
+	One(ctx context.Context, id string) (ads.Banner, bool)
+}
+
+// Capping считает показы пользователю в redis. Счетчик живет
+// Period часов с первого показа, после чего начинается новое окно.
+type Capping struct {
+	redis   *redispool.Redis
+	banners BannersCache
+	now     func() time.Time
+}
+
+func New(pool *redispool.Pool, cache *banners.Cache) (*Capping, error) {
+	rds, err := pool.GetPool("main")
+	if err != nil {
+		return nil, fmt.Errorf("redis pool error: %w", err)
+	}
+
+	return &Capping{
+		redis:   rds,
+		banners: cache,
+		now:     time.Now,
+	}, nil
+}
+
+// Record записывает показ пользователю. Настройки капинга берутся
+// из кеша баннеров, потому что в событии их нет.
+func (c *Capping) Record(ctx context.Context, action string, event ads.Event) error {
+	if action != ads.ActionImpression && action != ads.ActionBilling {
+		return nil
+	}
+
+	if event.UserID == "" || event.BannerID == "" {
+		return nil
+	}
+
+	banner, ok := c.banners.One(ctx, event.BannerID)
+	if !ok {
+		return nil
+	}
+
+	cc := checks(event.UserID, banner)
+	if len(cc) == 0 {
+		return nil
+	}
+
+	now := c.now().Unix()
+
+	return c.redis.Call(ctx, "capping_record", func(ctx context.Context, clu *redis.ClusterClient, kf redispool.KeyFormatter) error {
+		_, err := clu.Pipelined(ctx, func(p redis.Pipeliner) error {
+			for _, cc := range cc {
+				key := kf.FormatKey(cc.key)
+
+				p.HIncrBy(ctx, key, fieldCount, 1)
+				p.HSet(ctx, key, fieldLastSeen, now)
+				// окно отсчитывается от первого показа
+				p.ExpireNX(ctx, key, cc.period)
+			}
+
+			return nil
+		})
+
+		return err
+	})
+}
+
+// Capped возвращает баннеры, которые пользователь уже видел
+// максимальное число раз, вместе с причиной
+func (c *Capping) Capped(ctx context.Context, uid string, banners []ads.Banner) (map[string]string, error) {
+	all := map[string][]check{}
+	keys := []string{}
+	seen := map[string]struct{}{}
+
+	for _, banner := range banners {
+		cc := checks(uid, banner)
+		if len(cc) == 0 {
+			continue
+		}
+
+		all[banner.ID] = cc
+
+		for _, c := range cc {
+			if _, ok := seen[c.key]; ok {
+				continue
+			}
+
+			seen[c.key] = struct{}{}
+			keys = append(keys, c.key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	counts := map[string]int64{}
+
+	err := c.redis.Call(ctx, "capping_capped", func(ctx context.Context, clu *redis.ClusterClient, kf redispool.KeyFormatter) error {
+		cmds := make([]*redis.StringCmd, len(keys))
+
+		_, err := clu.Pipelined(ctx, func(p redis.Pipeliner) error {
+			for i, key := range keys {
+				cmds[i] = p.HGet(ctx, kf.FormatKey(key), fieldCount)
+			}
+
+			return nil
+		})
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+
+		for i, cmd := range cmds {
+			v, err := cmd.Int64()
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+
+			if err != nil {
+				return fmt.Errorf("error on read capping %s: %w", keys[i], err)
+			}
+
+			counts[keys[i]] = v
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	capped := map[string]string{}
+
+	for id, cc := range all {
+		if ok, reason := evaluate(cc, counts); !ok {
+			capped[id] = reason
+		}
+	}
+
+	return capped, nil
+}
+
+func Key(uid, level, id string) string {
+	return fmt.Sprintf(ads.CappingKeyTemplate, uid, level, id)
+}
+
+type check struct {
+	key    string
+	reason string
+	count  int64
+	period time.Duration
+}
+
+// checks собирает ключи счетчиков для уровней с включенным капингом
+func checks(uid string, banner ads.Banner) []check {
+	ll := []struct {
+		level   string
+		id      string
+		capping ads.Capping
+	}{
+		{level: LevelBanner, id: banner.ID, capping: banner.BannerCapping},
+		{level: LevelGroup, id: banner.GroupID, capping: banner.GroupCapping},
+		{level: LevelCampaign, id: banner.CampaignID, capping: banner.CampaignCapping},
+		{level: LevelAdvertiser, id: banner.AdvertiserID, capping: banner.AdvertiserCapping},
+	}
+
+	cc := []check{}
+
+	for _, l := range ll {
+		if l.id == "" || !l.capping.Enabled() {
+			continue
+		}
+
+		cc = append(cc, check{
+			key:    Key(uid, l.level, l.id),
+			reason: "capping." + l.level,
+			count:  l.capping.Count,
+			period: l.capping.Duration(),
+		})
+	}
+
+	return cc
+}
+
+func evaluate(cc []check, counts map[string]int64) (bool, string) {
+	for _, c := range cc {
+		if counts[c.key] >= c.count {
+			return false, c.reason
+		}
+	}
+
+	return true, ""
+}
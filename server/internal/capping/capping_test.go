@@ -0,0 +1,63 @@
+package capping
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "user-1:campaign:3", Key("user-1", LevelCampaign, "3"))
+}
+
+func TestChecks(t *testing.T) {
+	banner := ads.Banner{
+		ID:              "1",
+		GroupID:         "2",
+		CampaignID:      "3",
+		BannerCapping:   ads.Capping{Count: 3, Period: 24},
+		GroupCapping:    ads.Capping{Count: 5},
+		CampaignCapping: ads.Capping{Count: 10, Period: 1},
+		// без id уровень не проверяется
+		AdvertiserCapping: ads.Capping{Count: 1, Period: 1},
+	}
+
+	cc := checks("user-1", banner)
+	require.Len(t, cc, 2)
+
+	assert.Equal(t, check{
+		key:    "user-1:banner:1",
+		reason: "capping.banner",
+		count:  3,
+		period: 24 * time.Hour,
+	}, cc[0])
+
+	assert.Equal(t, check{
+		key:    "user-1:campaign:3",
+		reason: "capping.campaign",
+		count:  10,
+		period: time.Hour,
+	}, cc[1])
+}
+
+func TestEvaluate(t *testing.T) {
+	cc := []check{
+		{key: "banner", reason: "capping.banner", count: 3},
+		{key: "campaign", reason: "capping.campaign", count: 10},
+	}
+
+	ok, reason := evaluate(cc, map[string]int64{})
+	assert.True(t, ok)
+	assert.Empty(t, reason)
+
+	ok, _ = evaluate(cc, map[string]int64{"banner": 2, "campaign": 9})
+	assert.True(t, ok)
+
+	ok, reason = evaluate(cc, map[string]int64{"banner": 2, "campaign": 10})
+	assert.False(t, ok)
+	assert.Equal(t, "capping.campaign", reason)
+}
@@ -0,0 +1,13 @@
+package capping
+
+import (
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module(
+	"capping",
+
+	fx.Provide(
+		New,
+	),
+)
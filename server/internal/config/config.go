@@ -8,17 +8,29 @@ import (
 
 	"go.ads.coffee/platform/pkg/circuitbreaker"
 	"go.ads.coffee/platform/pkg/database"
+	"go.ads.coffee/platform/pkg/geoip"
 	"go.ads.coffee/platform/pkg/health"
 	"go.ads.coffee/platform/pkg/kafkapool"
 	"go.ads.coffee/platform/pkg/redispool"
 	"go.ads.coffee/platform/pkg/telemetry"
+	"go.ads.coffee/platform/server/internal/conversions"
+	"go.ads.coffee/platform/server/internal/decisions"
 	"go.ads.coffee/platform/server/internal/pipeline"
+	"go.ads.coffee/platform/server/internal/repos/banners"
+	"go.ads.coffee/platform/server/internal/repos/placements"
+	"go.ads.coffee/platform/server/internal/rewards"
+	"go.ads.coffee/platform/server/internal/server"
+	"go.ads.coffee/platform/server/internal/sessions"
+	"go.ads.coffee/platform/server/internal/stats"
+	"go.ads.coffee/platform/server/internal/tracking"
 )
 
 type Config struct {
 	fx.Out
 
 	Pipelines []pipeline.Config `yaml:"pipelines"`
+	Server    server.Config     `yaml:"server"`
+	Watch     Watch             `yaml:"watch"`
 
 	Health         health.Config                     `yaml:"health"`
 	CircuitBreaker map[string]*circuitbreaker.Config `yaml:"circuit-breaker"`
@@ -26,6 +38,15 @@ type Config struct {
 	Telemetry      telemetry.Config                  `yaml:"telemetry"`
 	Database       database.Config                   `yaml:"database"`
 	Kafka          map[string]*kafkapool.Config      `yaml:"kafka-pool"`
+	Geoip          geoip.Config                      `yaml:"geoip"`
+	Placements     placements.Config                 `yaml:"placements"`
+	Tracking       tracking.Config                   `yaml:"tracking"`
+	Conversions    conversions.Config                `yaml:"conversions"`
+	Sessions       sessions.Config                   `yaml:"sessions"`
+	Decisions      decisions.Config                  `yaml:"decisions"`
+	Banners        banners.Config                    `yaml:"banners"`
+	Stats          stats.Config                      `yaml:"stats"`
+	Rewards        rewards.Config                    `yaml:"rewards"`
 }
 
 func New(file string) (Config, error) {
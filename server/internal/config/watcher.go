@@ -0,0 +1,123 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultWatchInterval = 10 * time.Second
+
+type Watch struct {
+	// следить за изменением файла конфига,
+	// по SIGHUP конфиг перечитывается всегда
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Watcher перечитывает файл конфига по SIGHUP или
+// при изменении файла и передает его в reload
+type Watcher struct {
+	logger   *zap.Logger
+	file     string
+	cfg      Watch
+	reload   func(cfg Config) error
+	modified time.Time
+}
+
+func NewWatcher(logger *zap.Logger, file string, cfg Watch, reload func(cfg Config) error) *Watcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultWatchInterval
+	}
+
+	w := &Watcher{
+		logger: logger,
+		file:   file,
+		cfg:    cfg,
+		reload: reload,
+	}
+
+	w.modified, _ = w.stat()
+
+	return w
+}
+
+// Start блокируется до отмены контекста
+func (w *Watcher) Start(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+
+	if w.cfg.Enabled {
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			w.logger.Info("reload config on signal", zap.String("file", w.file))
+			w.Reload()
+		case <-tick:
+			if w.changed() {
+				w.logger.Info("reload changed config", zap.String("file", w.file))
+				w.Reload()
+			}
+		}
+	}
+}
+
+// Reload читает конфиг и применяет его. При ошибке
+// продолжает работать старый конфиг.
+func (w *Watcher) Reload() {
+	cfg, err := New(w.file)
+	if err != nil {
+		w.logger.Error("error on read config, keep current", zap.String("file", w.file), zap.Error(err))
+
+		return
+	}
+
+	if err := w.reload(cfg); err != nil {
+		w.logger.Error("config rejected, keep current", zap.String("file", w.file), zap.Error(err))
+
+		return
+	}
+
+	w.logger.Info("config reloaded", zap.String("file", w.file))
+}
+
+func (w *Watcher) changed() bool {
+	modified, err := w.stat()
+	if err != nil {
+		w.logger.Warn("error on stat config", zap.String("file", w.file), zap.Error(err))
+
+		return false
+	}
+
+	if modified.Equal(w.modified) {
+		return false
+	}
+
+	w.modified = modified
+
+	return true
+}
+
+func (w *Watcher) stat() (time.Time, error) {
+	info, err := os.Stat(w.file)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return info.ModTime(), nil
+}
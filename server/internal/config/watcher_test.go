@@ -0,0 +1,97 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const watchConfig = `
+pipelines:
+  - name: %s
+    route: /test
+    input:
+      name: inputs.test
+    output:
+      name: outputs.test
+`
+
+type reloads struct {
+	mu    sync.Mutex
+	names []string
+	err   error
+}
+
+func (r *reloads) reload(cfg Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	r.names = append(r.names, cfg.Pipelines[0].Name)
+
+	return nil
+}
+
+func (r *reloads) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string{}, r.names...)
+}
+
+func writeConfig(t *testing.T, file, name string, modified time.Time) {
+	require.NoError(t, os.WriteFile(file, []byte(fmt.Sprintf(watchConfig, name)), 0o600))
+	require.NoError(t, os.Chtimes(file, modified, modified))
+}
+
+func TestWatcher_Changed(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, file, "first", time.Now().Add(-time.Hour))
+
+	r := &reloads{}
+	w := NewWatcher(zap.NewNop(), file, Watch{Enabled: true, Interval: 10 * time.Millisecond}, r.reload)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go w.Start(ctx)
+
+	writeConfig(t, file, "second", time.Now())
+
+	assert.Eventually(t, func() bool {
+		return len(r.get()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"second"}, r.get())
+}
+
+func TestWatcher_Reload_Errors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("pipelines: [\n"), 0o600))
+
+	r := &reloads{}
+	w := NewWatcher(zap.NewNop(), file, Watch{}, r.reload)
+
+	// невалидный yaml не доходит до reload
+	w.Reload()
+	assert.Empty(t, r.get())
+
+	// ошибка reload только логируется
+	writeConfig(t, file, "first", time.Now())
+	r.err = errors.New("invalid pipelines")
+
+	w.Reload()
+	assert.Empty(t, r.get())
+}
@@ -0,0 +1,11 @@
+package conversions
+
+import "time"
+
+const defaultTTL = 30 * 24 * time.Hour
+
+type Config struct {
+	// сколько хранится контекст клика. Окно атрибуции
+	// в inputs.postback не может быть больше
+	TTL time.Duration `yaml:"ttl"`
+}
@@ -0,0 +1,110 @@
+package conversions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"go.ads.coffee/platform/pkg/redispool"
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+const dedupKeyTemplate = "conversions:%s:%s" // click id, event
+
+// Conversions хранит контекст клика по click id,
+// чтобы атрибутировать постбеки трекеров
+type Conversions struct {
+	redis *redispool.Redis
+	ttl   time.Duration
+}
+
+func New(pool *redispool.Pool, cfg Config) (*Conversions, error) {
+	rds, err := pool.GetPool("main")
+	if err != nil {
+		return nil, fmt.Errorf("redis pool error: %w", err)
+	}
+
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+
+	return &Conversions{
+		redis: rds,
+		ttl:   ttl,
+	}, nil
+}
+
+// Record сохраняет клик вместе с данными показа.
+// Остальные события пропускаются.
+func (c *Conversions) Record(ctx context.Context, action string, event ads.Event) error {
+	if action != ads.ActionCLick || event.ClickID == "" {
+		return nil
+	}
+
+	data, err := event.JSON()
+	if err != nil {
+		return err
+	}
+
+	return c.redis.Call(ctx, "conversions_record", func(ctx context.Context, clu *redis.ClusterClient, kf redispool.KeyFormatter) error {
+		return clu.Set(ctx, kf.FormatKey(Key(event.ClickID)), data, c.ttl).Err()
+	})
+}
+
+// Click возвращает сохраненный клик. Если клика нет
+// или redis выключен, возвращается false.
+func (c *Conversions) Click(ctx context.Context, clickID string) (ads.TrackerInfo, bool, error) {
+	info := ads.TrackerInfo{}
+	found := false
+
+	err := c.redis.Call(ctx, "conversions_click", func(ctx context.Context, clu *redis.ClusterClient, kf redispool.KeyFormatter) error {
+		data, err := clu.Get(ctx, kf.FormatKey(Key(clickID))).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal(data, &info); err != nil {
+			return fmt.Errorf("invalid click %s: %w", clickID, err)
+		}
+
+		found = true
+
+		return nil
+	})
+
+	return info, found, err
+}
+
+// First возвращает true, если конверсия с таким событием
+// по клику пришла впервые
+func (c *Conversions) First(ctx context.Context, clickID, event string) (bool, error) {
+	first := true
+
+	err := c.redis.Call(ctx, "conversions_dedup", func(ctx context.Context, clu *redis.ClusterClient, kf redispool.KeyFormatter) error {
+		key := kf.FormatKey(fmt.Sprintf(dedupKeyTemplate, clickID, event))
+
+		ok, err := clu.SetNX(ctx, key, 1, c.ttl).Result()
+		if err != nil {
+			return err
+		}
+
+		first = ok
+
+		return nil
+	})
+
+	return first, err
+}
+
+func Key(clickID string) string {
+	return fmt.Sprintf(ads.ConversionKeyTemplate, clickID)
+}
@@ -0,0 +1,22 @@
+package conversions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "conversions:click-1", Key("click-1"))
+}
+
+func TestConversions_Record_SkipsOtherActions(t *testing.T) {
+	c := &Conversions{}
+
+	// без клика redis не вызывается
+	assert.NoError(t, c.Record(context.Background(), ads.ActionImpression, ads.Event{ClickID: "click-1"}))
+	assert.NoError(t, c.Record(context.Background(), ads.ActionCLick, ads.Event{}))
+}
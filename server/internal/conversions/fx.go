@@ -0,0 +1,13 @@
+package conversions
+
+import (
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module(
+	"conversions",
+
+	fx.Provide(
+		New,
+	),
+)
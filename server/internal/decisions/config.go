@@ -0,0 +1,12 @@
+package decisions
+
+const defaultTopic = "decisions"
+
+type Config struct {
+	// токен отладки из заголовка X-Debug-Token: с ним ответ и журнал
+	// решений возвращаются в JSON конверте. Пустой токен выключает отладку
+	Token string `yaml:"token"`
+	// доля запросов, журнал которых отправляется в kafka, от 0 до 1
+	Sample float64 `yaml:"sample"`
+	Topic  string  `yaml:"topic"`
+}
@@ -0,0 +1,220 @@
+package decisions
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"go.ads.coffee/platform/pkg/kafkapool"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+const (
+	// токен отладки передается только в заголовке,
+	// чтобы он не попадал в логи доступа и реферер
+	HeaderToken = "X-Debug-Token"
+)
+
+type Producer interface {
+	SendAsync(ctx context.Context, topic string, payload kafkapool.Payload)
+}
+
+// Record - журнал решений по одному запросу
+type Record struct {
+	Timestamp int64              `json:"timestamp"`
+	RequestID string             `json:"request_id"`
+	Pipeline  string             `json:"pipeline"`
+	Placement string             `json:"placement,omitempty"`
+	Network   string             `json:"network,omitempty"`
+	Winners   []string           `json:"winners"`
+	Decisions []plugins.Decision `json:"decisions"`
+}
+
+// Envelope - ответ на отладочный запрос: то, что ответил бы
+// выход, и журнал решений целиком. В заголовок журнал
+// не кладем, его режут nginx и балансировщики
+type Envelope struct {
+	Status    int         `json:"status"`
+	Header    http.Header `json:"header,omitempty"`
+	Body      string      `json:"body"`
+	Decisions Record      `json:"decisions"`
+}
+
+// Decisions включает журнал решений стейджей для отладочных
+// запросов и для случайной выборки, которая уходит в kafka
+type Decisions struct {
+	token    string
+	sample   float64
+	topic    string
+	producer Producer
+	random   func() float64
+}
+
+func New(cfg Config, pool *kafkapool.Pool) (*Decisions, error) {
+	d := &Decisions{
+		token:  cfg.Token,
+		sample: cfg.Sample,
+		topic:  cfg.Topic,
+		random: rand.Float64,
+	}
+
+	if d.topic == "" {
+		d.topic = defaultTopic
+	}
+
+	// без выборки kafka не нужна
+	if d.sample <= 0 {
+		return d, nil
+	}
+
+	kfk, err := pool.GetPool("main")
+	if err != nil {
+		return nil, fmt.Errorf("kafka pool error: %w", err)
+	}
+
+	producer, err := kafkapool.NewProducer(kfk)
+	if err != nil {
+		return nil, fmt.Errorf("kafka producer error: %w", err)
+	}
+
+	d.producer = producer
+
+	return d, nil
+}
+
+// Start заводит журнал, если запрос отлаживается или попал в выборку.
+// Для отладки ответ выхода копится в буфере, чтобы вернуть его
+// вместе с журналом.
+func (d *Decisions) Start(pipeline string, state *plugins.State) {
+	debug := d.debug(state.Request)
+	sampled := d.producer != nil && d.random() < d.sample
+
+	if !debug && !sampled {
+		return
+	}
+
+	state.Decisions = &plugins.DecisionLog{
+		Sampled: sampled,
+		Debug:   debug,
+	}
+
+	if debug {
+		state.Response = &writer{
+			ResponseWriter: state.Response,
+			header:         http.Header{},
+		}
+	}
+}
+
+// Finish отвечает на отладочный запрос конвертом с журналом
+// и отправляет журнал в kafka
+func (d *Decisions) Finish(ctx context.Context, pipeline string, state *plugins.State) {
+	if state.Decisions == nil {
+		return
+	}
+
+	if w, ok := state.Response.(*writer); ok {
+		w.reply(d.record(pipeline, state))
+	}
+
+	if !state.Decisions.Sampled {
+		return
+	}
+
+	data, err := json.Marshal(d.record(pipeline, state))
+	if err != nil {
+		return
+	}
+
+	d.producer.SendAsync(context.WithoutCancel(ctx), d.topic, data)
+}
+
+func (d *Decisions) debug(r *http.Request) bool {
+	if d.token == "" || r == nil {
+		return false
+	}
+
+	token := r.Header.Get(HeaderToken)
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(d.token)) == 1
+}
+
+func (d *Decisions) record(pipeline string, state *plugins.State) Record {
+	r := Record{
+		Timestamp: time.Now().Unix(),
+		RequestID: state.RequestID,
+		Pipeline:  pipeline,
+		Network:   state.Network,
+		Winners:   []string{},
+		Decisions: state.Decisions.Items(),
+	}
+
+	if state.Placement != nil {
+		r.Placement = state.Placement.ID
+	}
+
+	for _, w := range state.Winners {
+		r.Winners = append(r.Winners, w.ID)
+	}
+
+	return r
+}
+
+// writer копит ответ выхода, чтобы отдать его в конверте
+type writer struct {
+	http.ResponseWriter
+
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *writer) Header() http.Header {
+	return w.header
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.body.Write(b)
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+//nolint:errcheck
+func (w *writer) reply(r Record) {
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	data, err := json.Marshal(Envelope{
+		Status:    status,
+		Header:    w.header,
+		Body:      w.body.String(),
+		Decisions: r,
+	})
+	if err != nil {
+		w.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.ResponseWriter.Header().Set("Content-Type", "application/json")
+	w.ResponseWriter.Write(data)
+}
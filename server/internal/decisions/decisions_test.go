@@ -0,0 +1,198 @@
+package decisions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/pkg/kafkapool"
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+type mockProducer struct {
+	mock.Mock
+}
+
+func (m *mockProducer) SendAsync(ctx context.Context, topic string, payload kafkapool.Payload) {
+	m.Called(ctx, topic, payload)
+}
+
+func newState(target string, header string) *plugins.State {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if header != "" {
+		r.Header.Set(HeaderToken, header)
+	}
+
+	return &plugins.State{
+		RequestID: "request",
+		Request:   r,
+		Response:  httptest.NewRecorder(),
+		Placement: &plugins.Placement{ID: "placement"},
+	}
+}
+
+func decisions(t *testing.T, data string) Record {
+	t.Helper()
+
+	record := Record{}
+	require.NoError(t, json.Unmarshal([]byte(data), &record))
+
+	return record
+}
+
+func envelope(t *testing.T, recorder *httptest.ResponseRecorder) Envelope {
+	t.Helper()
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	e := Envelope{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &e))
+
+	return e
+}
+
+func TestDecisions_Debug(t *testing.T) {
+	d, err := New(Config{Token: "secret"}, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		debug  bool
+	}{
+		{name: "header", target: "/dsp", header: "secret", debug: true},
+		{name: "query", target: "/dsp?debug=secret", debug: false},
+		{name: "wrong token", target: "/dsp", header: "other", debug: false},
+		{name: "no token", target: "/dsp", debug: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newState(tt.target, tt.header)
+			recorder := state.Response.(*httptest.ResponseRecorder)
+
+			d.Start("dsp", state)
+
+			state.Drop("stages.limits", ads.Banner{ID: "1"}, plugins.ReasonBudget, "daily")
+			state.Winners = []ads.Banner{{ID: "2"}}
+			state.Response.Header().Set("Content-Type", "text/html")
+			state.Response.WriteHeader(http.StatusCreated)
+			state.Response.Write([]byte("<div>ad</div>"))
+
+			d.Finish(context.Background(), "dsp", state)
+
+			if !tt.debug {
+				assert.Nil(t, state.Decisions)
+				assert.Equal(t, http.StatusCreated, recorder.Code)
+				assert.Equal(t, "<div>ad</div>", recorder.Body.String())
+
+				return
+			}
+
+			// ответ выхода лежит в конверте рядом с журналом
+			e := envelope(t, recorder)
+			assert.Equal(t, http.StatusCreated, e.Status)
+			assert.Equal(t, "text/html", e.Header.Get("Content-Type"))
+			assert.Equal(t, "<div>ad</div>", e.Body)
+
+			record := e.Decisions
+			assert.Equal(t, "request", record.RequestID)
+			assert.Equal(t, "dsp", record.Pipeline)
+			assert.Equal(t, "placement", record.Placement)
+			assert.Equal(t, []string{"2"}, record.Winners)
+			assert.Equal(t, []plugins.Decision{
+				{BannerID: "1", Stage: "stages.limits", Reason: plugins.ReasonBudget, Details: "daily"},
+			}, record.Decisions)
+		})
+	}
+}
+
+func TestDecisions_Debug_NoWrite(t *testing.T) {
+	d, err := New(Config{Token: "secret"}, nil)
+	require.NoError(t, err)
+
+	state := newState("/dsp", "secret")
+	recorder := state.Response.(*httptest.ResponseRecorder)
+
+	d.Start("dsp", state)
+	d.Finish(context.Background(), "dsp", state)
+
+	// выход ничего не написал, конверт все равно есть
+	e := envelope(t, recorder)
+	assert.Equal(t, http.StatusOK, e.Status)
+	assert.Empty(t, e.Body)
+	assert.Empty(t, e.Decisions.Decisions)
+	assert.Empty(t, e.Decisions.Winners)
+}
+
+func TestDecisions_Debug_Large(t *testing.T) {
+	d, err := New(Config{Token: "secret"}, nil)
+	require.NoError(t, err)
+
+	state := newState("/dsp", "secret")
+	recorder := state.Response.(*httptest.ResponseRecorder)
+
+	d.Start("dsp", state)
+
+	for i := range 1000 {
+		state.Drop("stages.targeting", ads.Banner{ID: fmt.Sprint(i)}, plugins.ReasonTargeting, "targetings.geo")
+	}
+
+	state.Response.WriteHeader(http.StatusNoContent)
+	d.Finish(context.Background(), "dsp", state)
+
+	// в теле журнал не обрезается
+	e := envelope(t, recorder)
+	assert.Equal(t, http.StatusNoContent, e.Status)
+	assert.Len(t, e.Decisions.Decisions, 1000)
+}
+
+func TestDecisions_Sample(t *testing.T) {
+	producer := &mockProducer{}
+	producer.On("SendAsync", mock.Anything, "decisions", mock.Anything).Return()
+
+	d := &Decisions{
+		sample:   0.5,
+		topic:    defaultTopic,
+		producer: producer,
+	}
+
+	// не попал в выборку
+	d.random = func() float64 { return 0.7 }
+
+	state := newState("/dsp", "")
+	d.Start("dsp", state)
+	d.Finish(context.Background(), "dsp", state)
+
+	assert.Nil(t, state.Decisions)
+	producer.AssertNotCalled(t, "SendAsync", mock.Anything, mock.Anything, mock.Anything)
+
+	// попал в выборку
+	d.random = func() float64 { return 0.2 }
+
+	state = newState("/dsp", "")
+	d.Start("dsp", state)
+
+	state.Drop("stages.capping", ads.Banner{ID: "1"}, plugins.ReasonCapping, "day")
+	d.Finish(context.Background(), "dsp", state)
+
+	producer.AssertNumberOfCalls(t, "SendAsync", 1)
+
+	payload := producer.Calls[0].Arguments.Get(2).(kafkapool.Payload)
+	record := decisions(t, string(payload))
+	assert.Equal(t, "request", record.RequestID)
+	assert.Len(t, record.Decisions, 1)
+
+	// в выборке без отладки ответ не подменяется
+	assert.IsType(t, &httptest.ResponseRecorder{}, state.Response)
+}
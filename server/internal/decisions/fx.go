@@ -0,0 +1,13 @@
+package decisions
+
+import (
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module(
+	"decisions",
+
+	fx.Provide(
+		New,
+	),
+)
@@ -10,4 +10,16 @@ const (
 	ActionRequest    = "request"
 	ActionResponse   = "response"
 	ActionMoney      = "money"
+
+	// события видеоплеера из VAST
+	ActionStart         = "start"
+	ActionFirstQuartile = "first_quartile"
+	ActionMidpoint      = "midpoint"
+	ActionThirdQuartile = "third_quartile"
+	ActionComplete      = "complete"
+	ActionSkip          = "skip"
+	ActionError         = "error"
+
+	// награда за досмотр в наградном плейсменте
+	ActionReward = "reward"
 )
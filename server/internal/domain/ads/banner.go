@@ -1,7 +1,9 @@
 package ads
 
 import (
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,17 +14,35 @@ const (
 	CreativeTypeMediator = "mediator"
 )
 
+// Модели оплаты: за что платит рекламодатель
+const (
+	PricingCPM = "cpm"
+	PricingCPC = "cpc"
+	PricingCPA = "cpa"
+)
+
 type Banner struct {
 	ID     string
 	Title  string
 	Price  int
 	Active bool
 
+	// за что назначена Price: за тысячу показов, клик
+	// или конверсию. Пустая модель - CPM
+	Pricing string
+	// прогноз eCPM для CPC и CPA по CTR и CVR баннера,
+	// его считает ротация
+	Estimate float64
+
+	// цена за тысячу показов после аукциона, 0 - аукциона не было
+	ClearingPrice float64
+
 	Type    string
 	Network string
 
 	Targeting Targeting
 	Timetable Timetable
+	Location  *time.Location // часовой пояс рекламодателя, nil - не задан
 
 	BannerBudget     Budget
 	GroupBudget      Budget
@@ -36,6 +56,7 @@ type Banner struct {
 
 	Image        Image
 	Icon         Image
+	Video        Video
 	Clicktracker string
 	Imptracker   string
 	Target       string
@@ -46,6 +67,10 @@ type Banner struct {
 
 	Erid string
 
+	// готовая разметка креатива от сети медиации,
+	// клиент показывает ее вместо того, чтобы идти в сеть
+	Markup string
+
 	GroupID      string `gorm:"bgroup_id"`
 	CampaignID   string `gorm:"campaign_id"`
 	AdvertiserID string `gorm:"advertiser_id"`
@@ -63,6 +88,57 @@ type Banner struct {
 	AdvertiserEnd   time.Time `gorm:"advertiser_end"`
 }
 
+// ECPM - ставка баннера за тысячу показов. Для CPM это Price,
+// для CPC и CPA - прогноз ротации. Без прогноза такой баннер
+// ничего не стоит: нельзя ставить цену клика как цену показов
+func (b Banner) ECPM() float64 {
+	if b.CPM() {
+		return float64(b.Price)
+	}
+
+	return b.Estimate
+}
+
+// CPM - цена баннера задана за тысячу показов
+func (b Banner) CPM() bool {
+	return b.Pricing == "" || b.Pricing == PricingCPM
+}
+
+// Expected переводит цену баннера в eCPM по CTR и CVR
+func (b Banner) Expected(ctr, cvr float64) float64 {
+	price := float64(b.Price)
+
+	switch b.Pricing {
+	case PricingCPC:
+		return price * ctr * 1000
+	case PricingCPA:
+		return price * ctr * cvr * 1000
+	}
+
+	return price
+}
+
+// Charge - сколько списываем за тысячу показов:
+// цена аукциона, если он был, иначе ставка
+func (b Banner) Charge() float64 {
+	if b.ClearingPrice > 0 {
+		return b.ClearingPrice
+	}
+
+	return b.ECPM()
+}
+
+// Domain - домен рекламодателя из ссылки баннера, как его
+// ждут биржи в adomain и badv
+func (b Banner) Domain() string {
+	u, err := url.Parse(b.Target)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
 func (b Banner) PriceFormated() string {
 	return strconv.FormatFloat(float64(b.Price), 'f', -1, 64)
 }
@@ -1,14 +1,26 @@
 package ads
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 const CappingKeyTemplate = "%s:%s:%s" //  uid, item, id
 
+// Capping - не больше Count показов пользователю за Period часов
 type Capping struct {
 	Count  int64 `json:"count"`
 	Period int64 `json:"period"`
 }
 
+func (c Capping) Enabled() bool {
+	return c.Count > 0 && c.Period > 0
+}
+
+func (c Capping) Duration() time.Duration {
+	return time.Duration(c.Period) * time.Hour
+}
+
 func NewCapping(data string) (Capping, error) {
 	c := Capping{}
 
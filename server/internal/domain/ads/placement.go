@@ -4,5 +4,21 @@ package ads
 // быть настроена медиаця или он может использоваться
 // как ендпоинт для RTB
 type Placement struct {
-	ID string
+	ID       string
+	Name     string
+	Formats  []string
+	Floor    float64
+	Timezone string
+	Units    []Unit
+	// nil - плейсмент без награды
+	Reward *Reward
+}
+
+// Reward - награда за досмотр видео. Если задан колбэк,
+// сервер сообщает паблишеру о награде подписанным запросом
+type Reward struct {
+	Name     string
+	Amount   int
+	Callback string
+	Secret   string
 }
@@ -6,6 +6,8 @@ type TrackerInfo struct {
 	Timestamp    int64   `json:"timestamp"`
 	Action       string  `json:"action"`
 	RequestID    string  `json:"request_id"`
+	UserID       string  `json:"user_id,omitempty"`
+	PlacementID  string  `json:"placement_id,omitempty"`
 	ClickID      string  `json:"click_id"`
 	BannerID     string  `json:"banner_id"`
 	GroupID      string  `json:"group_id"`
@@ -21,4 +23,8 @@ type TrackerInfo struct {
 	Network      string  `json:"network"`
 	Size         string  `json:"size"`
 	Make         string  `json:"make"`
+	Reason       string  `json:"reason,omitempty"`
+	Event        string  `json:"event,omitempty"`
+	Revenue      float64 `json:"revenue,omitempty"`
+	Currency     string  `json:"currency,omitempty"`
 }
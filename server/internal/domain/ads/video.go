@@ -0,0 +1,89 @@
+package ads
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+	"time"
+)
+
+// типы файлов, которые понимают видеоплееры
+var mediaTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".webm": "video/webm",
+	".ogv":  "video/ogg",
+	".mov":  "video/quicktime",
+	".m3u8": "application/x-mpegURL",
+	".mpd":  "application/dash+xml",
+}
+
+// Video - видеокреатив баннера
+type Video struct {
+	MediaFiles []MediaFile
+	Duration   time.Duration
+	// через сколько можно пропустить, 0 - пропустить нельзя
+	SkipOffset time.Duration
+}
+
+// MediaFile - одна кодировка видео
+type MediaFile struct {
+	URL    string
+	Type   string
+	Width  int
+	Height int
+}
+
+// Streaming - файл отдается плейлистом HLS или DASH
+func (f MediaFile) Streaming() bool {
+	return f.Type == mediaTypes[".m3u8"] || f.Type == mediaTypes[".mpd"]
+}
+
+type video struct {
+	Url    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// NewVideo собирает видео из загруженного файла и дополнительных
+// кодировок, по одной ссылке на строку. Длительность и пропуск в секундах
+func NewVideo(data string, files string, duration int, skip int) (Video, error) {
+	v := Video{
+		Duration:   time.Duration(duration) * time.Second,
+		SkipOffset: time.Duration(skip) * time.Second,
+	}
+
+	main := video{}
+
+	if data != "" {
+		if err := json.Unmarshal([]byte(data), &main); err != nil {
+			return Video{}, err
+		}
+	}
+
+	if main.Url != "" {
+		v.MediaFiles = append(v.MediaFiles, newMediaFile(main.Url, main.Width, main.Height))
+	}
+
+	for _, line := range strings.Split(files, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			v.MediaFiles = append(v.MediaFiles, newMediaFile(line, main.Width, main.Height))
+		}
+	}
+
+	return v, nil
+}
+
+func newMediaFile(link string, width, height int) MediaFile {
+	kind, ok := mediaTypes[strings.ToLower(path.Ext(strings.SplitN(link, "?", 2)[0]))]
+	if !ok {
+		kind = mediaTypes[".mp4"]
+	}
+
+	return MediaFile{
+		URL:    Image{Url: link}.Full(""),
+		Type:   kind,
+		Width:  width,
+		Height: height,
+	}
+}
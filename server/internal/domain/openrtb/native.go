@@ -0,0 +1,98 @@
+package openrtb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Типы картинок и данных в нативном запросе (Native 1.2)
+const (
+	NativeImageIcon = 1
+	NativeImageMain = 3
+
+	NativeDataSponsored = 1
+	NativeDataDesc      = 2
+	NativeDataCTA       = 12
+)
+
+// NativeRequest - содержимое imp.native.request
+type NativeRequest struct {
+	Ver    string        `json:"ver,omitempty"`
+	Assets []NativeAsset `json:"assets"`
+}
+
+type NativeAsset struct {
+	ID       int64        `json:"id"`
+	Required int8         `json:"required,omitempty"`
+	Title    *NativeTitle `json:"title,omitempty"`
+	Img      *NativeImage `json:"img,omitempty"`
+	Data     *NativeData  `json:"data,omitempty"`
+}
+
+type NativeTitle struct {
+	Len  int64  `json:"len,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+type NativeImage struct {
+	Type int64  `json:"type,omitempty"`
+	URL  string `json:"url,omitempty"`
+	W    int64  `json:"w,omitempty"`
+	H    int64  `json:"h,omitempty"`
+}
+
+type NativeData struct {
+	Type  int64  `json:"type,omitempty"`
+	Len   int64  `json:"len,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+type NativeLink struct {
+	URL           string   `json:"url"`
+	ClickTrackers []string `json:"clicktrackers,omitempty"`
+}
+
+// NativeResponse - разметка, которая отдается в bid.adm
+type NativeResponse struct {
+	Ver         string        `json:"ver,omitempty"`
+	Assets      []NativeAsset `json:"assets"`
+	Link        NativeLink    `json:"link"`
+	ImpTrackers []string      `json:"imptrackers,omitempty"`
+}
+
+// ParseNative разбирает строку из imp.native.request.
+// Версия 1.0 оборачивает запрос в объект native.
+func ParseNative(data string) (*NativeRequest, error) {
+	wrapper := struct {
+		Native *NativeRequest `json:"native"`
+	}{}
+
+	if err := json.Unmarshal([]byte(data), &wrapper); err != nil {
+		return nil, fmt.Errorf("error on decode native request: %w", err)
+	}
+
+	if wrapper.Native != nil {
+		return wrapper.Native, nil
+	}
+
+	req := &NativeRequest{}
+	if err := json.Unmarshal([]byte(data), req); err != nil {
+		return nil, fmt.Errorf("error on decode native request: %w", err)
+	}
+
+	return req, nil
+}
+
+// Empty проверяет, что в ассете нечего показывать
+func (a NativeAsset) Empty() bool {
+	switch {
+	case a.Title != nil:
+		return a.Title.Text == ""
+	case a.Img != nil:
+		return a.Img.URL == ""
+	case a.Data != nil:
+		return a.Data.Value == ""
+	}
+
+	return true
+}
@@ -0,0 +1,16 @@
+package openrtb
+
+// Коды причин отказа от ставки (No-Bid Reason Codes, OpenRTB 5.24)
+const (
+	NbrUnknown           = 0
+	NbrTechnicalError    = 1
+	NbrInvalidRequest    = 2
+	NbrKnownWebSpider    = 3
+	NbrSuspectedNonHuman = 4
+	NbrDataCenterIP      = 5
+	NbrUnsupportedDevice = 6
+	NbrBlockedPublisher  = 7
+	NbrUnmatchedUser     = 8
+	NbrDailyReaderCap    = 9
+	NbrDailyDomainCap    = 10
+)
@@ -0,0 +1,201 @@
+package openrtb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Ключи таргетинга, которые Prebid передает в ad server
+const (
+	TargetingPriceBucket = "hb_pb"
+	TargetingBidder      = "hb_bidder"
+	TargetingSize        = "hb_size"
+	TargetingCacheID     = "hb_cache_id"
+	TargetingFormat      = "hb_format"
+)
+
+// Типы ставок в ext.prebid.type
+const (
+	PrebidBanner = "banner"
+	PrebidVideo  = "video"
+	PrebidNative = "native"
+)
+
+var ErrInvalidGranularity = errors.New("invalid price granularity")
+
+// PrebidExt - ext запроса от Prebid Server
+type PrebidExt struct {
+	Prebid *PrebidRequest `json:"prebid,omitempty"`
+}
+
+type PrebidRequest struct {
+	Targeting *PrebidTargeting `json:"targeting,omitempty"`
+}
+
+type PrebidTargeting struct {
+	// строка с именем или объект с диапазонами
+	PriceGranularity json.RawMessage `json:"pricegranularity,omitempty"`
+}
+
+// PrebidImpExt - ext imp: параметры бидера приходят
+// в prebid.bidder.<имя бидера>, старые версии Prebid
+// Server кладут их в bidder
+type PrebidImpExt struct {
+	Bidder json.RawMessage `json:"bidder,omitempty"`
+	Prebid *struct {
+		Bidder map[string]json.RawMessage `json:"bidder,omitempty"`
+	} `json:"prebid,omitempty"`
+}
+
+// Params возвращает параметры бидера name. Если в запросе есть
+// prebid.bidder, то bidder не смотрим: он может быть чужим
+func (e PrebidImpExt) Params(name string) (json.RawMessage, bool) {
+	if e.Prebid != nil && len(e.Prebid.Bidder) > 0 {
+		params, ok := e.Prebid.Bidder[name]
+
+		return params, ok
+	}
+
+	if len(e.Bidder) > 0 {
+		return e.Bidder, true
+	}
+
+	return nil, false
+}
+
+// PrebidBidExt - ext ставки в ответе для Prebid Server
+type PrebidBidExt struct {
+	Prebid PrebidBid `json:"prebid"`
+}
+
+type PrebidBid struct {
+	Type      string            `json:"type"`
+	Targeting map[string]string `json:"targeting,omitempty"`
+	Cache     *PrebidBidCache   `json:"cache,omitempty"`
+}
+
+type PrebidBidCache struct {
+	Bids *PrebidCacheInfo `json:"bids,omitempty"`
+}
+
+type PrebidCacheInfo struct {
+	URL     string `json:"url"`
+	CacheID string `json:"cacheId"`
+}
+
+// Granularity - правила округления цены до корзины hb_pb
+type Granularity struct {
+	Precision int                `json:"precision"`
+	Ranges    []GranularityRange `json:"ranges"`
+}
+
+type GranularityRange struct {
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+	Increment float64 `json:"increment"`
+}
+
+// Стандартные гранулярности Prebid
+var granularities = map[string]Granularity{
+	"low":    {Precision: 2, Ranges: []GranularityRange{{Max: 5, Increment: 0.5}}},
+	"medium": {Precision: 2, Ranges: []GranularityRange{{Max: 20, Increment: 0.1}}},
+	"med":    {Precision: 2, Ranges: []GranularityRange{{Max: 20, Increment: 0.1}}},
+	"high":   {Precision: 2, Ranges: []GranularityRange{{Max: 20, Increment: 0.01}}},
+	"auto": {Precision: 2, Ranges: []GranularityRange{
+		{Max: 5, Increment: 0.05},
+		{Min: 5, Max: 10, Increment: 0.1},
+		{Min: 10, Max: 20, Increment: 0.5},
+	}},
+	"dense": {Precision: 2, Ranges: []GranularityRange{
+		{Max: 3, Increment: 0.01},
+		{Min: 3, Max: 8, Increment: 0.05},
+		{Min: 8, Max: 20, Increment: 0.5},
+	}},
+	// стандартные шкалы в долларах, для рублей потолок 20 слишком низкий
+	"rub": {Precision: 2, Ranges: []GranularityRange{
+		{Max: 100, Increment: 1},
+		{Min: 100, Max: 500, Increment: 5},
+		{Min: 500, Max: 2000, Increment: 10},
+	}},
+}
+
+// DefaultGranularity - medium, как в Prebid Server
+func DefaultGranularity() Granularity {
+	return granularities["medium"]
+}
+
+// LookupGranularity - гранулярность по имени
+func LookupGranularity(name string) (Granularity, bool) {
+	g, ok := granularities[name]
+
+	return g, ok
+}
+
+// ParseGranularity разбирает pricegranularity из запроса.
+// Пустое значение - гранулярность по умолчанию
+func ParseGranularity(data json.RawMessage) (Granularity, error) {
+	if len(data) == 0 {
+		return DefaultGranularity(), nil
+	}
+
+	name := ""
+	if err := json.Unmarshal(data, &name); err == nil {
+		g, ok := granularities[name]
+		if !ok {
+			return Granularity{}, fmt.Errorf("%w: %s", ErrInvalidGranularity, name)
+		}
+
+		return g, nil
+	}
+
+	g := Granularity{Precision: 2}
+	if err := json.Unmarshal(data, &g); err != nil {
+		return Granularity{}, fmt.Errorf("%w: %w", ErrInvalidGranularity, err)
+	}
+
+	if len(g.Ranges) == 0 {
+		return Granularity{}, fmt.Errorf("%w: no ranges", ErrInvalidGranularity)
+	}
+
+	for _, r := range g.Ranges {
+		if r.Increment <= 0 || r.Max <= r.Min {
+			return Granularity{}, fmt.Errorf("%w: range %v-%v", ErrInvalidGranularity, r.Min, r.Max)
+		}
+	}
+
+	return g, nil
+}
+
+// Bucket округляет цену вниз до шага диапазона. Цена выше
+// последнего диапазона попадает в его максимум
+func (g Granularity) Bucket(price float64) string {
+	if price <= 0 || len(g.Ranges) == 0 {
+		return ""
+	}
+
+	last := g.Ranges[len(g.Ranges)-1]
+	if price >= last.Max {
+		return g.format(last.Max)
+	}
+
+	for _, r := range g.Ranges {
+		if price < r.Min || price >= r.Max {
+			continue
+		}
+
+		// поправка на погрешность float, чтобы 0.3 не стало 0.29
+		steps := math.Floor((price-r.Min)/r.Increment + 1e-9)
+
+		return g.format(r.Min + steps*r.Increment)
+	}
+
+	// цена в промежутке между диапазонами
+	return ""
+}
+
+func (g Granularity) format(v float64) string {
+	return strconv.FormatFloat(v, 'f', g.Precision, 64)
+}
@@ -0,0 +1,79 @@
+package openrtb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGranularity_Bucket(t *testing.T) {
+	tests := []struct {
+		name   string
+		g      string
+		price  float64
+		bucket string
+	}{
+		{name: "medium", g: `"medium"`, price: 1.87, bucket: "1.80"},
+		{name: "medium exact", g: `"medium"`, price: 0.3, bucket: "0.30"},
+		{name: "medium cap", g: `"medium"`, price: 35, bucket: "20.00"},
+		{name: "low", g: `"low"`, price: 1.87, bucket: "1.50"},
+		{name: "high", g: `"high"`, price: 1.879, bucket: "1.87"},
+		{name: "auto second range", g: `"auto"`, price: 7.27, bucket: "7.20"},
+		{name: "dense third range", g: `"dense"`, price: 9.9, bucket: "9.50"},
+		{name: "zero", g: `"medium"`, price: 0, bucket: ""},
+		{
+			name:   "custom",
+			g:      `{"precision":0,"ranges":[{"min":0,"max":1000,"increment":50}]}`,
+			price:  377.5,
+			bucket: "350",
+		},
+		{name: "default", g: ``, price: 2.55, bucket: "2.50"},
+		{name: "rub", g: `"rub"`, price: 15.7, bucket: "15.00"},
+		{name: "rub second range", g: `"rub"`, price: 237, bucket: "235.00"},
+		{name: "rub cap", g: `"rub"`, price: 5000, bucket: "2000.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := ParseGranularity(json.RawMessage(tt.g))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.bucket, g.Bucket(tt.price))
+		})
+	}
+}
+
+func TestParseGranularity_Invalid(t *testing.T) {
+	for _, data := range []string{`"ultra"`, `{"ranges":[]}`, `{"ranges":[{"min":1,"max":1,"increment":0.1}]}`, `[1]`} {
+		_, err := ParseGranularity(json.RawMessage(data))
+		assert.ErrorIs(t, err, ErrInvalidGranularity, data)
+	}
+}
+
+func TestPrebidImpExt_Params(t *testing.T) {
+	ext := PrebidImpExt{}
+	require.NoError(t, json.Unmarshal([]byte(`{"bidder":{"placement":"1"}}`), &ext))
+
+	params, ok := ext.Params("adscoffee")
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"placement":"1"}`, string(params))
+
+	ext = PrebidImpExt{}
+	require.NoError(t, json.Unmarshal([]byte(`{"prebid":{"bidder":{"adscoffee":{"placement":"2"}}}}`), &ext))
+
+	params, ok = ext.Params("adscoffee")
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"placement":"2"}`, string(params))
+
+	_, ok = ext.Params("other")
+	assert.False(t, ok)
+
+	// bidder другого бидера, наших параметров в prebid.bidder нет
+	ext = PrebidImpExt{}
+	require.NoError(t, json.Unmarshal([]byte(`{"bidder":{"placement":"3"},"prebid":{"bidder":{"other":{"placement":"3"}}}}`), &ext))
+
+	_, ok = ext.Params("adscoffee")
+	assert.False(t, ok)
+}
@@ -0,0 +1,344 @@
+package openrtb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Поддерживаемые версии протокола. Версия приходит
+// в заголовке X-Openrtb-Version и может быть пустой.
+const (
+	HeaderVersion = "X-Openrtb-Version"
+	HeaderNbr     = "X-Openrtb-Nbr"
+
+	Version25 = "2.5"
+	Version26 = "2.6"
+)
+
+var (
+	ErrEmptyID          = errors.New("request id is empty")
+	ErrEmptyImps        = errors.New("request has no imps")
+	ErrEmptyImpID       = errors.New("imp id is empty")
+	ErrDuplicateImpID   = errors.New("imp id is duplicated")
+	ErrUnsupportedImp   = errors.New("imp has no banner, native or video object")
+	ErrSiteAndApp       = errors.New("request must have site or app, not both")
+	ErrUnsupportedVer   = errors.New("unsupported openrtb version")
+	ErrInvalidNativeReq = errors.New("native request is empty")
+)
+
+// BidRequest - запрос на ставку по спецификации OpenRTB 2.5/2.6.
+// Описаны только поля, которые используются в пайплайнах,
+// остальное доступно через Ext.
+type BidRequest struct {
+	ID      string          `json:"id"`
+	Imp     []Imp           `json:"imp"`
+	Site    *Site           `json:"site,omitempty"`
+	App     *App            `json:"app,omitempty"`
+	Device  *Device         `json:"device,omitempty"`
+	User    *User           `json:"user,omitempty"`
+	Test    int8            `json:"test,omitempty"`
+	At      int8            `json:"at,omitempty"`
+	Tmax    int64           `json:"tmax,omitempty"`
+	Wseat   []string        `json:"wseat,omitempty"`
+	Bseat   []string        `json:"bseat,omitempty"`
+	AllImps int8            `json:"allimps,omitempty"`
+	Cur     []string        `json:"cur,omitempty"`
+	Wlang   []string        `json:"wlang,omitempty"`
+	Bcat    []string        `json:"bcat,omitempty"`
+	Badv    []string        `json:"badv,omitempty"`
+	Bapp    []string        `json:"bapp,omitempty"`
+	Source  *Source         `json:"source,omitempty"`
+	Regs    *Regs           `json:"regs,omitempty"`
+	Ext     json.RawMessage `json:"ext,omitempty"`
+}
+
+type Imp struct {
+	ID          string          `json:"id"`
+	Banner      *Banner         `json:"banner,omitempty"`
+	Video       *Video          `json:"video,omitempty"`
+	Native      *Native         `json:"native,omitempty"`
+	TagID       string          `json:"tagid,omitempty"`
+	BidFloor    float64         `json:"bidfloor,omitempty"`
+	BidFloorCur string          `json:"bidfloorcur,omitempty"`
+	Secure      *int8           `json:"secure,omitempty"`
+	Instl       int8            `json:"instl,omitempty"`
+	Rwdd        int8            `json:"rwdd,omitempty"`
+	Exp         int64           `json:"exp,omitempty"`
+	Ext         json.RawMessage `json:"ext,omitempty"`
+}
+
+// Formats возвращает типы креативов, которые можно
+// показать в этом imp.
+func (imp Imp) Formats() []string {
+	ff := []string{}
+
+	if imp.Banner != nil {
+		ff = append(ff, "banner")
+	}
+
+	if imp.Native != nil {
+		ff = append(ff, "native")
+	}
+
+	if imp.Video != nil {
+		ff = append(ff, "video")
+	}
+
+	return ff
+}
+
+type Format struct {
+	W int64 `json:"w,omitempty"`
+	H int64 `json:"h,omitempty"`
+}
+
+type Banner struct {
+	ID     string          `json:"id,omitempty"`
+	W      *int64          `json:"w,omitempty"`
+	H      *int64          `json:"h,omitempty"`
+	Format []Format        `json:"format,omitempty"`
+	BType  []int64         `json:"btype,omitempty"`
+	BAttr  []int64         `json:"battr,omitempty"`
+	Pos    *int64          `json:"pos,omitempty"`
+	Mimes  []string        `json:"mimes,omitempty"`
+	Ext    json.RawMessage `json:"ext,omitempty"`
+}
+
+type Video struct {
+	Mimes          []string        `json:"mimes"`
+	MinDuration    int64           `json:"minduration,omitempty"`
+	MaxDuration    int64           `json:"maxduration,omitempty"`
+	Protocols      []int64         `json:"protocols,omitempty"`
+	W              *int64          `json:"w,omitempty"`
+	H              *int64          `json:"h,omitempty"`
+	StartDelay     *int64          `json:"startdelay,omitempty"`
+	Placement      int64           `json:"placement,omitempty"`
+	Plcmt          int64           `json:"plcmt,omitempty"`
+	Linearity      int64           `json:"linearity,omitempty"`
+	Skip           *int8           `json:"skip,omitempty"`
+	PlaybackMethod []int64         `json:"playbackmethod,omitempty"`
+	Ext            json.RawMessage `json:"ext,omitempty"`
+}
+
+// Native - в запросе сам native request передается
+// строкой с json внутри.
+type Native struct {
+	Request string          `json:"request"`
+	Ver     string          `json:"ver,omitempty"`
+	API     []int64         `json:"api,omitempty"`
+	BAttr   []int64         `json:"battr,omitempty"`
+	Ext     json.RawMessage `json:"ext,omitempty"`
+}
+
+type Publisher struct {
+	ID     string   `json:"id,omitempty"`
+	Name   string   `json:"name,omitempty"`
+	Cat    []string `json:"cat,omitempty"`
+	Domain string   `json:"domain,omitempty"`
+}
+
+type Site struct {
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Domain    string          `json:"domain,omitempty"`
+	Cat       []string        `json:"cat,omitempty"`
+	Page      string          `json:"page,omitempty"`
+	Ref       string          `json:"ref,omitempty"`
+	Publisher *Publisher      `json:"publisher,omitempty"`
+	Ext       json.RawMessage `json:"ext,omitempty"`
+}
+
+type App struct {
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Bundle    string          `json:"bundle,omitempty"`
+	Domain    string          `json:"domain,omitempty"`
+	StoreURL  string          `json:"storeurl,omitempty"`
+	Cat       []string        `json:"cat,omitempty"`
+	Ver       string          `json:"ver,omitempty"`
+	Publisher *Publisher      `json:"publisher,omitempty"`
+	Ext       json.RawMessage `json:"ext,omitempty"`
+}
+
+type Geo struct {
+	Lat       *float64 `json:"lat,omitempty"`
+	Lon       *float64 `json:"lon,omitempty"`
+	Type      int64    `json:"type,omitempty"`
+	Country   string   `json:"country,omitempty"`
+	Region    string   `json:"region,omitempty"`
+	City      string   `json:"city,omitempty"`
+	ZIP       string   `json:"zip,omitempty"`
+	UTCOffset int64    `json:"utcoffset,omitempty"`
+}
+
+type Device struct {
+	UA             string          `json:"ua,omitempty"`
+	Geo            *Geo            `json:"geo,omitempty"`
+	DNT            *int8           `json:"dnt,omitempty"`
+	Lmt            *int8           `json:"lmt,omitempty"`
+	IP             string          `json:"ip,omitempty"`
+	IPv6           string          `json:"ipv6,omitempty"`
+	DeviceType     int64           `json:"devicetype,omitempty"`
+	Make           string          `json:"make,omitempty"`
+	Model          string          `json:"model,omitempty"`
+	OS             string          `json:"os,omitempty"`
+	OSV            string          `json:"osv,omitempty"`
+	Language       string          `json:"language,omitempty"`
+	Carrier        string          `json:"carrier,omitempty"`
+	ConnectionType *int64          `json:"connectiontype,omitempty"`
+	IFA            string          `json:"ifa,omitempty"`
+	Ext            json.RawMessage `json:"ext,omitempty"`
+}
+
+type User struct {
+	ID       string          `json:"id,omitempty"`
+	BuyerUID string          `json:"buyeruid,omitempty"`
+	Yob      int64           `json:"yob,omitempty"`
+	Gender   string          `json:"gender,omitempty"`
+	Keywords string          `json:"keywords,omitempty"`
+	Geo      *Geo            `json:"geo,omitempty"`
+	Consent  string          `json:"consent,omitempty"`
+	Ext      json.RawMessage `json:"ext,omitempty"`
+}
+
+// Apps - установленные приложения пользователя. В спецификации
+// поля нет, биржи передают бандлы в user.ext.apps
+func (u *User) Apps() []string {
+	if len(u.Ext) == 0 {
+		return nil
+	}
+
+	ext := struct {
+		Apps []string `json:"apps"`
+	}{}
+
+	if err := json.Unmarshal(u.Ext, &ext); err != nil {
+		return nil
+	}
+
+	return ext.Apps
+}
+
+type Source struct {
+	FD     int8            `json:"fd,omitempty"`
+	TID    string          `json:"tid,omitempty"`
+	PChain string          `json:"pchain,omitempty"`
+	Ext    json.RawMessage `json:"ext,omitempty"`
+}
+
+// Regs - в 2.6 gdpr и us_privacy переехали из ext
+// в сам объект. При разборе 2.5 переносим их из ext.
+type Regs struct {
+	COPPA     int8            `json:"coppa,omitempty"`
+	GDPR      *int8           `json:"gdpr,omitempty"`
+	USPrivacy string          `json:"us_privacy,omitempty"`
+	GPP       string          `json:"gpp,omitempty"`
+	GPPSID    []int8          `json:"gpp_sid,omitempty"`
+	Ext       json.RawMessage `json:"ext,omitempty"`
+}
+
+// Parse разбирает и проверяет запрос. Версия берется
+// из заголовка, пустая версия считается 2.5.
+func Parse(data []byte, version string) (*BidRequest, error) {
+	if version != "" && !strings.HasPrefix(version, Version25) && !strings.HasPrefix(version, Version26) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedVer, version)
+	}
+
+	req := &BidRequest{}
+	if err := json.Unmarshal(data, req); err != nil {
+		return nil, fmt.Errorf("error on decode request: %w", err)
+	}
+
+	req.normalize()
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// Validate проверяет обязательные поля запроса.
+func (r *BidRequest) Validate() error {
+	if r.ID == "" {
+		return ErrEmptyID
+	}
+
+	if len(r.Imp) == 0 {
+		return ErrEmptyImps
+	}
+
+	if r.Site != nil && r.App != nil {
+		return ErrSiteAndApp
+	}
+
+	ids := map[string]struct{}{}
+
+	for _, imp := range r.Imp {
+		if imp.ID == "" {
+			return ErrEmptyImpID
+		}
+
+		if _, ok := ids[imp.ID]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateImpID, imp.ID)
+		}
+
+		ids[imp.ID] = struct{}{}
+
+		if imp.Banner == nil && imp.Native == nil && imp.Video == nil {
+			return fmt.Errorf("%w: %s", ErrUnsupportedImp, imp.ID)
+		}
+
+		if imp.Native != nil && imp.Native.Request == "" {
+			return fmt.Errorf("%w: %s", ErrInvalidNativeReq, imp.ID)
+		}
+	}
+
+	return nil
+}
+
+// Bundle возвращает бандл приложения или домен сайта.
+func (r *BidRequest) Bundle() string {
+	if r.App != nil {
+		return r.App.Bundle
+	}
+
+	if r.Site != nil {
+		return r.Site.Domain
+	}
+
+	return ""
+}
+
+// normalize переносит поля из ext версии 2.5
+// в поля версии 2.6
+func (r *BidRequest) normalize() {
+	if r.Regs != nil && len(r.Regs.Ext) > 0 {
+		ext := struct {
+			GDPR      *int8  `json:"gdpr"`
+			USPrivacy string `json:"us_privacy"`
+		}{}
+
+		if err := json.Unmarshal(r.Regs.Ext, &ext); err == nil {
+			if r.Regs.GDPR == nil {
+				r.Regs.GDPR = ext.GDPR
+			}
+
+			if r.Regs.USPrivacy == "" {
+				r.Regs.USPrivacy = ext.USPrivacy
+			}
+		}
+	}
+
+	if r.User != nil && r.User.Consent == "" && len(r.User.Ext) > 0 {
+		ext := struct {
+			Consent string `json:"consent"`
+		}{}
+
+		if err := json.Unmarshal(r.User.Ext, &ext); err == nil {
+			r.User.Consent = ext.Consent
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package openrtb
+
+import "encoding/json"
+
+// Макросы, которые биржа подставляет в nurl/burl/lurl и adm
+const (
+	MacroAuctionID    = "${AUCTION_ID}"
+	MacroAuctionPrice = "${AUCTION_PRICE}"
+	MacroAuctionLoss  = "${AUCTION_LOSS}"
+	MacroAuctionImpID = "${AUCTION_IMP_ID}"
+)
+
+// Типы разметки в bid.mtype (OpenRTB 2.6)
+const (
+	MarkupBanner = 1
+	MarkupVideo  = 2
+	MarkupAudio  = 3
+	MarkupNative = 4
+)
+
+type BidResponse struct {
+	ID      string          `json:"id"`
+	SeatBid []SeatBid       `json:"seatbid,omitempty"`
+	BidID   string          `json:"bidid,omitempty"`
+	Cur     string          `json:"cur,omitempty"`
+	NBR     *int            `json:"nbr,omitempty"`
+	Ext     json.RawMessage `json:"ext,omitempty"`
+}
+
+type SeatBid struct {
+	Bid   []Bid           `json:"bid"`
+	Seat  string          `json:"seat,omitempty"`
+	Group int8            `json:"group,omitempty"`
+	Ext   json.RawMessage `json:"ext,omitempty"`
+}
+
+type Bid struct {
+	ID      string          `json:"id"`
+	ImpID   string          `json:"impid"`
+	Price   float64         `json:"price"`
+	NURL    string          `json:"nurl,omitempty"`
+	BURL    string          `json:"burl,omitempty"`
+	LURL    string          `json:"lurl,omitempty"`
+	AdM     string          `json:"adm,omitempty"`
+	AdID    string          `json:"adid,omitempty"`
+	ADomain []string        `json:"adomain,omitempty"`
+	Bundle  string          `json:"bundle,omitempty"`
+	IURL    string          `json:"iurl,omitempty"`
+	CID     string          `json:"cid,omitempty"`
+	CrID    string          `json:"crid,omitempty"`
+	Cat     []string        `json:"cat,omitempty"`
+	W       int64           `json:"w,omitempty"`
+	H       int64           `json:"h,omitempty"`
+	MType   int8            `json:"mtype,omitempty"`
+	Exp     int64           `json:"exp,omitempty"`
+	Ext     json.RawMessage `json:"ext,omitempty"`
+}
@@ -0,0 +1,27 @@
+package plugins
+
+import (
+	"context"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+// Adapter запрашивает ставку у сети медиации с сервера,
+// вместо того чтобы клиент сам ходил в сеть
+type Adapter interface {
+	Name() string
+	Copy(cfg map[string]any) Adapter
+	// Bid возвращает ставку сети на блок не ниже floor,
+	// false - сеть не ответила ставкой
+	Bid(ctx context.Context, state *State, unit ads.Unit, floor float64) (Bid, bool, error)
+}
+
+// Bid - ставка сети медиации
+type Bid struct {
+	// цена за тысячу показов
+	Price float64
+	// разметка креатива, которую клиент показывает как есть
+	Markup string
+	// уведомление сети о показе
+	Tracker string
+}
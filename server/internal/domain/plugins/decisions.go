@@ -0,0 +1,90 @@
+package plugins
+
+import (
+	"sync"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+// коды причин, по которым стейдж убрал баннер из кандидатов
+const (
+	ReasonTargeting = "targeting"
+	ReasonSchedule  = "schedule"
+	ReasonBudget    = "budget"
+	ReasonCapping   = "capping"
+	ReasonRotation  = "rotation"
+	ReasonMediation = "mediation"
+	ReasonFloor     = "floor"
+	ReasonAuction   = "auction"
+)
+
+// Decision - запись о баннере, который стейдж убрал из выдачи
+type Decision struct {
+	BannerID string `json:"banner_id"`
+	Stage    string `json:"stage"`
+	Reason   string `json:"reason"`
+	Details  string `json:"details,omitempty"`
+}
+
+// DecisionLog собирает решения стейджей по одному запросу. Журнал
+// создается только для отладочных и попавших в выборку запросов,
+// у остальных он nil и запись ничего не стоит. Стейдж с таймаутом
+// пишет в свою копию журнала, она попадает в запрос, только если
+// стейдж успел. Запись под мьютексом: стейдж может писать из
+// нескольких горутин.
+type DecisionLog struct {
+	// журнал нужно отправить в kafka
+	Sampled bool
+	// журнал нужно вернуть в ответе
+	Debug bool
+
+	mu    sync.Mutex
+	items []Decision
+}
+
+func (l *DecisionLog) Add(d Decision) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.items = append(l.items, d)
+}
+
+func (l *DecisionLog) Items() []Decision {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	items := make([]Decision, len(l.items))
+	copy(items, l.items)
+
+	return items
+}
+
+func (l *DecisionLog) clone() *DecisionLog {
+	if l == nil {
+		return nil
+	}
+
+	return &DecisionLog{
+		Sampled: l.Sampled,
+		Debug:   l.Debug,
+		items:   l.Items(),
+	}
+}
+
+// Drop записывает в журнал, почему стейдж убрал баннер
+func (s *State) Drop(stage string, banner ads.Banner, reason, details string) {
+	s.Decisions.Add(Decision{
+		BannerID: banner.ID,
+		Stage:    stage,
+		Reason:   reason,
+		Details:  details,
+	})
+}
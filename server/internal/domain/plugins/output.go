@@ -7,3 +7,9 @@ type Output interface {
 	Copy(cfg map[string]any) Output
 	Do(ctx context.Context, state *State) error
 }
+
+// NoBidder - выход сам отвечает на ошибку пайплайна.
+// Биржи ждут no-bid, а не 404
+type NoBidder interface {
+	NoBid(state *State)
+}
@@ -3,20 +3,41 @@ package plugins
 import (
 	"context"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/openrtb"
 )
 
 type State struct {
-	RequestID  string
-	ClickID    string
-	Request    *http.Request
-	Response   http.ResponseWriter
-	User       *User
-	Device     *Device
-	Candidates []ads.Banner
-	Winners    []ads.Banner
-	Placement  *Placement
+	RequestID    string
+	ClickID      string
+	Request      *http.Request
+	Response     http.ResponseWriter
+	User         *User
+	Device       *Device
+	Geo          *Geo
+	App          *App
+	Restrictions *Restrictions
+	Candidates   []ads.Banner
+	Winners      []ads.Banner
+	Placement    *Placement
+
+	// сеть (эндпоинт), из которой пришел запрос
+	Network string
+
+	// исходный запрос, если пайплайн обрабатывает OpenRTB
+	BidRequest *openrtb.BidRequest
+
+	// время, до которого нужно ответить. Вход выставляет его, если
+	// вызывающий сам ограничил время ответа (tmax в OpenRTB),
+	// и тогда оно заменяет дедлайн пайплайна
+	Deadline time.Time
+
+	// журнал решений стейджей, nil если запрос не отлаживается
+	Decisions *DecisionLog
 }
 
 func (s *State) Value(key any) any {
@@ -29,16 +50,169 @@ func (s *State) WithValue(key, value any) {
 	s.Request = s.Request.WithContext(ctx)
 }
 
+// Clone копирует состояние вместе со всем, что могут менять стейджи.
+// Копию можно отдать стейджу в горутине: если он опоздает,
+// его изменения не дойдут до исходного состояния
+func (s *State) Clone() *State {
+	cp := *s
+
+	cp.Candidates = slices.Clone(s.Candidates)
+	cp.Winners = slices.Clone(s.Winners)
+	cp.Decisions = s.Decisions.clone()
+
+	if s.User != nil {
+		user := *s.User
+		user.Apps = slices.Clone(s.User.Apps)
+		cp.User = &user
+	}
+
+	if s.Device != nil {
+		device := *s.Device
+		cp.Device = &device
+	}
+
+	if s.Geo != nil {
+		geo := *s.Geo
+		cp.Geo = &geo
+	}
+
+	if s.App != nil {
+		app := *s.App
+		app.Cat = slices.Clone(s.App.Cat)
+		cp.App = &app
+	}
+
+	if s.Restrictions != nil {
+		restrictions := Restrictions{
+			Bcat: slices.Clone(s.Restrictions.Bcat),
+			Badv: slices.Clone(s.Restrictions.Badv),
+			Bapp: slices.Clone(s.Restrictions.Bapp),
+		}
+		cp.Restrictions = &restrictions
+	}
+
+	if s.Placement != nil {
+		placement := *s.Placement
+		placement.Units = slices.Clone(s.Placement.Units)
+		placement.Formats = slices.Clone(s.Placement.Formats)
+		cp.Placement = &placement
+	}
+
+	return &cp
+}
+
+// TrackerInfo собирает данные запроса и баннера для
+// событий аналитики и ссылок на трекер
+func (s *State) TrackerInfo(b ads.Banner) ads.TrackerInfo {
+	info := ads.TrackerInfo{
+		Timestamp:    time.Now().Unix(),
+		RequestID:    s.RequestID,
+		ClickID:      s.ClickID,
+		BannerID:     b.ID,
+		GroupID:      b.GroupID,
+		CampaignID:   b.CampaignID,
+		AdvertiserID: b.AdvertiserID,
+		Network:      s.Network,
+		Price:        b.Charge(),
+	}
+
+	if s.User != nil {
+		info.UserID = s.User.ID
+	}
+
+	if s.Placement != nil {
+		info.PlacementID = s.Placement.ID
+	}
+
+	if s.Geo != nil {
+		info.Country = s.Geo.Country
+		info.Region = s.Geo.Region
+		info.City = s.Geo.City
+	}
+
+	if s.App != nil {
+		info.Bundle = s.App.Bundle
+	}
+
+	if s.Device != nil {
+		info.Make = s.Device.Make
+
+		if strings.EqualFold(s.Device.OS, "android") {
+			info.GAID = s.Device.IFA
+		}
+	}
+
+	return info
+}
+
 type User struct {
-	ID string
+	ID       string
+	BuyerUID string
+	Yob      int64
+	Gender   string
+	Consent  string
+
+	// установленные приложения, если известны
+	Apps []string
 }
 
 type Device struct {
-	UA string
-	IP string
+	UA    string
+	IP    string
+	IFA   string
+	Make  string
+	Model string
+	OS    string
+	OSV   string
+	Type  int64
+}
+
+type Geo struct {
+	Country string
+	Region  string
+	City    string
+}
+
+// Приложение или сайт, на котором показывается реклама
+type App struct {
+	ID        string
+	Bundle    string
+	Domain    string
+	Publisher string
+	Cat       []string
+}
+
+// Ограничения из запроса: заблокированные категории,
+// домены рекламодателей и приложения
+type Restrictions struct {
+	Bcat []string
+	Badv []string
+	Bapp []string
 }
 
 type Placement struct {
 	ID    string
 	Units []ads.Unit
+
+	// внешний идентификатор показа, например imp.id в rtb
+	ImpID   string
+	Formats []string
+	// минимальная цена плейсмента
+	Floor float64
+	// минимальная цена из запроса (bidfloor в RTB)
+	BidFloor float64
+
+	// часовой пояс для расписания, пустой - из конфига пайплайна
+	Timezone string
+}
+
+// NewPlacement - плейсмент запроса из сохраненного плейсмента
+func NewPlacement(p ads.Placement) *Placement {
+	return &Placement{
+		ID:       p.ID,
+		Units:    p.Units,
+		Formats:  p.Formats,
+		Floor:    p.Floor,
+		Timezone: p.Timezone,
+	}
 }
@@ -0,0 +1,181 @@
+package plugins
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Типы значений в конфиге плагина
+type Type string
+
+const (
+	TypeString   Type = "string"
+	TypeBool     Type = "bool"
+	TypeInt      Type = "int"
+	TypeFloat    Type = "float"
+	TypeDuration Type = "duration"
+	TypeList     Type = "list"
+	TypeMap      Type = "map"
+)
+
+// Field описывает один ключ конфига плагина
+type Field struct {
+	Name     string
+	Type     Type
+	Required bool
+	Default  any
+	// допустимые значения для строк, пустой список - любые
+	Values []string
+}
+
+// Schema - ключи, которые принимает плагин.
+// Пустая схема означает, что плагин без настроек.
+type Schema []Field
+
+// WithSchema реализуют плагины, которые описывают свой конфиг
+type WithSchema interface {
+	Schema() Schema
+}
+
+// Validate проверяет конфиг и возвращает все найденные ошибки
+func (s Schema) Validate(cfg map[string]any) []error {
+	errs := []error{}
+	known := map[string]struct{}{}
+
+	for _, f := range s {
+		known[f.Name] = struct{}{}
+
+		v, ok := cfg[f.Name]
+		if !ok || v == nil {
+			if f.Required {
+				errs = append(errs, fmt.Errorf("%s: required", f.Name))
+			}
+
+			continue
+		}
+
+		if err := f.check(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Name, err))
+		}
+	}
+
+	unknown := []string{}
+
+	for key := range cfg {
+		if _, ok := known[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+
+	sort.Strings(unknown)
+
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("%s: unknown key", key))
+	}
+
+	return errs
+}
+
+// Apply возвращает копию конфига со значениями по умолчанию.
+// Вложенные словари из yaml приводятся к map[string]any.
+func (s Schema) Apply(cfg map[string]any) map[string]any {
+	result := map[string]any{}
+
+	for key, v := range cfg {
+		result[key] = normalize(v)
+	}
+
+	for _, f := range s {
+		if _, ok := result[f.Name]; !ok && f.Default != nil {
+			result[f.Name] = f.Default
+		}
+	}
+
+	return result
+}
+
+func (f Field) check(v any) error {
+	switch f.Type {
+	case TypeString:
+		s, ok := v.(string)
+		if !ok {
+			return typeError(f.Type, v)
+		}
+
+		if len(f.Values) > 0 && !slices.Contains(f.Values, s) {
+			return fmt.Errorf("%q is not one of %s", s, strings.Join(f.Values, ", "))
+		}
+	case TypeBool:
+		if _, ok := v.(bool); !ok {
+			return typeError(f.Type, v)
+		}
+	case TypeInt:
+		switch v.(type) {
+		case int, int64, uint64:
+		default:
+			return typeError(f.Type, v)
+		}
+	case TypeFloat:
+		switch v.(type) {
+		case float64, int, int64, uint64:
+		default:
+			return typeError(f.Type, v)
+		}
+	case TypeDuration:
+		s, ok := v.(string)
+		if !ok {
+			return typeError(f.Type, v)
+		}
+
+		if _, err := time.ParseDuration(s); err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+	case TypeList:
+		if _, ok := v.([]any); !ok {
+			return typeError(f.Type, v)
+		}
+	case TypeMap:
+		switch v.(type) {
+		case map[string]any, map[any]any:
+		default:
+			return typeError(f.Type, v)
+		}
+	}
+
+	return nil
+}
+
+func typeError(t Type, v any) error {
+	return fmt.Errorf("expected %s, got %T", t, v)
+}
+
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = normalize(value)
+		}
+
+		return m
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[key] = normalize(value)
+		}
+
+		return m
+	case []any:
+		l := make([]any, len(v))
+		for i, value := range v {
+			l[i] = normalize(value)
+		}
+
+		return l
+	default:
+		return v
+	}
+}
@@ -0,0 +1,82 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var schema = Schema{
+	{Name: "base", Type: TypeString, Required: true},
+	{Name: "mode", Type: TypeString, Default: "gif", Values: []string{"gif", "empty"}},
+	{Name: "fanout", Type: TypeBool},
+	{Name: "count", Type: TypeInt},
+	{Name: "floor", Type: TypeFloat},
+	{Name: "window", Type: TypeDuration},
+	{Name: "items", Type: TypeList},
+	{Name: "params", Type: TypeMap},
+}
+
+func TestSchema_Validate(t *testing.T) {
+	errs := schema.Validate(map[string]any{
+		"base":   "http://ads.coffee",
+		"mode":   "empty",
+		"fanout": true,
+		"count":  3,
+		"floor":  3,
+		"window": "24h",
+		"items":  []any{"a"},
+		"params": map[any]any{"click_id": "clickid"},
+	})
+
+	assert.Empty(t, errs)
+}
+
+func TestSchema_Validate_Errors(t *testing.T) {
+	errs := schema.Validate(map[string]any{
+		"mode":    "png",
+		"fanout":  "yes",
+		"count":   1.5,
+		"window":  "week",
+		"items":   "a",
+		"params":  []any{},
+		"unknown": 1,
+	})
+
+	assert.Equal(t, []string{
+		"base: required",
+		`mode: "png" is not one of gif, empty`,
+		"fanout: expected bool, got string",
+		"count: expected int, got float64",
+		`window: invalid duration "week"`,
+		"items: expected list, got string",
+		"params: expected map, got []interface {}",
+		"unknown: unknown key",
+	}, errorStrings(errs))
+}
+
+func TestSchema_Apply(t *testing.T) {
+	cfg := schema.Apply(map[string]any{
+		"base":   "http://ads.coffee",
+		"params": map[any]any{"click_id": []any{"clickid"}},
+	})
+
+	assert.Equal(t, map[string]any{
+		"base":   "http://ads.coffee",
+		"mode":   "gif",
+		"params": map[string]any{"click_id": []any{"clickid"}},
+	}, cfg)
+
+	// пустая схема без настроек
+	assert.Equal(t, map[string]any{}, Schema{}.Apply(nil))
+	assert.Equal(t, []string{"base: unknown key"}, errorStrings(Schema{}.Validate(map[string]any{"base": ""})))
+}
+
+func errorStrings(errs []error) []string {
+	msgs := []string{}
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+
+	return msgs
+}
@@ -1,7 +1,15 @@
 package plugins
 
+import (
+	"context"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
 type Targeting interface {
 	Name() string
 	Copy(cfg map[string]any) Targeting
-	Filter()
+	// Filter возвращает false и причину, если баннер
+	// не подходит под запрос
+	Filter(ctx context.Context, state *State, banner ads.Banner) (bool, string)
 }
@@ -0,0 +1,162 @@
+package vast
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// Поддерживаемые версии. 3.0 отдается старым плеерам,
+// которые не понимают VAST 4
+const (
+	Version4 = "4.2"
+	Version3 = "3.0"
+
+	namespace = "http://www.iab.com/VAST"
+)
+
+// События линейного креатива в TrackingEvents
+const (
+	EventStart         = "start"
+	EventFirstQuartile = "firstQuartile"
+	EventMidpoint      = "midpoint"
+	EventThirdQuartile = "thirdQuartile"
+	EventComplete      = "complete"
+	EventSkip          = "skip"
+)
+
+// MacroErrorCode плеер заменяет на код ошибки в ссылке Error
+const MacroErrorCode = "[ERRORCODE]"
+
+const (
+	DeliveryProgressive = "progressive"
+	DeliveryStreaming   = "streaming"
+)
+
+// VAST - документ с объявлениями. Пустой документ
+// означает, что рекламы нет
+type VAST struct {
+	XMLName xml.Name `xml:"VAST"`
+	Version string   `xml:"version,attr"`
+	XMLNS   string   `xml:"xmlns,attr,omitempty"`
+	Ads     []Ad     `xml:"Ad"`
+}
+
+func New(version string) VAST {
+	v := VAST{Version: version}
+
+	if version != Version3 {
+		v.Version = Version4
+		v.XMLNS = namespace
+	}
+
+	return v
+}
+
+// V4 - документ версии 4, в нем есть AdServingId и UniversalAdId
+func (v VAST) V4() bool {
+	return v.Version != Version3
+}
+
+// ContentType - тип ответа для плеера
+func (v VAST) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+// Encode отдает документ с заголовком xml
+func (v VAST) Encode() ([]byte, error) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("error on marshal vast: %w", err)
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
+
+type Ad struct {
+	ID      string   `xml:"id,attr,omitempty"`
+	InLine  *InLine  `xml:"InLine,omitempty"`
+	Wrapper *Wrapper `xml:"Wrapper,omitempty"`
+}
+
+// InLine - объявление с креативом
+type InLine struct {
+	AdSystem    AdSystem     `xml:"AdSystem"`
+	AdServingID string       `xml:"AdServingId,omitempty"`
+	AdTitle     string       `xml:"AdTitle"`
+	Description string       `xml:"Description,omitempty"`
+	Errors      []CDATA      `xml:"Error,omitempty"`
+	Impressions []Impression `xml:"Impression"`
+	Creatives   []Creative   `xml:"Creatives>Creative"`
+}
+
+// Wrapper - ссылка на VAST другой сети. Плеер соберет
+// наши трекеры вместе с трекерами из ее ответа
+type Wrapper struct {
+	FollowAdditionalWrappers bool         `xml:"followAdditionalWrappers,attr"`
+	AdSystem                 AdSystem     `xml:"AdSystem"`
+	Errors                   []CDATA      `xml:"Error,omitempty"`
+	Impressions              []Impression `xml:"Impression"`
+	AdTagURI                 CDATA        `xml:"VASTAdTagURI"`
+	Creatives                []Creative   `xml:"Creatives>Creative,omitempty"`
+}
+
+type AdSystem struct {
+	Version string `xml:"version,attr,omitempty"`
+	Name    string `xml:",chardata"`
+}
+
+type Impression struct {
+	ID  string `xml:"id,attr,omitempty"`
+	URL string `xml:",cdata"`
+}
+
+type Creative struct {
+	ID             string          `xml:"id,attr,omitempty"`
+	AdID           string          `xml:"adId,attr,omitempty"`
+	UniversalAdIDs []UniversalAdID `xml:"UniversalAdId,omitempty"`
+	Linear         *Linear         `xml:"Linear,omitempty"`
+}
+
+type UniversalAdID struct {
+	IDRegistry string `xml:"idRegistry,attr"`
+	ID         string `xml:",chardata"`
+}
+
+type Linear struct {
+	SkipOffset     string       `xml:"skipoffset,attr,omitempty"`
+	Duration       string       `xml:"Duration,omitempty"`
+	TrackingEvents []Tracking   `xml:"TrackingEvents>Tracking,omitempty"`
+	VideoClicks    *VideoClicks `xml:"VideoClicks,omitempty"`
+	MediaFiles     []MediaFile  `xml:"MediaFiles>MediaFile,omitempty"`
+}
+
+type Tracking struct {
+	Event string `xml:"event,attr"`
+	URL   string `xml:",cdata"`
+}
+
+type VideoClicks struct {
+	ClickThrough  *CDATA  `xml:"ClickThrough,omitempty"`
+	ClickTracking []CDATA `xml:"ClickTracking,omitempty"`
+}
+
+type MediaFile struct {
+	Delivery string `xml:"delivery,attr"`
+	Type     string `xml:"type,attr"`
+	Width    int    `xml:"width,attr"`
+	Height   int    `xml:"height,attr"`
+	URL      string `xml:",cdata"`
+}
+
+type CDATA struct {
+	Value string `xml:",cdata"`
+}
+
+// Duration форматирует время как HH:MM:SS.mmm
+func Duration(d time.Duration) string {
+	ms := d.Milliseconds()
+
+	return fmt.Sprintf("%02d:%02d:%02d.%03d",
+		ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,90 @@
+package vast
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	v := New(Version4)
+	assert.Equal(t, Version4, v.Version)
+	assert.Equal(t, namespace, v.XMLNS)
+	assert.True(t, v.V4())
+
+	v = New(Version3)
+	assert.Equal(t, Version3, v.Version)
+	assert.Empty(t, v.XMLNS)
+	assert.False(t, v.V4())
+
+	// неизвестная версия - последняя
+	assert.Equal(t, Version4, New("2.0").Version)
+}
+
+func TestDuration(t *testing.T) {
+	assert.Equal(t, "00:00:00.000", Duration(0))
+	assert.Equal(t, "00:00:15.000", Duration(15*time.Second))
+	assert.Equal(t, "01:02:03.450", Duration(time.Hour+2*time.Minute+3450*time.Millisecond))
+}
+
+func TestVAST_Encode(t *testing.T) {
+	v := New(Version4)
+	v.Ads = []Ad{
+		{
+			ID: "banner-1",
+			InLine: &InLine{
+				AdSystem:    AdSystem{Name: "adscoffee"},
+				AdServingID: "request-1",
+				AdTitle:     "Title",
+				Errors:      []CDATA{{Value: "http://ads.coffee/tracker?action=error&reason=" + MacroErrorCode}},
+				Impressions: []Impression{{URL: "http://ads.coffee/tracker?action=impression&a=1"}},
+				Creatives: []Creative{
+					{
+						ID: "banner-1",
+						Linear: &Linear{
+							SkipOffset: Duration(5 * time.Second),
+							Duration:   Duration(15 * time.Second),
+							TrackingEvents: []Tracking{
+								{Event: EventStart, URL: "http://ads.coffee/tracker?action=start"},
+							},
+							MediaFiles: []MediaFile{
+								{Delivery: DeliveryProgressive, Type: "video/mp4", Width: 640, Height: 360, URL: "https://cdn.example.com/video.mp4"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	data, err := v.Encode()
+	require.NoError(t, err)
+
+	doc := string(data)
+
+	assert.True(t, strings.HasPrefix(doc, xml.Header))
+	assert.Contains(t, doc, `<VAST version="4.2" xmlns="http://www.iab.com/VAST">`)
+	assert.Contains(t, doc, `<AdServingId>request-1</AdServingId>`)
+	assert.Contains(t, doc, `<Error><![CDATA[http://ads.coffee/tracker?action=error&reason=[ERRORCODE]]]></Error>`)
+	assert.Contains(t, doc, `<Impression><![CDATA[http://ads.coffee/tracker?action=impression&a=1]]></Impression>`)
+	assert.Contains(t, doc, `<Linear skipoffset="00:00:05.000"><Duration>00:00:15.000</Duration>`)
+	assert.Contains(t, doc, `<Tracking event="start"><![CDATA[http://ads.coffee/tracker?action=start]]></Tracking>`)
+	assert.Contains(t, doc, `<MediaFile delivery="progressive" type="video/mp4" width="640" height="360">`)
+
+	// документ читается обратно
+	parsed := VAST{}
+	require.NoError(t, xml.Unmarshal(data, &parsed))
+	require.Len(t, parsed.Ads, 1)
+	assert.Equal(t, "https://cdn.example.com/video.mp4", parsed.Ads[0].InLine.Creatives[0].Linear.MediaFiles[0].URL)
+}
+
+func TestVAST_Encode_Empty(t *testing.T) {
+	data, err := New(Version3).Encode()
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), `<VAST version="3.0"></VAST>`)
+}
@@ -22,3 +22,20 @@ func New(inputs []plugins.Input) *Inputs {
 func (i *Inputs) Get(name string, cfg map[string]any) plugins.Input {
 	return i.plugins[name].Copy(cfg)
 }
+
+// Has проверяет, что плагин зарегистрирован
+func (i *Inputs) Has(name string) bool {
+	_, ok := i.plugins[name]
+
+	return ok
+}
+
+// Schema возвращает схему конфига, если плагин ее описывает
+func (i *Inputs) Schema(name string) (plugins.Schema, bool) {
+	p, ok := i.plugins[name].(plugins.WithSchema)
+	if !ok {
+		return nil, false
+	}
+
+	return p.Schema(), true
+}
@@ -72,3 +72,12 @@ func TestInput_GetNonExistentInput(t *testing.T) {
 		inputs.Get("non-existent", cfg)
 	})
 }
+
+func TestInputs_Has(t *testing.T) {
+	list := New([]plugins.Input{
+		&mockInput{name: "inputs.rtb"},
+	})
+
+	assert.True(t, list.Has("inputs.rtb"))
+	assert.False(t, list.Has("non-existent"))
+}
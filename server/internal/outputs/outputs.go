@@ -20,3 +20,20 @@ func New(list []plugins.Output) *Outputs {
 func (i *Outputs) Get(name string, cfg map[string]any) plugins.Output {
 	return i.list[name].Copy(cfg)
 }
+
+// Has проверяет, что плагин зарегистрирован
+func (i *Outputs) Has(name string) bool {
+	_, ok := i.list[name]
+
+	return ok
+}
+
+// Schema возвращает схему конфига, если плагин ее описывает
+func (i *Outputs) Schema(name string) (plugins.Schema, bool) {
+	p, ok := i.list[name].(plugins.WithSchema)
+	if !ok {
+		return nil, false
+	}
+
+	return p.Schema(), true
+}
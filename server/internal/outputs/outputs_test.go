@@ -75,3 +75,12 @@ func TestOutput_GetNonExistentOutput(t *testing.T) {
 		outputs.Get("non-existent", cfg)
 	})
 }
+
+func TestOutputs_Has(t *testing.T) {
+	list := New([]plugins.Output{
+		&mockOutput{name: "outputs.rtb"},
+	})
+
+	assert.True(t, list.Has("outputs.rtb"))
+	assert.False(t, list.Has("non-existent"))
+}
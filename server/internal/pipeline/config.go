@@ -1,5 +1,7 @@
 package pipeline
 
+import "time"
+
 // тут используется только конфигурация для упорядочивания
 // обхода по плагинам
 type Config struct {
@@ -9,6 +11,9 @@ type Config struct {
 	Stages     []Stage     `yaml:"stages"`
 	Targetings []Targeting `yaml:"targetings"`
 	Output     Output      `yaml:"output"`
+
+	// общее время на обработку запроса, 0 - без ограничения
+	Timeout time.Duration `yaml:"timeout"`
 }
 
 type Input struct {
@@ -19,6 +24,11 @@ type Input struct {
 type Stage struct {
 	Name   string         `yaml:"name"`
 	Config map[string]any `yaml:"config"`
+
+	// время на стейдж и что делать, если он не уложился:
+	// skip - пропустить стейдж, nobid - сразу ответить без рекламы
+	Timeout   time.Duration `yaml:"timeout"`
+	OnTimeout string        `yaml:"on_timeout"`
 }
 
 type Targeting struct {
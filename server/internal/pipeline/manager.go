@@ -1,10 +1,16 @@
 package pipeline
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+
+	"go.ads.coffee/platform/pkg/telemetry"
+	"go.ads.coffee/platform/server/internal/decisions"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 	"go.ads.coffee/platform/server/internal/inputs"
 	"go.ads.coffee/platform/server/internal/outputs"
@@ -12,8 +18,30 @@ import (
 	"go.ads.coffee/platform/server/internal/targetings"
 )
 
+// Decisions ведет журнал решений стейджей по запросу
+type Decisions interface {
+	Start(pipeline string, state *plugins.State)
+	Finish(ctx context.Context, pipeline string, state *plugins.State)
+}
+
+// Manager собирает пайплайны из конфига и раздает запросы по роутам.
+// Набор пайплайнов можно заменить на лету через Reload: запросы,
+// которые уже выполняются, доходят до конца на старых пайплайнах.
 type Manager struct {
+	inputs     *inputs.Inputs
+	outputs    *outputs.Outputs
+	stages     *stages.Stages
+	targetings *targetings.Targetings
+	tracer     Tracer
+	decisions  Decisions
+
+	table atomic.Pointer[table]
+}
+
+// table - набор пайплайнов с роутером для них
+type table struct {
 	pipelines []*Pipeline
+	router    *chi.Mux
 }
 
 func NewManager(
@@ -22,54 +50,139 @@ func NewManager(
 	outputs *outputs.Outputs,
 	stages *stages.Stages,
 	targetings *targetings.Targetings,
-) *Manager {
-	m := &Manager{}
+	tel *telemetry.Telemetry,
+	decisions *decisions.Decisions,
+) (*Manager, error) {
+	if err := tel.Register(duration, timeouts); err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		inputs:     inputs,
+		outputs:    outputs,
+		stages:     stages,
+		targetings: targetings,
+		tracer:     tel,
+		decisions:  decisions,
+	}
+
+	if err := m.Reload(pipelines); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Reload собирает новый набор пайплайнов и атомарно подменяет
+// текущий. Если конфиг невалидный, остается старый набор.
+func (m *Manager) Reload(pipelines []Config) error {
+	t, err := m.build(pipelines)
+	if err != nil {
+		return err
+	}
+
+	m.table.Store(t)
+
+	return nil
+}
+
+// Pipelines возвращает текущий набор пайплайнов
+func (m *Manager) Pipelines() []*Pipeline {
+	return m.table.Load().pipelines
+}
+
+// Mount подключает пайплайны к роутеру сервера. Роуты
+// пайплайнов разбираются во внутреннем роутере, который
+// меняется при перезагрузке конфига.
+func (m *Manager) Mount(router *chi.Mux) {
+	router.Mount("/", m)
+}
+
+func (m *Manager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.table.Load().router.ServeHTTP(w, r)
+}
+
+func (m *Manager) build(pipelines []Config) (*table, error) {
+	if err := Validate(pipelines, m.inputs, m.outputs, m.stages, m.targetings); err != nil {
+		return nil, err
+	}
+
+	t := &table{
+		router: chi.NewRouter(),
+	}
+
 	for _, c := range pipelines {
-		tt := []plugins.Targeting{}
+		p, err := m.pipeline(c)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline %s: %w", c.Name, err)
+		}
+
+		t.pipelines = append(t.pipelines, p)
+		t.router.Mount(p.Route(), handler(p, m.decisions))
+	}
 
-		for _, t := range c.Targetings {
-			tt = append(tt, targetings.Get(t.Name, t.Config))
+	return t, nil
+}
+
+// pipeline создает копии плагинов с настройками из конфига.
+// Плагины читают конфиг без проверок, поэтому паника при
+// копировании превращается в ошибку.
+func (m *Manager) pipeline(c Config) (p *Pipeline, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid plugin config: %v", r)
 		}
+	}()
 
-		ss := []plugins.Stage{}
+	tt := []plugins.Targeting{}
 
-		for _, s := range c.Stages {
-			v := stages.Get(s.Name, s.Config)
-			switch s := v.(type) {
-			case plugins.WithTargetings:
-				s.Targetings(tt)
-			default:
-				ss = append(ss, v)
-			}
+	for _, t := range c.Targetings {
+		tt = append(tt, m.targetings.Get(t.Name, config(t.Name, t.Config, m.targetings.Schema)))
+	}
+
+	ss := []plugins.Stage{}
+	limits := []Timeout{}
+
+	for _, s := range c.Stages {
+		limits = append(limits, Timeout{Duration: s.Timeout, Action: s.OnTimeout})
+
+		v := m.stages.Get(s.Name, config(s.Name, s.Config, m.stages.Schema))
+		if s, ok := v.(plugins.WithTargetings); ok {
+			s.Targetings(tt)
 		}
 
-		m.pipelines = append(m.pipelines, NewPipeline(
-			c.Name,
-			c.Route,
-			inputs.Get(c.Input.Name, c.Input.Config),
-			outputs.Get(c.Output.Name, c.Output.Config),
-			ss,
-		))
+		ss = append(ss, v)
 	}
 
-	return m
+	return NewPipeline(
+		c.Name,
+		c.Route,
+		m.inputs.Get(c.Input.Name, config(c.Input.Name, c.Input.Config, m.inputs.Schema)),
+		m.outputs.Get(c.Output.Name, config(c.Output.Name, c.Output.Config, m.outputs.Schema)),
+		ss,
+		WithTimeout(c.Timeout),
+		WithStageTimeouts(limits),
+		WithTracer(m.tracer),
+	), nil
 }
 
-func (m *Manager) Mount(router *chi.Mux) {
-	for _, p := range m.pipelines {
-		router.Mount(p.Route(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx := r.Context()
-
-			state := &plugins.State{
-				RequestID: uuid.NewString(),
-				ClickID:   uuid.NewString(),
-				Request:   r,
-				Response:  w,
-			}
-
-			if err := p.Do(ctx, state); err != nil {
-				w.WriteHeader(http.StatusNotFound)
-			}
-		}))
-	}
+func handler(p *Pipeline, decisions Decisions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		state := &plugins.State{
+			RequestID: uuid.NewString(),
+			ClickID:   uuid.NewString(),
+			Request:   r,
+			Response:  w,
+		}
+
+		decisions.Start(p.Name(), state)
+
+		if err := p.Do(ctx, state); err != nil {
+			p.fail(state)
+		}
+
+		decisions.Finish(ctx, p.Name(), state)
+	})
 }
@@ -3,12 +3,21 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/pkg/telemetry"
+	"go.ads.coffee/platform/server/internal/decisions"
+	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 	"go.ads.coffee/platform/server/internal/inputs"
 	"go.ads.coffee/platform/server/internal/outputs"
@@ -75,7 +84,8 @@ func (m *mockTargeting) Copy(cfg map[string]any) plugins.Targeting {
 	return &mockTargeting{name: m.name}
 }
 
-func (m *mockTargeting) Filter() {
+func (m *mockTargeting) Filter(ctx context.Context, state *plugins.State, banner ads.Banner) (bool, string) {
+	return true, ""
 }
 
 type mockOutput struct {
@@ -147,14 +157,22 @@ func TestNewManager(t *testing.T) {
 		},
 	}
 
-	manager := NewManager(cfg, inputs, outputs, stages, targetings)
+	manager, err := NewManager(cfg, inputs, outputs, stages, targetings, newTelemetry(t), newDecisions(t))
+	require.NoError(t, err)
 
 	assert.NotNil(t, manager)
-	assert.Len(t, manager.pipelines, 1)
+	assert.Len(t, manager.Pipelines(), 1)
 
-	pipeline := manager.pipelines[0]
+	pipeline := manager.Pipelines()[0]
 	assert.Equal(t, "dsp", pipeline.Name())
 	assert.Equal(t, "/dsp", pipeline.Route())
+
+	// стейдж с таргетингами тоже попадает в пайплайн
+	assert.Len(t, pipeline.stages, 2)
+
+	stage, ok := pipeline.stages[1].(*mockStageWithTargetings)
+	assert.True(t, ok)
+	assert.Len(t, stage.targetings, 2)
 }
 
 func TestManager_Mount(t *testing.T) {
@@ -199,14 +217,15 @@ func TestManager_Mount(t *testing.T) {
 		},
 	}
 
-	manager := NewManager(cfg, inputs, outputs, stages, targetings)
+	manager, err := NewManager(cfg, inputs, outputs, stages, targetings, newTelemetry(t), newDecisions(t))
+	require.NoError(t, err)
 
 	router := chi.NewRouter()
 
 	manager.Mount(router)
 
 	assert.NotNil(t, manager)
-	assert.Len(t, manager.pipelines, 1)
+	assert.Len(t, manager.Pipelines(), 1)
 }
 
 func TestManager_MountHandlers(t *testing.T) {
@@ -271,7 +290,8 @@ func TestManager_MountHandlers(t *testing.T) {
 		},
 	}
 
-	manager := NewManager(cfg, inputs, outputs, stages, targetings)
+	manager, err := NewManager(cfg, inputs, outputs, stages, targetings, newTelemetry(t), newDecisions(t))
+	require.NoError(t, err)
 
 	router := chi.NewRouter()
 
@@ -339,7 +359,8 @@ func TestManager_MountWithPipelineDo(t *testing.T) {
 		},
 	}
 
-	manager := NewManager(cfg, inputs, outputs, stages, targetings)
+	manager, err := NewManager(cfg, inputs, outputs, stages, targetings, newTelemetry(t), newDecisions(t))
+	require.NoError(t, err)
 
 	router := chi.NewRouter()
 
@@ -356,3 +377,254 @@ func TestManager_MountWithPipelineDo(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	resp.Body.Close()
 }
+
+type panicOutput struct {
+	mockOutput
+}
+
+func (m *panicOutput) Copy(cfg map[string]any) plugins.Output {
+	_ = cfg["base"].(string)
+
+	return &panicOutput{}
+}
+
+func newTestManager(t *testing.T, cfg []Config) *Manager {
+	manager, err := NewManager(
+		cfg,
+		inputs.New([]plugins.Input{&mockInput{name: "inputs.rtb"}}),
+		outputs.New([]plugins.Output{
+			&mockOutput{name: "outputs.rtb"},
+			&panicOutput{mockOutput: mockOutput{name: "outputs.static"}},
+		}),
+		stages.New([]plugins.Stage{&mockStage{name: "stages.banners"}}),
+		targetings.New([]plugins.Targeting{&mockTargeting{name: "targetings.apps"}}),
+		newTelemetry(t),
+		newDecisions(t),
+	)
+	require.NoError(t, err)
+
+	return manager
+}
+
+func newDecisions(t *testing.T) *decisions.Decisions {
+	d, err := decisions.New(decisions.Config{Token: "secret"}, nil)
+	require.NoError(t, err)
+
+	return d
+}
+
+func newTelemetry(t *testing.T) *telemetry.Telemetry {
+	tel, err := telemetry.New(zap.NewNop(), telemetry.Config{}, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	return tel
+}
+
+func pipelineConfig(name, route string) Config {
+	return Config{
+		Name:   name,
+		Route:  route,
+		Input:  Input{Name: "inputs.rtb"},
+		Stages: []Stage{{Name: "stages.banners"}},
+		Output: Output{Name: "outputs.rtb"},
+	}
+}
+
+func status(t *testing.T, url string) int {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func TestManager_Reload(t *testing.T) {
+	manager := newTestManager(t, []Config{pipelineConfig("dsp", "/dsp")})
+
+	router := chi.NewRouter()
+	manager.Mount(router)
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	assert.Equal(t, http.StatusOK, status(t, ts.URL+"/dsp"))
+	assert.Equal(t, http.StatusNotFound, status(t, ts.URL+"/web"))
+
+	err := manager.Reload([]Config{pipelineConfig("web", "/web")})
+	require.NoError(t, err)
+
+	// роутер сервера тот же, роуты новые
+	assert.Equal(t, http.StatusNotFound, status(t, ts.URL+"/dsp"))
+	assert.Equal(t, http.StatusOK, status(t, ts.URL+"/web"))
+	assert.Equal(t, "web", manager.Pipelines()[0].Name())
+}
+
+func TestManager_Reload_Invalid(t *testing.T) {
+	unknown := pipelineConfig("dsp", "/dsp")
+	unknown.Input.Name = "inputs.unknown"
+	unknown.Stages = append(unknown.Stages, Stage{Name: "stages.unknown"})
+	unknown.Targetings = []Targeting{{Name: "targetings.unknown"}}
+	unknown.Output.Name = "outputs.unknown"
+
+	broken := pipelineConfig("static", "/static")
+	broken.Output = Output{Name: "outputs.static", Config: map[string]any{}}
+
+	timeouts := pipelineConfig("dsp", "/dsp")
+	timeouts.Timeout = -time.Second
+	timeouts.Stages[0].OnTimeout = "retry"
+
+	tests := []struct {
+		name   string
+		cfg    []Config
+		errors []string
+	}{
+		{
+			name: "unknown plugins",
+			cfg:  []Config{unknown},
+			errors: []string{
+				`unknown input "inputs.unknown"`,
+				`unknown stage "stages.unknown"`,
+				`unknown targeting "targetings.unknown"`,
+				`unknown output "outputs.unknown"`,
+			},
+		},
+		{
+			name:   "duplicate route",
+			cfg:    []Config{pipelineConfig("dsp", "/dsp"), pipelineConfig("web", "/dsp")},
+			errors: []string{"route /dsp already used by dsp"},
+		},
+		{
+			name:   "invalid route",
+			cfg:    []Config{pipelineConfig("dsp", "dsp")},
+			errors: []string{`invalid route "dsp"`},
+		},
+		{
+			name:   "plugin config",
+			cfg:    []Config{broken},
+			errors: []string{"pipeline static: invalid plugin config"},
+		},
+		{
+			name: "timeouts",
+			cfg:  []Config{timeouts},
+			errors: []string{
+				"pipeline dsp: negative timeout -1s",
+				`stage stages.banners: on_timeout "retry" is not one of skip, nobid`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newTestManager(t, []Config{pipelineConfig("dsp", "/dsp")})
+			old := manager.Pipelines()
+
+			err := manager.Reload(tt.cfg)
+			require.Error(t, err)
+
+			for _, e := range tt.errors {
+				assert.Contains(t, err.Error(), e)
+			}
+
+			// старый набор продолжает работать
+			assert.Equal(t, old, manager.Pipelines())
+		})
+	}
+}
+
+type schemaOutput struct {
+	mockOutput
+	cfg map[string]any
+}
+
+func (m *schemaOutput) Copy(cfg map[string]any) plugins.Output {
+	return &schemaOutput{mockOutput: m.mockOutput, cfg: cfg}
+}
+
+func (m *schemaOutput) Schema() plugins.Schema {
+	return plugins.Schema{
+		{Name: "base", Type: plugins.TypeString, Required: true},
+		{Name: "mode", Type: plugins.TypeString, Default: "gif"},
+	}
+}
+
+func TestNewManager_Schema(t *testing.T) {
+	registries := func() (*inputs.Inputs, *outputs.Outputs, *stages.Stages, *targetings.Targetings) {
+		return inputs.New([]plugins.Input{&mockInput{name: "inputs.rtb"}}),
+			outputs.New([]plugins.Output{&schemaOutput{mockOutput: mockOutput{name: "outputs.pixel"}}}),
+			stages.New([]plugins.Stage{&mockStage{name: "stages.banners"}}),
+			targetings.New([]plugins.Targeting{})
+	}
+
+	cfg := pipelineConfig("tracker", "/tracker")
+	cfg.Output = Output{Name: "outputs.pixel", Config: map[string]any{"base": "http://ads.coffee"}}
+
+	i, o, s, tt := registries()
+	manager, err := NewManager([]Config{cfg}, i, o, s, tt, newTelemetry(t), newDecisions(t))
+	require.NoError(t, err)
+
+	// значения по умолчанию попадают в конфиг плагина
+	output := manager.Pipelines()[0].output.(*schemaOutput)
+	assert.Equal(t, map[string]any{"base": "http://ads.coffee", "mode": "gif"}, output.cfg)
+
+	cfg.Output.Config = map[string]any{"mod": "gif"}
+
+	i, o, s, tt = registries()
+	_, err = NewManager([]Config{cfg}, i, o, s, tt, newTelemetry(t), newDecisions(t))
+	require.Error(t, err)
+
+	assert.Equal(t, "pipeline tracker: output outputs.pixel: base: required\n"+
+		"pipeline tracker: output outputs.pixel: mod: unknown key", err.Error())
+}
+
+type failOutput struct {
+	mockOutput
+}
+
+func (m *failOutput) Copy(cfg map[string]any) plugins.Output {
+	return &failOutput{mockOutput: mockOutput{name: m.name}}
+}
+
+func (m *failOutput) Do(ctx context.Context, state *plugins.State) error {
+	return errors.New("imp not found")
+}
+
+type nobidOutput struct {
+	failOutput
+}
+
+func (m *nobidOutput) Copy(cfg map[string]any) plugins.Output {
+	return &nobidOutput{failOutput: failOutput{mockOutput: mockOutput{name: m.name}}}
+}
+
+func (m *nobidOutput) NoBid(state *plugins.State) {
+	state.Response.WriteHeader(http.StatusNoContent)
+}
+
+func TestManager_Handler_OutputError(t *testing.T) {
+	manager, err := NewManager(
+		[]Config{
+			{Name: "dsp", Route: "/dsp", Input: Input{Name: "inputs.rtb"}, Output: Output{Name: "outputs.rtb"}},
+			{Name: "web", Route: "/web", Input: Input{Name: "inputs.rtb"}, Output: Output{Name: "outputs.web"}},
+		},
+		inputs.New([]plugins.Input{&mockInput{name: "inputs.rtb"}}),
+		outputs.New([]plugins.Output{
+			&nobidOutput{failOutput: failOutput{mockOutput: mockOutput{name: "outputs.rtb"}}},
+			&failOutput{mockOutput: mockOutput{name: "outputs.web"}},
+		}),
+		stages.New([]plugins.Stage{}),
+		targetings.New([]plugins.Targeting{}),
+		newTelemetry(t),
+		newDecisions(t),
+	)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	manager.Mount(router)
+
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	// биржа получает no-bid, а не 404
+	assert.Equal(t, http.StatusNoContent, status(t, ts.URL+"/dsp"))
+	assert.Equal(t, http.StatusNotFound, status(t, ts.URL+"/web"))
+}
@@ -0,0 +1,28 @@
+package pipeline
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.ads.coffee/platform/pkg/telemetry"
+)
+
+var duration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "pipeline",
+		Subsystem: "stage",
+		Name:      "duration_seconds",
+		Help:      "Stage latency.",
+		Buckets:   telemetry.DefaultHistogramBuckets,
+	},
+	[]string{"pipeline", "stage"},
+)
+
+var timeouts = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pipeline",
+		Subsystem: "stage",
+		Name:      "timeouts_total",
+		Help:      "Total number of stage timeouts.",
+	},
+	[]string{"pipeline", "stage", "action"},
+)
@@ -2,16 +2,71 @@ package pipeline
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 )
 
+const (
+	// пропустить стейдж, который не уложился во время
+	ActionSkip = "skip"
+	// не искать рекламу дальше и ответить no-bid
+	ActionNoBid = "nobid"
+)
+
+// errNoBid останавливает стейджи, выход отвечает без рекламы
+var errNoBid = errors.New("no bid")
+
+type Tracer interface {
+	StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span)
+}
+
+// Timeout ограничивает время работы стейджа
+type Timeout struct {
+	Duration time.Duration
+	Action   string
+}
+
+type Option func(p *Pipeline)
+
+// WithTimeout задает общее время на обработку запроса
+func WithTimeout(d time.Duration) Option {
+	return func(p *Pipeline) {
+		p.timeout = d
+	}
+}
+
+// WithStageTimeouts задает ограничения для стейджей, по порядку
+func WithStageTimeouts(tt []Timeout) Option {
+	return func(p *Pipeline) {
+		p.timeouts = tt
+	}
+}
+
+func WithTracer(t Tracer) Option {
+	return func(p *Pipeline) {
+		p.tracer = t
+	}
+}
+
 type Pipeline struct {
 	name   string
 	route  string
 	input  plugins.Input
 	output plugins.Output
 	stages []plugins.Stage
+
+	timeout  time.Duration
+	timeouts []Timeout
+	tracer   Tracer
 }
 
 func NewPipeline(
@@ -20,14 +75,22 @@ func NewPipeline(
 	input plugins.Input,
 	output plugins.Output,
 	stages []plugins.Stage,
+	opts ...Option,
 ) *Pipeline {
-	return &Pipeline{
+	p := &Pipeline{
 		name:   name,
 		route:  route,
 		input:  input,
 		output: output,
 		stages: stages,
+		tracer: noopTracer{},
 	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
 }
 
 func (p *Pipeline) Name() string {
@@ -42,15 +105,159 @@ func (p *Pipeline) Do(
 	ctx context.Context,
 	state *plugins.State,
 ) error {
+	ctx, span := p.tracer.StartSpan(ctx, p.name,
+		trace.WithAttributes(attribute.String("pipeline.route", p.route)),
+	)
+	defer span.End()
+
+	base := ctx
+
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(base, p.timeout)
+		defer cancel()
+	}
+
 	if ok := p.input.Do(ctx, state); !ok {
 		return nil
 	}
 
-	for _, stage := range p.stages {
-		if err := stage.Do(ctx, state); err != nil {
+	// дедлайн от входа заменяет дедлайн пайплайна
+	if !state.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(base, state.Deadline)
+		defer cancel()
+	}
+
+	for i, stage := range p.stages {
+		err := p.stage(ctx, i, stage, state)
+		if errors.Is(err, errNoBid) {
+			span.AddEvent("nobid", trace.WithAttributes(attribute.String("pipeline.stage", stage.Name())))
+			state.Candidates = nil
+			state.Winners = nil
+
+			break
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+
 			return err
 		}
 	}
 
-	return p.output.Do(ctx, state)
+	// выход должен ответить даже после дедлайна,
+	// иначе вызывающий так и не получит no-bid
+	return p.output.Do(context.WithoutCancel(ctx), state)
+}
+
+// fail отвечает на ошибку пайплайна: выходы OpenRTB
+// отвечают no-bid, остальные 404
+func (p *Pipeline) fail(state *plugins.State) {
+	if nb, ok := p.output.(plugins.NoBidder); ok {
+		nb.NoBid(state)
+
+		return
+	}
+
+	state.Response.WriteHeader(http.StatusNotFound)
+}
+
+// stage запускает стейдж под своим спаном. Если у стейджа или у всего
+// пайплайна есть ограничение по времени, стейдж работает в горутине
+// с копией состояния: результат опоздавшего стейджа отбрасывается.
+func (p *Pipeline) stage(
+	ctx context.Context,
+	i int,
+	stage plugins.Stage,
+	state *plugins.State,
+) (err error) {
+	name := stage.Name()
+	limit := p.limit(i)
+
+	ctx, span := p.tracer.StartSpan(ctx, name)
+	defer span.End()
+
+	start := time.Now()
+
+	defer func() {
+		duration.WithLabelValues(p.name, name).Observe(time.Since(start).Seconds())
+
+		if err != nil && !errors.Is(err, errNoBid) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}()
+
+	if _, ok := ctx.Deadline(); !ok && limit.Duration <= 0 {
+		return stage.Do(ctx, state)
+	}
+
+	parent := ctx
+
+	if limit.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limit.Duration)
+		defer cancel()
+	}
+
+	// стейдж может писать в пользователя, плейсмент или журнал,
+	// поэтому копия глубокая, а не только кандидаты
+	cp := state.Clone()
+
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("stage %s panic: %v", name, r)
+			}
+		}()
+
+		done <- stage.Do(ctx, cp)
+	}()
+
+	select {
+	case err := <-done:
+		// стейдж успел, принимаем его изменения
+		*state = *cp
+
+		return err
+	case <-ctx.Done():
+	}
+
+	// если истек дедлайн всего пайплайна, искать рекламу уже некогда
+	action := limit.Action
+	if action == "" {
+		action = ActionSkip
+	}
+
+	if parent.Err() != nil {
+		action = ActionNoBid
+	}
+
+	timeouts.WithLabelValues(p.name, name, action).Inc()
+	span.SetStatus(codes.Error, "timeout")
+	span.SetAttributes(attribute.String("pipeline.timeout.action", action))
+
+	if action == ActionSkip {
+		return nil
+	}
+
+	return errNoBid
+}
+
+func (p *Pipeline) limit(i int) Timeout {
+	if i < len(p.timeouts) {
+		return p.timeouts[i]
+	}
+
+	return Timeout{}
+}
+
+type noopTracer struct{}
+
+func (noopTracer) StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return noop.Tracer{}.Start(ctx, name, opts...)
 }
@@ -4,8 +4,12 @@ import (
 	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 )
 
@@ -134,3 +138,229 @@ func TestPipeline_NameAndRoute(t *testing.T) {
 	assert.Equal(t, "test-pipeline", pipeline.Name(), "Pipeline name should match")
 	assert.Equal(t, "/test-route", pipeline.Route(), "Pipeline route should match")
 }
+
+// slowStage ждет отмены контекста и только потом меняет состояние
+type slowStage struct {
+	name string
+}
+
+func (m *slowStage) Name() string {
+	return m.name
+}
+
+func (m *slowStage) Copy(cfg map[string]any) plugins.Stage {
+	return m
+}
+
+func (m *slowStage) Do(ctx context.Context, state *plugins.State) error {
+	<-ctx.Done()
+	state.Winners = append(state.Winners, ads.Banner{ID: "late"})
+
+	return nil
+}
+
+// winnerStage выбирает один баннер
+type winnerStage struct{}
+
+func (m *winnerStage) Name() string {
+	return "winner"
+}
+
+func (m *winnerStage) Copy(cfg map[string]any) plugins.Stage {
+	return m
+}
+
+func (m *winnerStage) Do(ctx context.Context, state *plugins.State) error {
+	state.Winners = []ads.Banner{{ID: "banner"}}
+
+	return nil
+}
+
+// deadlineInput выставляет дедлайн, как это делает вход по tmax
+type deadlineInput struct {
+	testMockInput
+	deadline time.Duration
+}
+
+func (m *deadlineInput) Do(ctx context.Context, state *plugins.State) bool {
+	state.Deadline = time.Now().Add(m.deadline)
+
+	return true
+}
+
+type winnersOutput struct {
+	testMockOutput
+	winners []ads.Banner
+	err     error
+}
+
+func (m *winnersOutput) Do(ctx context.Context, state *plugins.State) error {
+	m.doCalled = true
+	m.winners = state.Winners
+	m.err = ctx.Err()
+
+	return nil
+}
+
+func TestPipeline_Do_StageTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  string
+		winners []ads.Banner
+	}{
+		{name: "skip", action: ActionSkip, winners: []ads.Banner{{ID: "banner"}}},
+		{name: "default skip", action: "", winners: []ads.Banner{{ID: "banner"}}},
+		{name: "nobid", action: ActionNoBid, winners: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &winnersOutput{}
+
+			pipeline := NewPipeline(
+				"test-pipeline",
+				"/test",
+				&testMockInput{},
+				output,
+				[]plugins.Stage{&winnerStage{}, &slowStage{name: "slow"}, &testMockStage{}},
+				WithStageTimeouts([]Timeout{{}, {Duration: 10 * time.Millisecond, Action: tt.action}}),
+			)
+
+			err := pipeline.Do(context.Background(), &plugins.State{Request: &http.Request{}})
+
+			require.NoError(t, err)
+			assert.True(t, output.doCalled)
+			assert.Equal(t, tt.winners, output.winners)
+		})
+	}
+}
+
+func TestPipeline_Do_Deadline(t *testing.T) {
+	output := &winnersOutput{}
+
+	// дедлайн от входа короче таймаута пайплайна
+	pipeline := NewPipeline(
+		"test-pipeline",
+		"/test",
+		&deadlineInput{deadline: 10 * time.Millisecond},
+		output,
+		[]plugins.Stage{&winnerStage{}, &slowStage{name: "slow"}},
+		WithTimeout(time.Minute),
+		WithStageTimeouts([]Timeout{{}, {Action: ActionSkip}}),
+	)
+
+	start := time.Now()
+	err := pipeline.Do(context.Background(), &plugins.State{Request: &http.Request{}})
+
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// дедлайн пайплайна истек - ответ без рекламы,
+	// но выход получает живой контекст
+	assert.True(t, output.doCalled)
+	assert.Nil(t, output.winners)
+	assert.NoError(t, output.err)
+}
+
+func TestPipeline_Do_StageError(t *testing.T) {
+	output := &winnersOutput{}
+
+	pipeline := NewPipeline(
+		"test-pipeline",
+		"/test",
+		&testMockInput{},
+		output,
+		[]plugins.Stage{&panicStage{}},
+		WithTimeout(time.Minute),
+	)
+
+	err := pipeline.Do(context.Background(), &plugins.State{Request: &http.Request{}})
+
+	assert.ErrorContains(t, err, "stage broken panic: boom")
+	assert.False(t, output.doCalled)
+}
+
+// lateUserStage после дедлайна меняет пользователя, плейсмент и журнал
+type lateUserStage struct {
+	done chan struct{}
+}
+
+func (m *lateUserStage) Name() string {
+	return "late-user"
+}
+
+func (m *lateUserStage) Copy(cfg map[string]any) plugins.Stage {
+	return m
+}
+
+func (m *lateUserStage) Do(ctx context.Context, state *plugins.State) error {
+	defer close(m.done)
+
+	<-ctx.Done()
+
+	state.User.ID = "late"
+	state.User.Apps = append(state.User.Apps[:0], "late")
+	state.Placement.Floor = 100
+	state.Drop("late-user", ads.Banner{ID: "banner"}, plugins.ReasonTargeting, "")
+
+	return nil
+}
+
+// userOutput читает пользователя, пока опоздавший стейдж еще работает
+type userOutput struct {
+	testMockOutput
+	user string
+}
+
+func (m *userOutput) Do(ctx context.Context, state *plugins.State) error {
+	m.user = state.User.ID
+
+	return nil
+}
+
+func TestPipeline_Do_LateStageState(t *testing.T) {
+	stage := &lateUserStage{done: make(chan struct{})}
+	output := &userOutput{}
+
+	pipeline := NewPipeline(
+		"test-pipeline",
+		"/test",
+		&testMockInput{},
+		output,
+		[]plugins.Stage{stage},
+		WithStageTimeouts([]Timeout{{Duration: 10 * time.Millisecond, Action: ActionSkip}}),
+	)
+
+	state := &plugins.State{
+		Request:   &http.Request{},
+		User:      &plugins.User{ID: "user", Apps: []string{"app"}},
+		Placement: &plugins.Placement{ID: "placement", Floor: 1},
+		Decisions: &plugins.DecisionLog{Debug: true},
+	}
+
+	err := pipeline.Do(context.Background(), state)
+	require.NoError(t, err)
+
+	// запускать с -race: стейдж пишет в свою копию состояния
+	<-stage.done
+
+	assert.Equal(t, "user", output.user)
+	assert.Equal(t, "user", state.User.ID)
+	assert.Equal(t, []string{"app"}, state.User.Apps)
+	assert.Equal(t, 1.0, state.Placement.Floor)
+	assert.Empty(t, state.Decisions.Items())
+}
+
+type panicStage struct{}
+
+func (m *panicStage) Name() string {
+	return "broken"
+}
+
+func (m *panicStage) Copy(cfg map[string]any) plugins.Stage {
+	return m
+}
+
+func (m *panicStage) Do(ctx context.Context, state *plugins.State) error {
+	panic("boom")
+}
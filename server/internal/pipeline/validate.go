@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/inputs"
+	"go.ads.coffee/platform/server/internal/outputs"
+	"go.ads.coffee/platform/server/internal/stages"
+	"go.ads.coffee/platform/server/internal/targetings"
+)
+
+// Validate проверяет роуты, имена плагинов и их конфиги по схемам
+// до сборки, чтобы не создавать копии плагинов для невалидного конфига.
+// Возвращает сразу все найденные ошибки.
+func Validate(
+	pipelines []Config,
+	inputs *inputs.Inputs,
+	outputs *outputs.Outputs,
+	stages *stages.Stages,
+	targetings *targetings.Targetings,
+) error {
+	errs := []error{}
+	routes := map[string]string{}
+
+	for _, c := range pipelines {
+		if !strings.HasPrefix(c.Route, "/") {
+			errs = append(errs, fmt.Errorf("pipeline %s: invalid route %q", c.Name, c.Route))
+		}
+
+		if other, ok := routes[c.Route]; ok {
+			errs = append(errs, fmt.Errorf("pipeline %s: route %s already used by %s", c.Name, c.Route, other))
+		}
+
+		routes[c.Route] = c.Name
+
+		if !inputs.Has(c.Input.Name) {
+			errs = append(errs, fmt.Errorf("pipeline %s: unknown input %q", c.Name, c.Input.Name))
+		} else {
+			errs = append(errs, check(c.Name, "input", c.Input.Name, c.Input.Config, inputs.Schema)...)
+		}
+
+		if !outputs.Has(c.Output.Name) {
+			errs = append(errs, fmt.Errorf("pipeline %s: unknown output %q", c.Name, c.Output.Name))
+		} else {
+			errs = append(errs, check(c.Name, "output", c.Output.Name, c.Output.Config, outputs.Schema)...)
+		}
+
+		if c.Timeout < 0 {
+			errs = append(errs, fmt.Errorf("pipeline %s: negative timeout %s", c.Name, c.Timeout))
+		}
+
+		for _, s := range c.Stages {
+			if s.Timeout < 0 {
+				errs = append(errs, fmt.Errorf("pipeline %s: stage %s: negative timeout %s", c.Name, s.Name, s.Timeout))
+			}
+
+			if s.OnTimeout != "" && s.OnTimeout != ActionSkip && s.OnTimeout != ActionNoBid {
+				errs = append(errs, fmt.Errorf("pipeline %s: stage %s: on_timeout %q is not one of %s, %s",
+					c.Name, s.Name, s.OnTimeout, ActionSkip, ActionNoBid))
+			}
+
+			if !stages.Has(s.Name) {
+				errs = append(errs, fmt.Errorf("pipeline %s: unknown stage %q", c.Name, s.Name))
+			} else {
+				errs = append(errs, check(c.Name, "stage", s.Name, s.Config, stages.Schema)...)
+			}
+		}
+
+		for _, t := range c.Targetings {
+			if !targetings.Has(t.Name) {
+				errs = append(errs, fmt.Errorf("pipeline %s: unknown targeting %q", c.Name, t.Name))
+			} else {
+				errs = append(errs, check(c.Name, "targeting", t.Name, t.Config, targetings.Schema)...)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+type schemas func(name string) (plugins.Schema, bool)
+
+// check проверяет конфиг плагина по его схеме
+func check(pipeline, kind, name string, cfg map[string]any, schema schemas) []error {
+	s, ok := schema(name)
+	if !ok {
+		return nil
+	}
+
+	errs := []error{}
+
+	for _, err := range s.Validate(cfg) {
+		errs = append(errs, fmt.Errorf("pipeline %s: %s %s: %w", pipeline, kind, name, err))
+	}
+
+	return errs
+}
+
+// config добавляет к конфигу плагина значения по умолчанию из схемы
+func config(name string, cfg map[string]any, schema schemas) map[string]any {
+	s, ok := schema(name)
+	if !ok {
+		return cfg
+	}
+
+	return s.Apply(cfg)
+}
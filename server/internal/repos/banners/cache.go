@@ -3,27 +3,76 @@ package banners
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 
+	"go.ads.coffee/platform/pkg/telemetry"
 	"go.ads.coffee/platform/server/internal/domain/ads"
 )
 
+type Repository interface {
+	All(ctx context.Context) ([]ads.Banner, error)
+	Subtree(ctx context.Context, table string, id string) ([]ads.Banner, error)
+}
+
+type Notifier interface {
+	Listen(ctx context.Context, changes chan<- Change)
+}
+
 type Cache struct {
-	logger *zap.Logger
-	repo   *Repo
+	logger   *zap.Logger
+	repo     Repository
+	listener Notifier
+	interval time.Duration
+	notify   bool
+
+	// закрывается после первой успешной загрузки
+	loaded chan struct{}
+	once   sync.Once
+
+	// время последней полной загрузки, unix nano
+	reloaded atomic.Int64
 
 	lock        sync.RWMutex
 	banners     []ads.Banner
 	bannersById map[string]ads.Banner
+	index       *Index
 }
 
-func NewCache(logger *zap.Logger, repo *Repo) *Cache {
+func NewCache(
+	logger *zap.Logger,
+	repo *Repo,
+	listener *Listener,
+	cfg Config,
+	tel *telemetry.Telemetry,
+) (*Cache, error) {
+	c := newCache(logger, repo, listener, cfg)
+
+	if err := tel.Register(c.metrics()...); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func newCache(logger *zap.Logger, repo Repository, listener Notifier, cfg Config) *Cache {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
 	return &Cache{
 		logger:      logger,
 		repo:        repo,
+		listener:    listener,
+		interval:    interval,
+		notify:      cfg.Notify.Enabled,
+		loaded:      make(chan struct{}),
 		bannersById: map[string]ads.Banner{},
+		index:       NewIndex(nil),
 	}
 }
 
@@ -37,6 +86,16 @@ func (c *Cache) All(ctx context.Context) []ads.Banner {
 	return banners
 }
 
+// Candidates отбирает по индексу баннеры, которые могут
+// подойти под запрос, без копирования всего кеша
+func (c *Cache) Candidates(ctx context.Context, q Query) []ads.Banner {
+	c.lock.RLock()
+	index := c.index
+	c.lock.RUnlock()
+
+	return index.Candidates(q)
+}
+
 func (c *Cache) One(ctx context.Context, id string) (ads.Banner, bool) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
@@ -46,34 +105,166 @@ func (c *Cache) One(ctx context.Context, id string) (ads.Banner, bool) {
 	return b, ok
 }
 
-// Start reload banners cache.
+// Loaded закрывается, когда баннеры загружены в первый раз
+func (c *Cache) Loaded() <-chan struct{} {
+	return c.loaded
+}
+
+// Start загружает баннеры и обновляет кеш до отмены контекста.
+// Изменения из базы применяются по поддеревьям сразу, полная
+// перезагрузка по таймеру остается страховкой.
 func (c *Cache) Start(ctx context.Context) {
-	c.reload()
+	changes := make(chan Change, 128)
+
+	// слушаем до первой загрузки, чтобы не потерять
+	// изменения, которые придут во время нее
+	if c.notify {
+		go c.listener.Listen(ctx, changes)
+	}
+
+	c.reload(ctx)
 
-	ticker := time.NewTicker(time.Minute * 1)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
 		select {
 		case <-ctx.Done():
 			return
-		default:
-			c.reload()
+		case <-ticker.C:
+			c.reload(ctx)
+		case change := <-changes:
+			if change.Full() {
+				c.reload(ctx)
+			} else {
+				c.update(ctx, change)
+			}
 		}
 	}
 }
 
-func (c *Cache) reload() {
-	banners, err := c.repo.All(context.Background())
+func (c *Cache) reload(ctx context.Context) {
+	banners, err := c.repo.All(ctx)
 	if err != nil {
 		c.logger.Error("error on get banners from repo", zap.Error(err))
 
 		return
 	}
 
-	c.lock.Lock()
-	c.banners = banners
+	// удаленные баннеры должны пропасть из кеша
+	byId := make(map[string]ads.Banner, len(banners))
 	for _, banner := range banners {
-		c.bannersById[banner.ID] = banner
+		byId[banner.ID] = banner
+	}
+
+	c.swap(banners, byId)
+
+	c.reloaded.Store(time.Now().UnixNano())
+	c.once.Do(func() { close(c.loaded) })
+}
+
+// update заменяет в кеше баннеры поддерева измененной записи
+func (c *Cache) update(ctx context.Context, change Change) {
+	fresh, err := c.repo.Subtree(ctx, change.Table, change.ID)
+	if err != nil {
+		c.logger.Error("error on get banners subtree from repo",
+			zap.String("table", change.Table),
+			zap.String("id", change.ID),
+			zap.Error(err),
+		)
+
+		return
+	}
+
+	ids := make(map[string]struct{}, len(fresh))
+	for _, banner := range fresh {
+		ids[banner.ID] = struct{}{}
 	}
+
+	// кеш меняет только Start, поэтому текущий набор
+	// можно читать без блокировки на запись
+	c.lock.RLock()
+	current := c.banners
+	c.lock.RUnlock()
+
+	banners := make([]ads.Banner, 0, len(current)+len(fresh))
+	byId := make(map[string]ads.Banner, len(current)+len(fresh))
+
+	for _, banner := range current {
+		if _, ok := ids[banner.ID]; ok || change.match(banner) {
+			continue
+		}
+
+		banners = append(banners, banner)
+		byId[banner.ID] = banner
+	}
+
+	for _, banner := range fresh {
+		banners = append(banners, banner)
+		byId[banner.ID] = banner
+	}
+
+	c.swap(banners, byId)
+}
+
+// swap подменяет набор баннеров вместе с индексом по ним
+func (c *Cache) swap(banners []ads.Banner, byId map[string]ads.Banner) {
+	index := NewIndex(banners)
+
+	c.lock.Lock()
+	c.banners = banners
+	c.bannersById = byId
+	c.index = index
 	c.lock.Unlock()
 }
+
+func (c *Cache) metrics() []prometheus.Collector {
+	return []prometheus.Collector{
+		prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Namespace: "banners",
+				Subsystem: "cache",
+				Name:      "size",
+				Help:      "Number of banners in cache.",
+			},
+			func() float64 {
+				c.lock.RLock()
+				defer c.lock.RUnlock()
+
+				return float64(len(c.banners))
+			},
+		),
+		prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Namespace: "banners",
+				Subsystem: "cache",
+				Name:      "age_seconds",
+				Help:      "Time since the last full reload of banners cache.",
+			},
+			func() float64 {
+				reloaded := c.reloaded.Load()
+				if reloaded == 0 {
+					return 0
+				}
+
+				return time.Since(time.Unix(0, reloaded)).Seconds()
+			},
+		),
+	}
+}
+
+// match - баннер находится в поддереве измененной записи
+func (c Change) match(banner ads.Banner) bool {
+	switch c.Table {
+	case TableBanners:
+		return banner.ID == c.ID
+	case TableGroups:
+		return banner.GroupID == c.ID
+	case TableCampaigns:
+		return banner.CampaignID == c.ID
+	case TableAdvertisers:
+		return banner.AdvertiserID == c.ID
+	}
+
+	return false
+}
@@ -0,0 +1,249 @@
+package banners
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+type fakeRepo struct {
+	mu      sync.Mutex
+	banners []ads.Banner
+	err     error
+}
+
+func (r *fakeRepo) set(banners ...ads.Banner) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.banners = banners
+}
+
+func (r *fakeRepo) All(ctx context.Context) ([]ads.Banner, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return append([]ads.Banner{}, r.banners...), nil
+}
+
+func (r *fakeRepo) Subtree(ctx context.Context, table string, id string) ([]ads.Banner, error) {
+	banners, err := r.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	change := Change{Table: table, ID: id}
+	result := []ads.Banner{}
+
+	for _, b := range banners {
+		if change.match(b) {
+			result = append(result, b)
+		}
+	}
+
+	return result, nil
+}
+
+type fakeListener struct {
+	changes chan Change
+}
+
+func (l *fakeListener) Listen(ctx context.Context, changes chan<- Change) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case c := <-l.changes:
+			changes <- c
+		}
+	}
+}
+
+func banner(id, group, campaign, advertiser string) ads.Banner {
+	return ads.Banner{ID: id, GroupID: group, CampaignID: campaign, AdvertiserID: advertiser}
+}
+
+func ids(banners []ads.Banner) []string {
+	result := []string{}
+	for _, b := range banners {
+		result = append(result, b.ID)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+func TestCache_Reload_PurgesRemoved(t *testing.T) {
+	repo := &fakeRepo{}
+	repo.set(banner("1", "g1", "c1", "a1"), banner("2", "g1", "c1", "a1"))
+
+	cache := newCache(zap.NewNop(), repo, nil, Config{})
+	cache.reload(context.Background())
+
+	repo.set(banner("1", "g1", "c1", "a1"))
+	cache.reload(context.Background())
+
+	assert.Equal(t, []string{"1"}, ids(cache.All(context.Background())))
+
+	_, ok := cache.One(context.Background(), "2")
+	assert.False(t, ok)
+
+	// при ошибке остается старый набор
+	repo.err = errors.New("db is down")
+	cache.reload(context.Background())
+
+	assert.Equal(t, []string{"1"}, ids(cache.All(context.Background())))
+}
+
+func TestCache_Update(t *testing.T) {
+	repo := &fakeRepo{}
+	repo.set(
+		banner("1", "g1", "c1", "a1"),
+		banner("2", "g1", "c1", "a1"),
+		banner("3", "g2", "c2", "a1"),
+		banner("4", "g3", "c3", "a2"),
+	)
+
+	tests := []struct {
+		name    string
+		current []ads.Banner
+		change  Change
+		want    []string
+	}{
+		{
+			name:    "campaign paused",
+			current: []ads.Banner{banner("3", "g2", "c2", "a1"), banner("4", "g3", "c3", "a2")},
+			change:  Change{Table: TableCampaigns, ID: "c1"},
+			want:    []string{"3", "4"},
+		},
+		{
+			name: "banner added",
+			current: []ads.Banner{
+				banner("1", "g1", "c1", "a1"), banner("2", "g1", "c1", "a1"),
+				banner("3", "g2", "c2", "a1"), banner("4", "g3", "c3", "a2"), banner("5", "g3", "c3", "a2"),
+			},
+			change: Change{Table: TableBanners, ID: "5"},
+			want:   []string{"1", "2", "3", "4", "5"},
+		},
+		{
+			name:    "banner moved to other group",
+			current: []ads.Banner{banner("1", "g1", "c1", "a1"), banner("2", "g3", "c3", "a2"), banner("3", "g2", "c2", "a1"), banner("4", "g3", "c3", "a2")},
+			change:  Change{Table: TableBanners, ID: "2"},
+			want:    []string{"1", "2", "3", "4"},
+		},
+		{
+			name:    "advertiser deleted",
+			current: []ads.Banner{banner("4", "g3", "c3", "a2")},
+			change:  Change{Table: TableAdvertisers, ID: "a1"},
+			want:    []string{"4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.set(
+				banner("1", "g1", "c1", "a1"),
+				banner("2", "g1", "c1", "a1"),
+				banner("3", "g2", "c2", "a1"),
+				banner("4", "g3", "c3", "a2"),
+			)
+
+			cache := newCache(zap.NewNop(), repo, nil, Config{})
+			cache.reload(context.Background())
+
+			repo.set(tt.current...)
+			cache.update(context.Background(), tt.change)
+
+			assert.Equal(t, tt.want, ids(cache.All(context.Background())))
+
+			for _, b := range tt.current {
+				if !tt.change.match(b) {
+					continue
+				}
+
+				cached, ok := cache.One(context.Background(), b.ID)
+				require.True(t, ok)
+				assert.Equal(t, b, cached)
+			}
+		})
+	}
+}
+
+func TestCache_Start(t *testing.T) {
+	repo := &fakeRepo{}
+	repo.set(banner("1", "g1", "c1", "a1"), banner("2", "g2", "c2", "a1"))
+
+	listener := &fakeListener{changes: make(chan Change)}
+	cache := newCache(zap.NewNop(), repo, listener, Config{
+		Interval: time.Hour,
+		Notify:   Notify{Enabled: true},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		cache.Start(ctx)
+		close(done)
+	}()
+
+	<-cache.Loaded()
+	assert.Equal(t, []string{"1", "2"}, ids(cache.All(ctx)))
+
+	// изменение применяется без полной перезагрузки
+	repo.set(banner("1", "g1", "c1", "a1"))
+	listener.changes <- Change{Table: TableGroups, ID: "g2"}
+
+	assert.Eventually(t, func() bool {
+		_, ok := cache.One(ctx, "2")
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+
+	// Start завершается по отмене контекста
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("cache did not stop")
+	}
+}
+
+func TestCache_Metrics(t *testing.T) {
+	repo := &fakeRepo{}
+	repo.set(banner("1", "g1", "c1", "a1"), banner("2", "g2", "c2", "a1"))
+
+	cache := newCache(zap.NewNop(), repo, nil, Config{})
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(cache.metrics()[0]))
+	require.NoError(t, registry.Register(cache.metrics()[1]))
+
+	cache.reload(context.Background())
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	values := map[string]float64{}
+	for _, f := range families {
+		values[f.GetName()] = f.GetMetric()[0].GetGauge().GetValue()
+	}
+
+	assert.Equal(t, float64(2), values["banners_cache_size"])
+	assert.Less(t, values["banners_cache_age_seconds"], float64(1))
+}
@@ -0,0 +1,27 @@
+package banners
+
+import "time"
+
+const (
+	defaultInterval = time.Minute
+
+	// канал зашит в триггере миграции banners_notify,
+	// поэтому в конфиге его не меняем
+	channel = "banners"
+)
+
+type Config struct {
+	// полная перезагрузка кеша. С уведомлениями это страховка
+	// на случай потерянных событий
+	Interval time.Duration `yaml:"interval"`
+	Notify   Notify        `yaml:"notify"`
+}
+
+// Notify - обновление кеша по LISTEN/NOTIFY из postgres
+type Notify struct {
+	Enabled bool `yaml:"enabled"`
+	// LISTEN не работает через pgbouncer в режиме транзакций,
+	// поэтому можно слушать базу напрямую на другом хосте и порту
+	Host string `yaml:"host"`
+	Port string `yaml:"port"`
+}
@@ -10,5 +10,6 @@ var Module = fx.Module(
 	fx.Provide(
 		NewRepo,
 		NewCache,
+		NewListener,
 	),
 )
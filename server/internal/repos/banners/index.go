@@ -0,0 +1,226 @@
+package banners
+
+import (
+	"math/bits"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+// Query - атрибуты запроса, по которым индекс отбирает кандидатов.
+// Пустое значение означает, что атрибут неизвестен
+type Query struct {
+	Country string
+	Region  string
+	City    string
+	Bundle  string
+	Network string
+	Formats []string
+}
+
+// Index - инвертированный индекс баннеров по измерениям таргетинга.
+// Собирается при обновлении кеша и дальше не меняется, поэтому
+// читается без блокировок. Индекс отсекает заведомо неподходящие
+// баннеры, полную проверку таргетингов делает stages.targeting.
+type Index struct {
+	banners []ads.Banner
+	all     bitset
+
+	country dimension
+	region  dimension
+	city    dimension
+	bundle  dimension
+	network dimension
+	format  dimension
+}
+
+// dimension - списки баннеров по значениям одного измерения
+type dimension struct {
+	// баннеры без включающего таргетинга подходят под любое значение
+	any bitset
+	// баннеры, у которых значение есть во включениях
+	include map[string]bitset
+	// баннеры, у которых значение есть в исключениях по OR
+	exclude map[string]bitset
+}
+
+func NewIndex(banners []ads.Banner) *Index {
+	n := len(banners)
+
+	idx := &Index{
+		banners: banners,
+		all:     newBitset(n),
+		country: newDimension(n),
+		region:  newDimension(n),
+		city:    newDimension(n),
+		bundle:  newDimension(n),
+		network: newDimension(n),
+		format:  newDimension(n),
+	}
+
+	for i, b := range banners {
+		idx.all.set(i)
+
+		idx.country.add(i, b.Targeting.Country, n)
+		idx.region.add(i, b.Targeting.Region, n)
+		idx.city.add(i, b.Targeting.City, n)
+		idx.bundle.add(i, b.Targeting.Bundle, n)
+		idx.network.add(i, b.Targeting.Network, n)
+
+		// баннер без типа подходит под любой формат плейсмента
+		if b.Type == "" {
+			idx.format.any.set(i)
+		} else {
+			idx.format.posting(idx.format.include, b.Type, n).set(i)
+		}
+	}
+
+	return idx
+}
+
+// Len - количество баннеров в индексе
+func (idx *Index) Len() int {
+	return len(idx.banners)
+}
+
+// Candidates пересекает списки по атрибутам запроса и создает
+// только подходящих кандидатов
+func (idx *Index) Candidates(q Query) []ads.Banner {
+	result := idx.all.clone()
+
+	idx.country.match(result, values(q.Country))
+	idx.region.match(result, values(q.Region))
+	idx.city.match(result, values(q.City))
+	idx.bundle.match(result, values(q.Bundle))
+	idx.network.match(result, values(q.Network))
+
+	// формат плейсмента неизвестен - тип баннера не проверяем
+	if len(q.Formats) > 0 {
+		idx.format.match(result, q.Formats)
+	}
+
+	banners := make([]ads.Banner, 0, result.count())
+
+	result.each(func(i int) {
+		banners = append(banners, idx.banners[i])
+	})
+
+	return banners
+}
+
+func newDimension(n int) dimension {
+	return dimension{
+		any:     newBitset(n),
+		include: map[string]bitset{},
+		exclude: map[string]bitset{},
+	}
+}
+
+func (d *dimension) add(i int, t ads.ExcludeInclude, n int) {
+	if len(t.IncludeOr) == 0 && len(t.IncludeAnd) == 0 {
+		d.any.set(i)
+	}
+
+	// запрос дает одно значение на измерение, поэтому включение
+	// по AND индексируем как по OR, лишнее отсечет таргетинг
+	for _, v := range t.IncludeOr {
+		d.posting(d.include, v, n).set(i)
+	}
+
+	for _, v := range t.IncludeAnd {
+		d.posting(d.include, v, n).set(i)
+	}
+
+	for _, v := range t.ExcludeOr {
+		d.posting(d.exclude, v, n).set(i)
+	}
+}
+
+func (d *dimension) posting(m map[string]bitset, v string, n int) bitset {
+	b, ok := m[v]
+	if !ok {
+		b = newBitset(n)
+		m[v] = b
+	}
+
+	return b
+}
+
+// match оставляет в result баннеры, подходящие хотя бы под одно значение
+func (d *dimension) match(result bitset, vv []string) {
+	allowed := d.any.clone()
+
+	for _, v := range vv {
+		if b, ok := d.include[v]; ok {
+			allowed.or(b)
+		}
+	}
+
+	for _, v := range vv {
+		if b, ok := d.exclude[v]; ok {
+			allowed.andNot(b)
+		}
+	}
+
+	result.and(allowed)
+}
+
+func values(v string) []string {
+	if v == "" {
+		return nil
+	}
+
+	return []string{v}
+}
+
+type bitset []uint64
+
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << (uint(i) % 64)
+}
+
+func (b bitset) clone() bitset {
+	c := make(bitset, len(b))
+	copy(c, b)
+
+	return c
+}
+
+func (b bitset) and(o bitset) {
+	for i := range b {
+		b[i] &= o[i]
+	}
+}
+
+func (b bitset) or(o bitset) {
+	for i := range b {
+		b[i] |= o[i]
+	}
+}
+
+func (b bitset) andNot(o bitset) {
+	for i := range b {
+		b[i] &^= o[i]
+	}
+}
+
+func (b bitset) count() int {
+	n := 0
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+
+	return n
+}
+
+func (b bitset) each(fn func(i int)) {
+	for i, w := range b {
+		for w != 0 {
+			fn(i*64 + bits.TrailingZeros64(w))
+			w &= w - 1
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package banners
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+var (
+	countries = []string{"RU", "BY", "KZ", "UZ", "AM", "GE", "DE", "TR"}
+	regions   = []string{"MOW", "SPE", "NVS", "SVE", "KDA", "TA"}
+	cities    = []string{"Moscow", "Saint Petersburg", "Novosibirsk", "Yekaterinburg", "Kazan"}
+	bundles   = []string{"com.example.news", "com.example.game", "com.example.music", "com.example.maps"}
+	networks  = []string{"exchange", "yandex", "vk", "mytarget"}
+	types     = []string{"", ads.CreativeTypeBanner, ads.CreativeTypeNative, ads.CreativeTypeVideo}
+)
+
+// rule - случайный таргетинг по одному измерению, чаще всего пустой
+func rule(r *rand.Rand, values []string) ads.ExcludeInclude {
+	pick := func() []string {
+		return []string{values[r.IntN(len(values))], values[r.IntN(len(values))]}
+	}
+
+	switch r.IntN(6) {
+	case 0:
+		return ads.ExcludeInclude{IncludeOr: pick()}
+	case 1:
+		return ads.ExcludeInclude{ExcludeOr: pick()}
+	case 2:
+		return ads.ExcludeInclude{IncludeAnd: pick()[:1]}
+	default:
+		return ads.ExcludeInclude{}
+	}
+}
+
+func inventory(n int) []ads.Banner {
+	r := rand.New(rand.NewPCG(1, 2))
+	banners := make([]ads.Banner, 0, n)
+
+	for i := range n {
+		banners = append(banners, ads.Banner{
+			ID:   fmt.Sprint(i),
+			Type: types[r.IntN(len(types))],
+			Targeting: ads.Targeting{
+				Country: rule(r, countries),
+				Region:  rule(r, regions),
+				City:    rule(r, cities),
+				Bundle:  rule(r, bundles),
+				Network: rule(r, networks),
+			},
+		})
+	}
+
+	return banners
+}
+
+func queries() []Query {
+	r := rand.New(rand.NewPCG(3, 4))
+	qq := []Query{{}}
+
+	for range 100 {
+		qq = append(qq, Query{
+			Country: countries[r.IntN(len(countries))],
+			Region:  regions[r.IntN(len(regions))],
+			City:    cities[r.IntN(len(cities))],
+			Bundle:  bundles[r.IntN(len(bundles))],
+			Network: networks[r.IntN(len(networks))],
+			Formats: []string{ads.CreativeTypeBanner, ads.CreativeTypeNative},
+		})
+	}
+
+	return qq
+}
+
+// scan - отбор перебором, как до индекса: копия кеша
+// и проверка каждого баннера таргетингами
+func scan(cache *Cache, q Query) []ads.Banner {
+	result := []ads.Banner{}
+
+	for _, b := range cache.All(context.Background()) {
+		if !b.Targeting.Country.Validate(values(q.Country)) ||
+			!b.Targeting.Region.Validate(values(q.Region)) ||
+			!b.Targeting.City.Validate(values(q.City)) ||
+			!b.Targeting.Bundle.Validate(values(q.Bundle)) ||
+			!b.Targeting.Network.Validate(values(q.Network)) {
+			continue
+		}
+
+		if len(q.Formats) > 0 && b.Type != "" && !slices.Contains(q.Formats, b.Type) {
+			continue
+		}
+
+		result = append(result, b)
+	}
+
+	return result
+}
+
+func loaded(t testing.TB, n int) *Cache {
+	repo := &fakeRepo{}
+	repo.set(inventory(n)...)
+
+	cache := newCache(zap.NewNop(), repo, nil, Config{})
+	cache.reload(context.Background())
+
+	require.Equal(t, n, cache.index.Len())
+
+	return cache
+}
+
+func TestIndex_Candidates(t *testing.T) {
+	cache := loaded(t, 2000)
+
+	// индекс дает те же баннеры, что и полный перебор:
+	// включение по AND с одним значением совпадает с OR
+	for _, q := range queries() {
+		assert.Equal(t, ids(scan(cache, q)), ids(cache.Candidates(context.Background(), q)), "query %+v", q)
+	}
+}
+
+func TestIndex_Rules(t *testing.T) {
+	index := NewIndex([]ads.Banner{
+		{ID: "any"},
+		{ID: "ru", Targeting: ads.Targeting{Country: ads.ExcludeInclude{IncludeOr: []string{"RU", "BY"}}}},
+		{ID: "not-ru", Targeting: ads.Targeting{Country: ads.ExcludeInclude{ExcludeOr: []string{"RU"}}}},
+		{ID: "and", Targeting: ads.Targeting{Country: ads.ExcludeInclude{IncludeAnd: []string{"RU", "BY"}}}},
+		{ID: "video", Type: ads.CreativeTypeVideo},
+	})
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{name: "empty query", query: Query{}, want: []string{"any", "not-ru", "video"}},
+		{name: "include", query: Query{Country: "RU"}, want: []string{"and", "any", "ru", "video"}},
+		{name: "exclude", query: Query{Country: "DE"}, want: []string{"any", "not-ru", "video"}},
+		{name: "formats", query: Query{Country: "BY", Formats: []string{ads.CreativeTypeBanner}}, want: []string{"and", "any", "not-ru", "ru"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ids(index.Candidates(tt.query)))
+		})
+	}
+}
+
+func BenchmarkCandidates(b *testing.B) {
+	for _, n := range []int{1000, 10000, 50000} {
+		cache := loaded(b, n)
+		qq := queries()
+
+		b.Run(fmt.Sprintf("scan/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := range b.N {
+				_ = scan(cache, qq[i%len(qq)])
+			}
+		})
+
+		b.Run(fmt.Sprintf("index/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := range b.N {
+				_ = cache.Candidates(context.Background(), qq[i%len(qq)])
+			}
+		})
+	}
+}
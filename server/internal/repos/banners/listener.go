@@ -0,0 +1,112 @@
+package banners
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/pkg/database"
+)
+
+// пауза перед переподключением к базе
+const reconnect = 5 * time.Second
+
+// Change - изменение записи, которое затрагивает баннеры.
+// Пустое изменение означает, что нужна полная перезагрузка
+type Change struct {
+	Table string `json:"table"`
+	ID    string `json:"id"`
+	Op    string `json:"op"`
+}
+
+func (c Change) Full() bool {
+	return c.Table == ""
+}
+
+// Listener слушает уведомления триггеров banners_notify
+type Listener struct {
+	logger *zap.Logger
+	dsn    string
+}
+
+func NewListener(logger *zap.Logger, db database.Config, cfg Config) *Listener {
+	if cfg.Notify.Host != "" {
+		db.Host = cfg.Notify.Host
+	}
+
+	if cfg.Notify.Port != "" {
+		db.Port = cfg.Notify.Port
+	}
+
+	return &Listener{
+		logger: logger.Named("banners"),
+		dsn:    db.Connection(),
+	}
+}
+
+// Listen передает изменения в канал, пока не отменен контекст.
+// После переподключения отправляет полную перезагрузку, потому
+// что уведомления без соединения теряются.
+func (l *Listener) Listen(ctx context.Context, changes chan<- Change) {
+	connected := false
+
+	for {
+		err := l.listen(ctx, changes, connected)
+		if ctx.Err() != nil {
+			return
+		}
+
+		connected = true
+
+		l.logger.Warn("banners listener stopped, reconnect", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnect):
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, changes chan<- Change, reconnected bool) error {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "listen "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	if reconnected {
+		send(ctx, changes, Change{})
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		change := Change{}
+		if err := json.Unmarshal([]byte(n.Payload), &change); err != nil || change.Full() {
+			l.logger.Warn("invalid banners notification", zap.String("payload", n.Payload), zap.Error(err))
+
+			continue
+		}
+
+		send(ctx, changes, change)
+	}
+}
+
+func send(ctx context.Context, changes chan<- Change, change Change) {
+	select {
+	case changes <- change:
+	case <-ctx.Done():
+	}
+}
@@ -3,7 +3,9 @@ package banners
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -11,28 +13,29 @@ import (
 	"go.ads.coffee/platform/server/internal/domain/ads"
 )
 
-type Repo struct {
-	logger *zap.Logger
-	db     *gorm.DB
-}
+// таблицы, изменения в которых затрагивают баннеры
+const (
+	TableBanners     = "banners"
+	TableGroups      = "bgroups"
+	TableCampaigns   = "campaigns"
+	TableAdvertisers = "advertisers"
+)
 
-func NewRepo(logger *zap.Logger, db *gorm.DB) *Repo {
-	return &Repo{
-		logger: logger.Named("banners"),
-		db:     db,
-	}
+var subtree = map[string]string{
+	TableBanners:     "banners.id",
+	TableGroups:      "bgroups.id",
+	TableCampaigns:   "campaigns.id",
+	TableAdvertisers: "advertisers.id",
 }
 
-func (b *Repo) All(ctx context.Context) ([]ads.Banner, error) {
-	rows := []Row{}
-
-	err := b.db.Model(Row{}).Raw(`select 
+const query = `select 
     banners.id as id, 
     banners.title as title, 
     CASE 
         WHEN banners.price IS NOT NULL AND banners.price > 0  THEN banners.price
         ELSE bgroups.price
     END AS price,
+    coalesce(banners.pricing, '') as pricing,
     banners.active as active, 
     
     banners.targeting as banner_targeting,
@@ -44,6 +47,7 @@ func (b *Repo) All(ctx context.Context) ([]ads.Banner, error) {
     bgroups.timetable as bgroup_timetable,
     campaigns.timetable as campaign_timetable,
     advertisers.timetable as advertiser_timetable,
+    advertisers.timezone as advertiser_timezone,
 
     banners.budget as banner_budget,
     bgroups.budget as bgroup_budget,
@@ -57,6 +61,10 @@ func (b *Repo) All(ctx context.Context) ([]ads.Banner, error) {
 
     banners.image as image,
     banners.icon as icon,
+    banners.video as video,
+    banners.media_files as media_files,
+    coalesce(banners.duration, 0) as duration,
+    coalesce(banners.skip_offset, 0) as skip_offset,
 	banners.clicktracker as clicktracker,
 	banners.imptracker as imptracker,
 	banners.target as target,
@@ -73,8 +81,8 @@ func (b *Repo) All(ctx context.Context) ([]ads.Banner, error) {
     
     banners.start as banner_start,
     banners.end as banner_end,
-    bgroups.start as bgroups_start,
-    bgroups.end as bgroups_end,
+    bgroups.start as bgroup_start,
+    bgroups.end as bgroup_end,
     campaigns.start as campaign_start,
     campaigns.end as campaign_end,
     advertisers.start as advertiser_start,
@@ -83,7 +91,9 @@ from banners
 join bgroups ON (banners.bgroup_id = bgroups.id)
 join campaigns ON (bgroups.campaign_id = campaigns.id)
 join advertisers ON (campaigns.advertiser_id = advertisers.id)
-where
+`
+
+const active = `where
     banners.active = true 
     and banners.deleted_at is NULL
     and banners.archived_at is NULL
@@ -99,7 +109,40 @@ where
     and (banners."end" is null or banners."end" >  NOW() or banners."end" < '2001-01-02 00:00:00')
     and (campaigns."end" is null or campaigns."end" >  NOW() or campaigns."end" < '2001-01-02 00:00:00')
     and (bgroups."end" is null or bgroups."end" >  NOW() or bgroups."end" < '2001-01-02 00:00:00')
-    and (advertisers."end" is null or advertisers."end" >  NOW() or advertisers."end" < '2001-01-02 00:00:00')`).Find(&rows).Error
+    and (advertisers."end" is null or advertisers."end" >  NOW() or advertisers."end" < '2001-01-02 00:00:00')`
+
+type Repo struct {
+	logger *zap.Logger
+	db     *gorm.DB
+}
+
+func NewRepo(logger *zap.Logger, db *gorm.DB) *Repo {
+	return &Repo{
+		logger: logger.Named("banners"),
+		db:     db,
+	}
+}
+
+func (b *Repo) All(ctx context.Context) ([]ads.Banner, error) {
+	return b.find(ctx, query+active)
+}
+
+// Subtree загружает активные баннеры под одной записью: самим баннером,
+// группой, кампанией или рекламодателем. Баннеры поддерева, которых
+// нет в ответе, выключены или удалены.
+func (b *Repo) Subtree(ctx context.Context, table string, id string) ([]ads.Banner, error) {
+	column, ok := subtree[table]
+	if !ok {
+		return nil, fmt.Errorf("unknown table %q", table)
+	}
+
+	return b.find(ctx, query+active+" and "+column+" = ?", id)
+}
+
+func (b *Repo) find(ctx context.Context, sql string, values ...any) ([]ads.Banner, error) {
+	rows := []Row{}
+
+	err := b.db.WithContext(ctx).Model(Row{}).Raw(sql, values...).Find(&rows).Error
 	if err != nil {
 		return nil, err
 	}
@@ -121,10 +164,11 @@ where
 
 func toModel(row Row) (ads.Banner, error) {
 	banner := ads.Banner{
-		ID:     row.ID,
-		Title:  row.Title,
-		Price:  row.Price,
-		Active: row.Active,
+		ID:      row.ID,
+		Title:   row.Title,
+		Price:   row.Price,
+		Pricing: row.Pricing,
+		Active:  row.Active,
 
 		Clicktracker: row.Clicktracker,
 		Imptracker:   row.Imptracker,
@@ -201,6 +245,12 @@ func toModel(row Row) (ads.Banner, error) {
 
 	banner.Timetable = att.Merge(ctt).Merge(gtt).Merge(btt)
 
+	if row.AdvertiserTimezone != "" {
+		if banner.Location, err = time.LoadLocation(row.AdvertiserTimezone); err != nil {
+			return ads.Banner{}, err
+		}
+	}
+
 	// budget
 	if banner.BannerBudget, err = ads.NewBudget(row.BannerBudget); err != nil {
 		return ads.Banner{}, err
@@ -244,6 +294,16 @@ func toModel(row Row) (ads.Banner, error) {
 		return ads.Banner{}, err
 	}
 
+	// video
+	if banner.Video, err = ads.NewVideo(row.Video, row.MediaFiles, row.Duration, row.SkipOffset); err != nil {
+		return ads.Banner{}, err
+	}
+
+	// баннер с видео показывается только в видеоплейсментах
+	if len(banner.Video.MediaFiles) > 0 {
+		banner.Type = ads.CreativeTypeVideo
+	}
+
 	return banner, nil
 }
 
@@ -271,6 +331,12 @@ func (e excludeInclude) merge(source excludeInclude) excludeInclude {
 	if len(source.ExcludeAnd) > 0 {
 		result.ExcludeAnd = source.ExcludeAnd
 	}
+	if len(source.Include) > 0 {
+		result.Include = source.Include
+	}
+	if len(source.Exclude) > 0 {
+		result.Exclude = source.Exclude
+	}
 
 	return result
 }
@@ -291,15 +357,13 @@ func (e excludeInclude) toExcludeIncludeIP() ads.ExcludeIncludeIP {
 	}
 
 	for _, v := range e.Include {
-		_, network, err := net.ParseCIDR(v)
-		if err != nil {
+		if network, ok := parseNetwork(v); ok {
 			t.Include = append(t.Include, network)
 		}
 	}
 
 	for _, v := range e.Exclude {
-		_, network, err := net.ParseCIDR(v)
-		if err != nil {
+		if network, ok := parseNetwork(v); ok {
 			t.Exclude = append(t.Exclude, network)
 		}
 	}
@@ -307,6 +371,24 @@ func (e excludeInclude) toExcludeIncludeIP() ads.ExcludeIncludeIP {
 	return t
 }
 
+// parseNetwork разбирает подсеть или отдельный ip адрес
+func parseNetwork(v string) (*net.IPNet, bool) {
+	if _, network, err := net.ParseCIDR(v); err == nil {
+		return network, true
+	}
+
+	ip := net.ParseIP(v)
+	if ip == nil {
+		return nil, false
+	}
+
+	if ip.To4() != nil {
+		return &net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)}, true
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, true
+}
+
 type targeting struct {
 	Bundle   excludeInclude `json:"bundle"`
 	Audience excludeInclude `json:"audience"`
@@ -350,7 +432,7 @@ func (t targeting) toDomain() ads.Targeting {
 		Bundle:   t.Bundle.toExcludeIncludeString(),
 		Audience: t.Audience.toExcludeIncludeString(),
 		Bapp:     t.Bapp.toExcludeIncludeString(),
-		IP:       t.Bapp.toExcludeIncludeIP(),
+		IP:       t.IP.toExcludeIncludeIP(),
 		Country:  t.Country.toExcludeIncludeString(),
 		City:     t.City.toExcludeIncludeString(),
 		Region:   t.Region.toExcludeIncludeString(),
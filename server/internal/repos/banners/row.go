@@ -3,10 +3,11 @@ package banners
 import "time"
 
 type Row struct {
-	ID     string
-	Title  string
-	Price  int
-	Active bool
+	ID      string
+	Title   string
+	Price   int
+	Pricing string
+	Active  bool
 
 	BannerTargeting     string `gorm:"column:banner_targeting"`
 	GroupTargeting      string `gorm:"column:bgroup_targeting"`
@@ -17,10 +18,11 @@ type Row struct {
 	GroupTimetable      string `gorm:"column:bgroup_timetable"`
 	CampaignTimetable   string `gorm:"column:campaign_timetable"`
 	AdvertiserTimetable string `gorm:"column:advertiser_timetable"`
+	AdvertiserTimezone  string `gorm:"column:advertiser_timezone"`
 
 	BannerBudget     string `gorm:"column:banner_budget"`
 	GroupBudget      string `gorm:"column:bgroup_budget"`
-	CampaignBudget   string `gorm:"column:ampaign_budget"`
+	CampaignBudget   string `gorm:"column:campaign_budget"`
 	AdvertiserBudget string `gorm:"column:advertiser_budget"`
 
 	BannerCapping     string `gorm:"column:banner_capping"`
@@ -30,6 +32,10 @@ type Row struct {
 
 	Image        string
 	Icon         string
+	Video        string
+	MediaFiles   string `gorm:"column:media_files"`
+	Duration     int
+	SkipOffset   int `gorm:"column:skip_offset"`
 	Clicktracker string
 	Imptracker   string
 	Target       string
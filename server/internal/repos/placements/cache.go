@@ -1,11 +1,91 @@
 package placements
 
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
 type Cache struct {
-	repo *Repo
+	logger   *zap.Logger
+	repo     *Repo
+	interval time.Duration
+
+	// закрывается после первой успешной загрузки
+	loaded chan struct{}
+	once   sync.Once
+
+	lock           sync.RWMutex
+	placementsById map[string]ads.Placement
 }
 
-func NewCache(repo *Repo) *Cache {
+func NewCache(logger *zap.Logger, repo *Repo, cfg Config) *Cache {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
 	return &Cache{
-		repo: repo,
+		logger:         logger,
+		repo:           repo,
+		interval:       interval,
+		loaded:         make(chan struct{}),
+		placementsById: map[string]ads.Placement{},
+	}
+}
+
+func (c *Cache) One(ctx context.Context, id string) (ads.Placement, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	p, ok := c.placementsById[id]
+
+	return p, ok
+}
+
+// Loaded закрывается, когда плейсменты загружены в первый раз
+func (c *Cache) Loaded() <-chan struct{} {
+	return c.loaded
+}
+
+// Start загружает плейсменты и обновляет кеш до отмены контекста.
+func (c *Cache) Start(ctx context.Context) {
+	c.reload(ctx)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.reload(ctx)
+		}
 	}
 }
+
+func (c *Cache) reload(ctx context.Context) {
+	placements, err := c.repo.All(ctx)
+	if err != nil {
+		c.logger.Error("error on get placements from repo", zap.Error(err))
+
+		return
+	}
+
+	// удаленные плейсменты должны пропасть из кеша
+	byId := make(map[string]ads.Placement, len(placements))
+	for _, placement := range placements {
+		byId[placement.ID] = placement
+	}
+
+	c.lock.Lock()
+	c.placementsById = byId
+	c.lock.Unlock()
+
+	c.once.Do(func() { close(c.loaded) })
+}
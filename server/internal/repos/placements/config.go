@@ -0,0 +1,10 @@
+package placements
+
+import "time"
+
+const defaultInterval = time.Minute
+
+type Config struct {
+	// как часто перечитывать плейсменты из базы
+	Interval time.Duration `yaml:"interval"`
+}
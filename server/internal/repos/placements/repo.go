@@ -2,6 +2,7 @@ package placements
 
 import (
 	"context"
+	"strings"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -24,5 +25,100 @@ func NewRepo(logger *zap.Logger, db *gorm.DB) *Repo {
 }
 
 func (b *Repo) All(ctx context.Context) ([]ads.Placement, error) {
-	return nil, nil
+	rows := []Row{}
+
+	err := b.db.WithContext(ctx).Model(Row{}).Raw(`select
+    placements.id as id,
+    placements.name as name,
+    placements.formats as formats,
+    placements.floor as floor,
+    placements.timezone as timezone,
+    coalesce(placements.rewarded, false) as rewarded,
+    coalesce(placements.reward_name, '') as reward_name,
+    coalesce(placements.reward_amount, 0) as reward_amount,
+    coalesce(placements.callback, '') as callback,
+    coalesce(placements.secret, '') as secret,
+
+    units.id as unit_id,
+    units.name as unit_name,
+    units.format as unit_format,
+    units.price as unit_price,
+    networks.name as network_name
+from placements
+left join placement_units ON (placement_units.placement_id = placements.id)
+left join units ON (placement_units.unit_id = units.id and units.deleted_at is NULL)
+left join networks ON (units.network_id = networks.id and networks.deleted_at is NULL)
+where
+    placements.deleted_at is NULL
+order by placements.id, units.id`).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return toModels(rows), nil
+}
+
+// toModels собирает плейсменты из строк джойна,
+// по строке на каждый юнит
+func toModels(rows []Row) []ads.Placement {
+	placements := []ads.Placement{}
+	index := map[string]int{}
+
+	for _, row := range rows {
+		i, ok := index[row.ID]
+		if !ok {
+			i = len(placements)
+			index[row.ID] = i
+
+			placements = append(placements, ads.Placement{
+				ID:       row.ID,
+				Name:     row.Name,
+				Formats:  formats(row.Formats),
+				Floor:    row.Floor,
+				Timezone: row.Timezone,
+				Units:    []ads.Unit{},
+				Reward:   reward(row),
+			})
+		}
+
+		// юнит удален или у плейсмента нет юнитов
+		if row.UnitID == "" || row.UnitName == "" {
+			continue
+		}
+
+		placements[i].Units = append(placements[i].Units, ads.Unit{
+			ID:      row.UnitName,
+			Title:   row.UnitName,
+			Network: row.NetworkName,
+			Price:   row.UnitPrice,
+			Format:  row.UnitFormat,
+		})
+	}
+
+	return placements
+}
+
+func reward(row Row) *ads.Reward {
+	if !row.Rewarded {
+		return nil
+	}
+
+	return &ads.Reward{
+		Name:     row.RewardName,
+		Amount:   row.RewardAmount,
+		Callback: row.Callback,
+		Secret:   row.Secret,
+	}
+}
+
+func formats(v string) []string {
+	ff := []string{}
+
+	for _, f := range strings.Split(v, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			ff = append(ff, f)
+		}
+	}
+
+	return ff
 }
@@ -0,0 +1,48 @@
+package placements
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+func TestToModels(t *testing.T) {
+	rows := []Row{
+		{ID: "1", Name: "main", Formats: "banner, native", Floor: 1.5, Timezone: "Europe/Moscow", UnitID: "10", UnitName: "R-A-1-1", UnitFormat: "banner", UnitPrice: 10, NetworkName: "yandex"},
+		{ID: "1", Name: "main", Formats: "banner, native", Floor: 1.5, Timezone: "Europe/Moscow", UnitID: "11", UnitName: "vk-1", UnitFormat: "native", UnitPrice: 5, NetworkName: "vk"},
+		{ID: "2", Name: "empty"},
+		{ID: "3", Name: "rewarded", Rewarded: true, RewardName: "coins", RewardAmount: 10, Callback: "https://publisher.com/ssv", Secret: "secret"},
+	}
+
+	placements := toModels(rows)
+	require.Len(t, placements, 3)
+
+	assert.Equal(t, ads.Placement{
+		ID:       "1",
+		Name:     "main",
+		Formats:  []string{"banner", "native"},
+		Floor:    1.5,
+		Timezone: "Europe/Moscow",
+		Units: []ads.Unit{
+			{ID: "R-A-1-1", Title: "R-A-1-1", Network: "yandex", Price: 10, Format: "banner"},
+			{ID: "vk-1", Title: "vk-1", Network: "vk", Price: 5, Format: "native"},
+		},
+	}, placements[0])
+
+	assert.Equal(t, ads.Placement{
+		ID:      "2",
+		Name:    "empty",
+		Formats: []string{},
+		Units:   []ads.Unit{},
+	}, placements[1])
+
+	assert.Equal(t, &ads.Reward{
+		Name:     "coins",
+		Amount:   10,
+		Callback: "https://publisher.com/ssv",
+		Secret:   "secret",
+	}, placements[2].Reward)
+}
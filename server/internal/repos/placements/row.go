@@ -0,0 +1,21 @@
+package placements
+
+type Row struct {
+	ID       string
+	Name     string
+	Formats  string
+	Floor    float64
+	Timezone string
+
+	Rewarded     bool
+	RewardName   string `gorm:"column:reward_name"`
+	RewardAmount int    `gorm:"column:reward_amount"`
+	Callback     string
+	Secret       string
+
+	UnitID      string `gorm:"column:unit_id"`
+	UnitName    string `gorm:"column:unit_name"`
+	UnitFormat  string `gorm:"column:unit_format"`
+	UnitPrice   int    `gorm:"column:unit_price"`
+	NetworkName string `gorm:"column:network_name"`
+}
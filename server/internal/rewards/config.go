@@ -0,0 +1,20 @@
+package rewards
+
+import "time"
+
+const (
+	defaultAttempts   = 5
+	defaultBackoff    = time.Second
+	defaultMaxBackoff = time.Minute
+	defaultTimeout    = 5 * time.Second
+)
+
+type Config struct {
+	// сколько раз вызывать колбэк паблишера, пока он не ответит 2xx
+	Attempts int `yaml:"attempts"`
+	// пауза перед первым повтором, дальше удваивается до max_backoff
+	Backoff    time.Duration `yaml:"backoff"`
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+	// таймаут одного запроса
+	Timeout time.Duration `yaml:"timeout"`
+}
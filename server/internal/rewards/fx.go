@@ -0,0 +1,13 @@
+package rewards
+
+import (
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module(
+	"rewards",
+
+	fx.Provide(
+		New,
+	),
+)
@@ -0,0 +1,225 @@
+package rewards
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/pkg/circuitbreaker"
+	"go.ads.coffee/platform/pkg/ssv"
+	"go.ads.coffee/platform/server/internal/analytics"
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/repos/placements"
+)
+
+// шаблон circuit для колбэков. У каждого паблишера свой
+// circuit rewards.<host> с настройками шаблона
+const Circuit = "rewards"
+
+type Placements interface {
+	One(ctx context.Context, id string) (ads.Placement, bool)
+}
+
+type Analytics interface {
+	LogReward(ctx context.Context, data ads.TrackerInfo) error
+}
+
+type Circuits interface {
+	Derive(name string, template string) circuitbreaker.Circuit
+}
+
+// Rewards выдает награду за досмотр видео в наградном плейсменте
+// и сообщает о ней паблишеру подписанным колбэком (SSV)
+type Rewards struct {
+	logger     *zap.Logger
+	placements Placements
+	analytics  Analytics
+	circuits   Circuits
+	client     *http.Client
+
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+func New(
+	logger *zap.Logger,
+	cfg Config,
+	pool *circuitbreaker.Pool,
+	cache *placements.Cache,
+	analytics *analytics.Analytics,
+) *Rewards {
+	r := &Rewards{
+		logger:     logger,
+		placements: cache,
+		analytics:  analytics,
+		circuits:   pool,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		attempts:   cfg.Attempts,
+		backoff:    cfg.Backoff,
+		maxBackoff: cfg.MaxBackoff,
+	}
+
+	if r.attempts <= 0 {
+		r.attempts = defaultAttempts
+	}
+
+	if r.backoff <= 0 {
+		r.backoff = defaultBackoff
+	}
+
+	if r.maxBackoff <= 0 {
+		r.maxBackoff = defaultMaxBackoff
+	}
+
+	if r.client.Timeout <= 0 {
+		r.client.Timeout = defaultTimeout
+	}
+
+	return r
+}
+
+// Grant выдает награду, если плейсмент наградной. Колбэк
+// вызывается асинхронно, повторы не задерживают трекер
+func (r *Rewards) Grant(ctx context.Context, info ads.TrackerInfo) {
+	placement, ok := r.placements.One(ctx, info.PlacementID)
+	if !ok || placement.Reward == nil {
+		return
+	}
+
+	if err := r.analytics.LogReward(ctx, info); err != nil {
+		r.logger.Error("error on log reward", zap.Error(err))
+	}
+
+	// награду начисляет клиент, серверная проверка не нужна
+	if placement.Reward.Callback == "" {
+		return
+	}
+
+	link, err := Callback(*placement.Reward, info, time.Now())
+	if err != nil {
+		r.logger.Error("error on build reward callback",
+			zap.String("placement", info.PlacementID), zap.Error(err))
+
+		return
+	}
+
+	go r.send(context.WithoutCancel(ctx), link)
+}
+
+// Callback - подписанная ссылка на колбэк паблишера. Параметры
+// из ссылки в настройках плейсмента тоже входят в подпись
+func Callback(reward ads.Reward, info ads.TrackerInfo, now time.Time) (*url.URL, error) {
+	if reward.Secret == "" {
+		return nil, ssv.ErrNoSecret
+	}
+
+	u, err := url.Parse(reward.Callback)
+	if err != nil {
+		return nil, fmt.Errorf("invalid callback: %w", err)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid callback %q: host is empty", reward.Callback)
+	}
+
+	values := u.Query()
+	values.Set(ssv.ParamUserID, info.UserID)
+	values.Set(ssv.ParamPlacementID, info.PlacementID)
+	values.Set(ssv.ParamRewardName, reward.Name)
+	values.Set(ssv.ParamRewardAmount, strconv.Itoa(reward.Amount))
+	values.Set(ssv.ParamTransactionID, TransactionID(info))
+	values.Set(ssv.ParamTimestamp, strconv.FormatInt(now.Unix(), 10))
+	values.Set(ssv.ParamSignature, ssv.Sign(values, reward.Secret))
+
+	u.RawQuery = values.Encode()
+
+	return u, nil
+}
+
+// TransactionID одинаковый для всех попыток одного показа,
+// по нему паблишер отсекает повторные колбэки
+func TransactionID(info ads.TrackerInfo) string {
+	sum := sha256.Sum256([]byte(info.RequestID + ":" + info.BannerID))
+
+	return hex.EncodeToString(sum[:16])
+}
+
+// send вызывает колбэк с экспоненциальной паузой между попытками.
+// Отказ паблишера (4xx) не повторяем
+func (r *Rewards) send(ctx context.Context, link *url.URL) {
+	circuit := r.circuits.Derive(Circuit+"."+link.Host, Circuit)
+	backoff := r.backoff
+
+	for attempt := 1; ; attempt++ {
+		err := circuit.Run(ctx, func(ctx context.Context) error {
+			return r.call(ctx, link.String())
+		})
+		if err == nil {
+			return
+		}
+
+		if errors.As(err, new(rejected)) || attempt >= r.attempts {
+			r.logger.Error("error on call reward callback",
+				zap.String("host", link.Host),
+				zap.String("transaction", link.Query().Get(ssv.ParamTransactionID)),
+				zap.Int("attempts", attempt),
+				zap.Error(err))
+
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, r.maxBackoff)
+	}
+}
+
+func (r *Rewards) call(ctx context.Context, link string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	case resp.StatusCode >= http.StatusBadRequest:
+		return rejected(resp.StatusCode)
+	}
+
+	return nil
+}
+
+// rejected - паблишер отклонил колбэк. Это не сбой партнера,
+// поэтому circuit такую ошибку не учитывает (BadRequest)
+type rejected int
+
+func (e rejected) Error() string {
+	return fmt.Sprintf("callback rejected with status %d", int(e))
+}
+
+func (e rejected) BadRequest() bool {
+	return true
+}
@@ -0,0 +1,196 @@
+package rewards
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/pkg/circuitbreaker"
+	"go.ads.coffee/platform/pkg/ssv"
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+type cache map[string]ads.Placement
+
+func (c cache) One(ctx context.Context, id string) (ads.Placement, bool) {
+	p, ok := c[id]
+
+	return p, ok
+}
+
+type analyticsMock struct {
+	rewards atomic.Int32
+}
+
+func (a *analyticsMock) LogReward(ctx context.Context, data ads.TrackerInfo) error {
+	a.rewards.Add(1)
+
+	return nil
+}
+
+var info = ads.TrackerInfo{
+	RequestID:   "request-1",
+	BannerID:    "banner-1",
+	UserID:      "user-1",
+	PlacementID: "placement-1",
+}
+
+func newTestRewards(callback string, analytics *analyticsMock) *Rewards {
+	var pool *circuitbreaker.Pool
+
+	return &Rewards{
+		logger: zap.NewNop(),
+		placements: cache{
+			"placement-1": {
+				ID: "placement-1",
+				Reward: &ads.Reward{
+					Name:     "coins",
+					Amount:   10,
+					Callback: callback,
+					Secret:   "secret",
+				},
+			},
+			"placement-2": {ID: "placement-2"},
+		},
+		analytics:  analytics,
+		circuits:   pool,
+		client:     http.DefaultClient,
+		attempts:   3,
+		backoff:    time.Millisecond,
+		maxBackoff: 2 * time.Millisecond,
+	}
+}
+
+func TestNew(t *testing.T) {
+	r := New(zap.NewNop(), Config{}, nil, nil, nil)
+
+	assert.Equal(t, defaultAttempts, r.attempts)
+	assert.Equal(t, defaultBackoff, r.backoff)
+	assert.Equal(t, defaultMaxBackoff, r.maxBackoff)
+	assert.Equal(t, defaultTimeout, r.client.Timeout)
+}
+
+func TestCallback(t *testing.T) {
+	reward := ads.Reward{Name: "coins", Amount: 10, Callback: "https://publisher.com/ssv?app=game", Secret: "secret"}
+	now := time.Unix(1760796000, 0)
+
+	link, err := Callback(reward, info, now)
+	require.NoError(t, err)
+
+	values := link.Query()
+
+	assert.Equal(t, "publisher.com", link.Host)
+	assert.Equal(t, "game", values.Get("app"))
+	assert.Equal(t, "user-1", values.Get(ssv.ParamUserID))
+	assert.Equal(t, "placement-1", values.Get(ssv.ParamPlacementID))
+	assert.Equal(t, "coins", values.Get(ssv.ParamRewardName))
+	assert.Equal(t, "10", values.Get(ssv.ParamRewardAmount))
+	assert.Equal(t, TransactionID(info), values.Get(ssv.ParamTransactionID))
+	assert.Equal(t, "1760796000", values.Get(ssv.ParamTimestamp))
+
+	// паблишер проверяет подпись своим секретом
+	assert.NoError(t, ssv.Verify(values, "secret", 0))
+
+	_, err = Callback(ads.Reward{Callback: "https://publisher.com/ssv"}, info, now)
+	assert.ErrorIs(t, err, ssv.ErrNoSecret)
+
+	_, err = Callback(ads.Reward{Callback: "/ssv", Secret: "secret"}, info, now)
+	assert.Error(t, err)
+}
+
+func TestTransactionID(t *testing.T) {
+	assert.Len(t, TransactionID(info), 32)
+	assert.Equal(t, TransactionID(info), TransactionID(info))
+
+	other := info
+	other.BannerID = "banner-2"
+	assert.NotEqual(t, TransactionID(info), TransactionID(other))
+}
+
+func TestRewards_Grant(t *testing.T) {
+	calls := make(chan url.Values, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls <- r.URL.Query()
+	}))
+	defer srv.Close()
+
+	analytics := &analyticsMock{}
+	r := newTestRewards(srv.URL+"/ssv", analytics)
+
+	r.Grant(context.Background(), info)
+
+	select {
+	case values := <-calls:
+		assert.NoError(t, ssv.Verify(values, "secret", time.Minute))
+		assert.Equal(t, "user-1", values.Get(ssv.ParamUserID))
+	case <-time.After(time.Second):
+		require.FailNow(t, "callback was not called")
+	}
+
+	assert.EqualValues(t, 1, analytics.rewards.Load())
+}
+
+func TestRewards_Grant_NotRewarded(t *testing.T) {
+	analytics := &analyticsMock{}
+	r := newTestRewards("", analytics)
+
+	// плейсмент без награды и неизвестный плейсмент
+	r.Grant(context.Background(), ads.TrackerInfo{PlacementID: "placement-2"})
+	r.Grant(context.Background(), ads.TrackerInfo{PlacementID: "unknown"})
+	assert.EqualValues(t, 0, analytics.rewards.Load())
+
+	// награда без колбэка только пишется в аналитику
+	r.Grant(context.Background(), info)
+	assert.EqualValues(t, 1, analytics.rewards.Load())
+}
+
+func TestRewards_Send_Retry(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	r := newTestRewards(srv.URL, &analyticsMock{})
+
+	link, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	r.send(context.Background(), link)
+	assert.EqualValues(t, 3, calls.Load())
+
+	// попытки кончились
+	calls.Store(-10)
+	r.send(context.Background(), link)
+	assert.EqualValues(t, -7, calls.Load())
+}
+
+func TestRewards_Send_Rejected(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	r := newTestRewards(srv.URL, &analyticsMock{})
+
+	link, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	r.send(context.Background(), link)
+	assert.EqualValues(t, 1, calls.Load())
+}
@@ -0,0 +1,28 @@
+package server
+
+import "time"
+
+type Config struct {
+	// адрес для рекламных запросов
+	Port string `yaml:"port"`
+	// отдельный адрес для health и метрик,
+	// если пустой - они доступны на основном порту
+	AdminPort string `yaml:"admin_port"`
+
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+
+	TLS TLS `yaml:"tls"`
+	// http/2 без tls, например за балансером
+	H2C bool `yaml:"h2c"`
+
+	AccessLog bool `yaml:"access_log"`
+}
+
+type TLS struct {
+	Enabled bool   `yaml:"enabled"`
+	Cert    string `yaml:"cert"`
+	Key     string `yaml:"key"`
+}
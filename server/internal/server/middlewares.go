@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/server/internal/tools/network"
+)
+
+const (
+	headerRequestID = "X-Request-Id"
+
+	// id из заголовка попадает в логи и ответ,
+	// поэтому длинный или странный заменяем своим
+	maxRequestID = 64
+)
+
+// requestID берет id запроса из заголовка или создает новый
+// и возвращает его в ответе
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(headerRequestID)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(headerRequestID, id)
+
+		ctx := context.WithValue(r.Context(), chimiddleware.RequestIDKey, id)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID - буквы, цифры и -_.: не длиннее maxRequestID
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestID {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// recoverer не дает панике в плагине уронить сервер
+func recoverer(logger *zap.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+
+				// соединение закрыто клиентом, ответ уже не нужен
+				if rvr == http.ErrAbortHandler { //nolint:errorlint
+					panic(rvr)
+				}
+
+				logger.Error("panic on handle request",
+					zap.Any("panic", rvr),
+					zap.String("request_id", chimiddleware.GetReqID(r.Context())),
+					zap.String("path", r.URL.Path),
+					zap.Stack("stack"),
+				)
+
+				w.WriteHeader(http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func accessLog(logger *zap.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ts := time.Now()
+
+			next.ServeHTTP(ww, r)
+
+			logger.Info("request",
+				zap.String("request_id", chimiddleware.GetReqID(r.Context())),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", ww.Status()),
+				zap.Int("bytes", ww.BytesWritten()),
+				zap.Duration("duration", time.Since(ts)),
+				zap.String("ip", network.ClientIP(r)),
+				zap.String("ua", r.UserAgent()),
+			)
+		})
+	}
+}
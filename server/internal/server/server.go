@@ -2,50 +2,154 @@ package server
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
 
+	"go.ads.coffee/platform/pkg/health"
+	middleware "go.ads.coffee/platform/pkg/middlewares"
+	"go.ads.coffee/platform/pkg/telemetry"
 	"go.ads.coffee/platform/server/internal/pipeline"
 )
 
-const addr = ":9090"
+const defaultPort = ":9090"
 
 type Manager interface {
 	Mount(router *chi.Mux)
 }
 
+type Health interface {
+	Handler() http.HandlerFunc
+	HandlerExternal() http.HandlerFunc
+}
+
+type Telemetry interface {
+	middleware.Telemetry
+	Handler() http.HandlerFunc
+}
+
 type Server struct {
+	cfg     Config
+	logger  *zap.Logger
 	srv     *http.Server
+	ops     *http.Server
 	manager Manager
+	health  Health
+	tel     Telemetry
+	metrics func(next http.Handler) http.Handler
+}
+
+func New(
+	cfg Config,
+	logger *zap.Logger,
+	manager *pipeline.Manager,
+	health *health.Health,
+	tel *telemetry.Telemetry,
+) (*Server, error) {
+	return newServer(cfg, logger, manager, health, tel)
 }
 
-func New(manager *pipeline.Manager) *Server {
-	return &Server{
-		srv:     &http.Server{Addr: addr},
+func newServer(cfg Config, logger *zap.Logger, manager Manager, health Health, tel Telemetry) (*Server, error) {
+	metrics, err := middleware.Metrics(tel)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Port == "" {
+		cfg.Port = defaultPort
+	}
+
+	s := &Server{
+		cfg:     cfg,
+		logger:  logger,
+		srv:     httpServer(cfg, cfg.Port),
 		manager: manager,
+		health:  health,
+		tel:     tel,
+		metrics: metrics,
+	}
+
+	if cfg.AdminPort != "" {
+		s.ops = httpServer(cfg, cfg.AdminPort)
 	}
+
+	return s, nil
+}
+
+func httpServer(cfg Config, addr string) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	if cfg.H2C && !cfg.TLS.Enabled {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	return srv
 }
 
 func (s *Server) Start(ctx context.Context) error {
 	router := chi.NewRouter()
 
+	router.Use(requestID, recoverer(s.logger), s.metrics)
+
+	if s.cfg.AccessLog {
+		router.Use(accessLog(s.logger))
+	}
+
 	s.manager.Mount(router)
 
-	http.Handle("/", router)
+	s.srv.Handler = router
+
+	if s.ops == nil {
+		s.mountOps(router)
+	} else {
+		ops := chi.NewRouter()
+		s.mountOps(ops)
+
+		s.ops.Handler = ops
+
+		if err := s.serve(s.ops, false); err != nil {
+			return err
+		}
+	}
+
+	return s.serve(s.srv, s.cfg.TLS.Enabled)
+}
 
-	ln, err := net.Listen("tcp", addr)
+func (s *Server) mountOps(router *chi.Mux) {
+	router.Get("/health", s.health.Handler())
+	router.Get("/health/external", s.health.HandlerExternal())
+	router.Get("/metrics", s.tel.Handler())
+}
+
+func (s *Server) serve(srv *http.Server, tls bool) error {
+	ln, err := net.Listen("tcp", srv.Addr)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Served at http://localhost" + addr)
+	s.logger.Info("server started", zap.String("addr", ln.Addr().String()), zap.Bool("tls", tls))
 
 	go func() {
-		if err := s.srv.Serve(ln); err != nil {
-			fmt.Println(err)
+		var err error
+		if tls {
+			err = srv.ServeTLS(ln, s.cfg.TLS.Cert, s.cfg.TLS.Key)
+		} else {
+			err = srv.Serve(ln)
+		}
+
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("server stopped", zap.String("addr", srv.Addr), zap.Error(err))
 		}
 	}()
 
@@ -53,5 +157,11 @@ func (s *Server) Start(ctx context.Context) error {
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	if s.ops != nil {
+		if err := s.ops.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+
 	return s.srv.Shutdown(ctx)
 }
@@ -3,15 +3,24 @@ package server
 
 import (
 	"context"
+	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
+const addr = "127.0.0.1:19090"
+
 type mockManager struct {
 	mountCalled bool
 }
@@ -22,105 +31,193 @@ func (m *mockManager) Mount(router *chi.Mux) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
+	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+}
+
+type mockHealth struct{}
+
+func (mockHealth) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("health"))
+	}
+}
+
+func (mockHealth) HandlerExternal() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("external"))
+	}
+}
+
+type mockTelemetry struct {
+	registry *prometheus.Registry
+}
+
+func (m *mockTelemetry) Register(collectors ...prometheus.Collector) error {
+	for _, c := range collectors {
+		if err := m.registry.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *mockTelemetry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("metrics"))
+	}
+}
+
+func newTestServer(t *testing.T, cfg Config, mgr Manager) *Server {
+	server, err := newServer(cfg, zap.NewNop(), mgr, mockHealth{}, &mockTelemetry{registry: prometheus.NewRegistry()})
+	require.NoError(t, err)
+
+	return server
+}
+
+func get(t *testing.T, url string) (int, string, http.Header) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, string(body), resp.Header
+}
+
+func shutdown(t *testing.T, server *Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, server.Shutdown(ctx))
 }
 
 func TestNew(t *testing.T) {
 	mockMgr := &mockManager{}
 
-	server := &Server{
-		srv:     &http.Server{Addr: addr},
-		manager: mockMgr,
-	}
+	server := newTestServer(t, Config{
+		ReadTimeout:  time.Second,
+		WriteTimeout: 2 * time.Second,
+		IdleTimeout:  3 * time.Second,
+	}, mockMgr)
 
 	assert.NotNil(t, server)
 	assert.Equal(t, mockMgr, server.manager)
 	assert.NotNil(t, server.srv)
-	assert.Equal(t, addr, server.srv.Addr)
+	assert.Nil(t, server.ops)
+	assert.Equal(t, defaultPort, server.srv.Addr)
+	assert.Equal(t, time.Second, server.srv.ReadTimeout)
+	assert.Equal(t, 2*time.Second, server.srv.WriteTimeout)
+	assert.Equal(t, 3*time.Second, server.srv.IdleTimeout)
+	assert.Nil(t, server.srv.Protocols)
+}
+
+func TestNew_H2C(t *testing.T) {
+	server := newTestServer(t, Config{Port: addr, H2C: true}, &mockManager{})
+
+	require.NotNil(t, server.srv.Protocols)
+	assert.True(t, server.srv.Protocols.HTTP1())
+	assert.True(t, server.srv.Protocols.UnencryptedHTTP2())
 }
 
 func TestServer_StartSuccess(t *testing.T) {
 	mockMgr := &mockManager{}
 
-	server := &Server{
-		srv:     &http.Server{Addr: addr},
-		manager: mockMgr,
-	}
-
-	ctx := context.Background()
+	server := newTestServer(t, Config{Port: addr}, mockMgr)
 
-	err := server.Start(ctx)
+	err := server.Start(context.Background())
 
 	assert.NoError(t, err)
 	assert.True(t, mockMgr.mountCalled)
 
-	time.Sleep(100 * time.Millisecond)
-
 	conn, err := net.DialTimeout("tcp", addr, time.Second)
 	assert.NoError(t, err)
 	err = conn.Close()
 	assert.NoError(t, err)
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	err = server.Shutdown(shutdownCtx)
-	assert.NoError(t, err)
+	shutdown(t, server)
 }
 
-func TestServer_Shutdown(t *testing.T) {
-	oldServeMux := http.DefaultServeMux
-	http.DefaultServeMux = http.NewServeMux()
-	defer func() {
-		http.DefaultServeMux = oldServeMux
-	}()
+func TestServer_Integration(t *testing.T) {
+	server := newTestServer(t, Config{Port: addr}, &mockManager{})
 
-	mockMgr := &mockManager{}
+	require.NoError(t, server.Start(context.Background()))
+	defer shutdown(t, server)
 
-	server := &Server{
-		srv:     &http.Server{Addr: addr},
-		manager: mockMgr,
-	}
+	status, body, header := get(t, "http://"+addr+"/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "OK", body)
+	assert.NotEmpty(t, header.Get(headerRequestID))
 
-	ctx := context.Background()
-	err := server.Start(ctx)
-	assert.NoError(t, err)
+	// health и метрики на основном порту
+	_, body, _ = get(t, "http://"+addr+"/health")
+	assert.Equal(t, "health", body)
 
-	time.Sleep(100 * time.Millisecond)
+	_, body, _ = get(t, "http://"+addr+"/health/external")
+	assert.Equal(t, "external", body)
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	err = server.Shutdown(shutdownCtx)
-	assert.NoError(t, err)
+	_, body, _ = get(t, "http://"+addr+"/metrics")
+	assert.Equal(t, "metrics", body)
+
+	// паника в обработчике не роняет сервер
+	status, _, _ = get(t, "http://"+addr+"/panic")
+	assert.Equal(t, http.StatusInternalServerError, status)
+
+	status, _, _ = get(t, "http://"+addr+"/")
+	assert.Equal(t, http.StatusOK, status)
 }
 
-func TestServer_Integration(t *testing.T) {
-	oldServeMux := http.DefaultServeMux
-	http.DefaultServeMux = http.NewServeMux()
-	defer func() {
-		http.DefaultServeMux = oldServeMux
-	}()
+func TestServer_AdminPort(t *testing.T) {
+	admin := "127.0.0.1:19091"
 
-	mockMgr := &mockManager{}
+	server := newTestServer(t, Config{Port: addr, AdminPort: admin}, &mockManager{})
 
-	server := &Server{
-		srv:     &http.Server{Addr: addr},
-		manager: mockMgr,
-	}
+	require.NoError(t, server.Start(context.Background()))
+	defer shutdown(t, server)
 
-	ctx := context.Background()
-	err := server.Start(ctx)
-	assert.NoError(t, err)
+	_, body, _ := get(t, "http://"+admin+"/health")
+	assert.Equal(t, "health", body)
 
-	time.Sleep(100 * time.Millisecond)
+	_, body, _ = get(t, "http://"+admin+"/metrics")
+	assert.Equal(t, "metrics", body)
 
-	resp, err := http.Get("http://localhost" + addr)
-	if err == nil {
-		resp.Body.Close()
-	}
+	status, _, _ := get(t, "http://"+addr+"/metrics")
+	assert.Equal(t, http.StatusNotFound, status)
+}
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	err = server.Shutdown(shutdownCtx)
-	assert.NoError(t, err)
+func TestRequestID(t *testing.T) {
+	handler := requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
 
-	assert.True(t, mockMgr.mountCalled)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(headerRequestID, "request-1")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, "request-1", rr.Header().Get(headerRequestID))
+}
+
+func TestRequestID_Invalid(t *testing.T) {
+	handler := requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, id := range []string{
+		"",
+		strings.Repeat("a", maxRequestID+1),
+		"request 1",
+		"request\x00",
+		`"><script>`,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(headerRequestID, id)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		// вместо чужого id новый uuid
+		_, err := uuid.Parse(rr.Header().Get(headerRequestID))
+		assert.NoError(t, err, id)
+	}
 }
@@ -0,0 +1,28 @@
+package sessions
+
+import "time"
+
+// Хранилища сессий
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+	BackendToken  = "token"
+)
+
+const (
+	defaultTTL    = 10 * time.Minute
+	defaultSecret = "changeme"
+)
+
+type Config struct {
+	// memory - отпечаток в памяти процесса, работает только с одной репликой;
+	// redis - отпечаток в redis, общий для всех реплик;
+	// token - подписанный токен в ссылке клика, ее отдает экшен frame
+	Backend string `yaml:"backend"`
+	// где искать сессию по отпечатку, если в ссылке клика нет токена.
+	// Используется только для backend: token, пустое значение - без отпечатка
+	Fallback string `yaml:"fallback"`
+	// ключ для подписи токенов
+	Secret string        `yaml:"secret"`
+	TTL    time.Duration `yaml:"ttl"`
+}
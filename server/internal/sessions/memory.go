@@ -0,0 +1,71 @@
+package sessions
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval - как часто удаляются просроченные сессии
+const sweepInterval = time.Minute
+
+// memory хранит сессии в памяти процесса. Подходит
+// только для одной реплики сервера.
+type memory struct {
+	sessions sync.Map
+
+	mu    sync.Mutex
+	swept time.Time
+}
+
+func newMemory() *memory {
+	return &memory{swept: time.Now()}
+}
+
+func (m *memory) save(_ context.Context, key string, session Session) error {
+	m.sessions.Store(key, session)
+	m.sweep()
+
+	return nil
+}
+
+func (m *memory) load(_ context.Context, key string) (Session, bool, error) {
+	raw, ok := m.sessions.Load(key)
+	if !ok {
+		return Session{}, false, nil
+	}
+
+	session, ok := raw.(Session)
+	if !ok {
+		return Session{}, false, nil
+	}
+
+	if session.isExpired() {
+		m.sessions.CompareAndDelete(key, raw)
+
+		return Session{}, false, nil
+	}
+
+	return session, true, nil
+}
+
+// sweep удаляет просроченные сессии не чаще sweepInterval
+func (m *memory) sweep() {
+	m.mu.Lock()
+	if time.Since(m.swept) < sweepInterval {
+		m.mu.Unlock()
+
+		return
+	}
+
+	m.swept = time.Now()
+	m.mu.Unlock()
+
+	m.sessions.Range(func(key, raw any) bool {
+		if session, ok := raw.(Session); ok && session.isExpired() {
+			m.sessions.CompareAndDelete(key, raw)
+		}
+
+		return true
+	})
+}
@@ -0,0 +1,86 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"go.ads.coffee/platform/pkg/redispool"
+)
+
+const sessionKeyTemplate = "sessions:%s" // отпечаток клиента
+
+// record - сессия в redis и в токене
+type record struct {
+	Value   string `json:"v"`
+	ClickID string `json:"c,omitempty"`
+	Expiry  int64  `json:"e"`
+}
+
+func newRecord(session Session) record {
+	return record{
+		Value:   session.Value,
+		ClickID: session.ClickID,
+		Expiry:  session.expiry.Unix(),
+	}
+}
+
+func (r record) session() Session {
+	return Session{
+		Value:   r.Value,
+		ClickID: r.ClickID,
+		expiry:  time.Unix(r.Expiry, 0),
+	}
+}
+
+// rds хранит сессии в redis, срок жизни задается ttl ключа
+type rds struct {
+	redis *redispool.Redis
+}
+
+func newRedis(redis *redispool.Redis) *rds {
+	return &rds{redis: redis}
+}
+
+func (s *rds) save(ctx context.Context, key string, session Session) error {
+	data, err := json.Marshal(newRecord(session))
+	if err != nil {
+		return err
+	}
+
+	return s.redis.Call(ctx, "sessions_save", func(ctx context.Context, clu *redis.ClusterClient, kf redispool.KeyFormatter) error {
+		return clu.Set(ctx, kf.FormatKey(fmt.Sprintf(sessionKeyTemplate, key)), data, time.Until(session.expiry)).Err()
+	})
+}
+
+func (s *rds) load(ctx context.Context, key string) (Session, bool, error) {
+	session := Session{}
+	found := false
+
+	err := s.redis.Call(ctx, "sessions_load", func(ctx context.Context, clu *redis.ClusterClient, kf redispool.KeyFormatter) error {
+		data, err := clu.Get(ctx, kf.FormatKey(fmt.Sprintf(sessionKeyTemplate, key))).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		r := record{}
+		if err := json.Unmarshal(data, &r); err != nil {
+			return fmt.Errorf("invalid session: %w", err)
+		}
+
+		session = r.session()
+		found = true
+
+		return nil
+	})
+
+	return session, found, err
+}
@@ -1,17 +1,27 @@
 package sessions
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"net"
+	"errors"
+	"fmt"
 	"net/http"
-	"strings"
-	"sync"
 	"time"
+
+	"go.ads.coffee/platform/pkg/redispool"
+	"go.ads.coffee/platform/server/internal/tools/network"
 )
 
+// ParamToken - параметр ссылки клика с токеном сессии
+const ParamToken = "token"
+
+// Session связывает показ баннера с последующим кликом
 type Session struct {
+	// id показанного баннера
 	Value string
+	// click id показа, чтобы клик попал в ту же цепочку событий
+	ClickID string
 
 	expiry time.Time
 }
@@ -20,76 +30,109 @@ func (s Session) isExpired() bool {
 	return s.expiry.Before(time.Now())
 }
 
-type Sessions struct {
-	sessions sync.Map
+// store хранит сессии по отпечатку клиента
+type store interface {
+	save(ctx context.Context, key string, session Session) error
+	load(ctx context.Context, key string) (Session, bool, error)
 }
 
-func New() *Sessions {
-	return &Sessions{}
+type Sessions struct {
+	ttl    time.Duration
+	store  store
+	tokens *tokens
 }
 
-// TODO: в ключе нужно использовать слот
-func (s *Sessions) LoadWithExpire(r *http.Request) (Session, bool) {
-	session, ok := s.LoadWithoutExpire(r)
-
-	if session.isExpired() {
-		return Session{}, false
+func New(cfg Config, pool *redispool.Pool) (*Sessions, error) {
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
 	}
 
-	return session, ok
-}
+	s := &Sessions{ttl: ttl}
 
-func (s *Sessions) LoadWithoutExpire(r *http.Request) (Session, bool) {
-	token := s.identifier(r)
-	raw, ok := s.sessions.Load(token)
-	if !ok {
-		return Session{}, false
+	backend := cfg.Backend
+	if backend == "" {
+		backend = BackendMemory
 	}
 
-	session, ok := raw.(Session)
+	if backend == BackendToken {
+		if cfg.Secret == "" {
+			return nil, errors.New("sessions secret is required for token backend")
+		}
 
-	return session, ok
-}
+		// заглушку из примера конфига знают все, токены можно подделать
+		if cfg.Secret == defaultSecret {
+			return nil, errors.New("sessions secret must not be the default one")
+		}
 
-func (s *Sessions) Start(r *http.Request, value string) error {
-	token := s.identifier(r)
-	expires := time.Now().Add(10 * time.Minute)
+		s.tokens = newTokens(cfg.Secret)
+		backend = cfg.Fallback
+	}
 
-	s.sessions.Store(token, Session{
-		Value:  value,
-		expiry: expires,
-	})
+	switch backend {
+	case "":
+	case BackendMemory:
+		s.store = newMemory()
+	case BackendRedis:
+		rds, err := pool.GetPool("main")
+		if err != nil {
+			return nil, fmt.Errorf("redis pool error: %w", err)
+		}
 
-	return nil
+		s.store = newRedis(rds)
+	default:
+		return nil, fmt.Errorf("unknown sessions backend %q", backend)
+	}
+
+	return s, nil
 }
 
-func (s *Sessions) identifier(r *http.Request) string {
-	agent := r.UserAgent()
-	ip := forwarded(address(r), r)
-	data := agent + ip
-	hash := sha256.Sum256([]byte(data))
+// Start сохраняет сессию. В режиме token возвращает токен,
+// который клиент должен передать в ссылке клика.
+func (s *Sessions) Start(r *http.Request, session Session) (string, error) {
+	session.expiry = time.Now().Add(s.ttl)
 
-	return hex.EncodeToString(hash[:])
-}
+	token := ""
+	if s.tokens != nil {
+		token = s.tokens.sign(session)
+	}
 
-func address(r *http.Request) string {
-	addr := r.RemoteAddr
-	ip, _, err := net.SplitHostPort(addr)
-	if err != nil {
-		return addr
+	if s.store != nil {
+		if err := s.store.save(r.Context(), s.Identifier(r), session); err != nil {
+			return token, err
+		}
 	}
 
-	return ip
+	return token, nil
 }
 
-func forwarded(ip string, r *http.Request) string {
-	forwardedFor := r.Header.Get("X-Forwarded-For")
-	if forwardedFor != "" {
-		ips := strings.Split(forwardedFor, ",")
-		if len(ips) > 0 {
-			ip = strings.TrimSpace(ips[0])
+// LoadWithExpire ищет сессию сначала по токену из ссылки,
+// затем по отпечатку клиента
+func (s *Sessions) LoadWithExpire(r *http.Request) (Session, bool) {
+	if s.tokens != nil {
+		if token := r.URL.Query().Get(ParamToken); token != "" {
+			return s.tokens.verify(token)
 		}
 	}
 
-	return ip
+	if s.store == nil {
+		return Session{}, false
+	}
+
+	session, ok, err := s.store.load(r.Context(), s.Identifier(r))
+	if err != nil || !ok || session.isExpired() {
+		return Session{}, false
+	}
+
+	return session, true
+}
+
+// Identifier - отпечаток клиента по user agent и ip
+func (s *Sessions) Identifier(r *http.Request) string {
+	agent := r.UserAgent()
+	ip := network.ClientIP(r)
+	data := agent + ip
+	hash := sha256.Sum256([]byte(data))
+
+	return hex.EncodeToString(hash[:])
 }
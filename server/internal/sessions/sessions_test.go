@@ -6,17 +6,26 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func newMemorySessions(t *testing.T) *Sessions {
+	sessions, err := New(Config{}, nil)
+	require.NoError(t, err)
+
+	return sessions
+}
+
 func TestSessions_LoadWithExpire_ValidSession(t *testing.T) {
 	// Arrange
-	sessions := New()
+	sessions := newMemorySessions(t)
 	req := httptest.NewRequest("GET", "/", nil)
 	sessionValue := "test-session-value"
 
 	// Start a session
-	err := sessions.Start(req, sessionValue)
+	token, err := sessions.Start(req, Session{Value: sessionValue})
 	assert.NoError(t, err, "Starting session should not produce an error")
+	assert.Empty(t, token, "Memory backend should not issue tokens")
 
 	// Act
 	session, ok := sessions.LoadWithExpire(req)
@@ -29,15 +38,16 @@ func TestSessions_LoadWithExpire_ValidSession(t *testing.T) {
 
 func TestSessions_LoadWithExpire_ExpiredSession(t *testing.T) {
 	// Arrange
-	sessions := New()
+	sessions := newMemorySessions(t)
 	req := httptest.NewRequest("GET", "/", nil)
 	sessionValue := "test-session-value"
 
 	// Start a session with a short expiry time
-	token := sessions.identifier(req)
+	token := sessions.Identifier(req)
 	expires := time.Now().Add(-1 * time.Second) // Expired 1 second ago
 
-	sessions.sessions.Store(token, Session{
+	mem := sessions.store.(*memory)
+	mem.sessions.Store(token, Session{
 		Value:  sessionValue,
 		expiry: expires,
 	})
@@ -48,11 +58,15 @@ func TestSessions_LoadWithExpire_ExpiredSession(t *testing.T) {
 	// Assert
 	assert.False(t, ok, "Session should not be found as it's expired")
 	assert.Equal(t, Session{}, session, "Should return empty session")
+
+	// Expired session is evicted on read
+	_, found := mem.sessions.Load(token)
+	assert.False(t, found, "Expired session should be evicted")
 }
 
 func TestSessions_LoadWithExpire_NonExistentSession(t *testing.T) {
 	// Arrange
-	sessions := New()
+	sessions := newMemorySessions(t)
 	req := httptest.NewRequest("GET", "/", nil)
 
 	// Act
@@ -62,3 +76,90 @@ func TestSessions_LoadWithExpire_NonExistentSession(t *testing.T) {
 	assert.False(t, ok, "Session should not be found")
 	assert.Equal(t, Session{}, session, "Should return empty session")
 }
+
+func TestNew_Errors(t *testing.T) {
+	_, err := New(Config{Backend: "unknown"}, nil)
+	assert.Error(t, err)
+
+	_, err = New(Config{Backend: BackendToken}, nil)
+	assert.Error(t, err, "token backend requires secret")
+
+	_, err = New(Config{Backend: BackendToken, Secret: "changeme"}, nil)
+	assert.Error(t, err, "default secret is public")
+}
+
+func TestSessions_Token(t *testing.T) {
+	sessions, err := New(Config{Backend: BackendToken, Secret: "secret"}, nil)
+	require.NoError(t, err)
+
+	img := httptest.NewRequest("GET", "/banner/1/img", nil)
+
+	token, err := sessions.Start(img, Session{Value: "banner-1", ClickID: "click-1"})
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	// клик с другого адреса - сессия берется из токена
+	click := httptest.NewRequest("GET", "/banner/1/click?token="+token, nil)
+	click.RemoteAddr = "10.0.0.2:1234"
+
+	session, ok := sessions.LoadWithExpire(click)
+	assert.True(t, ok)
+	assert.Equal(t, "banner-1", session.Value)
+	assert.Equal(t, "click-1", session.ClickID)
+
+	// без fallback отпечаток не используется
+	session, ok = sessions.LoadWithExpire(httptest.NewRequest("GET", "/banner/1/click", nil))
+	assert.False(t, ok)
+	assert.Equal(t, Session{}, session)
+}
+
+func TestSessions_Token_Invalid(t *testing.T) {
+	sessions, err := New(Config{Backend: BackendToken, Secret: "secret"}, nil)
+	require.NoError(t, err)
+
+	other, err := New(Config{Backend: BackendToken, Secret: "other"}, nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	token, err := other.Start(req, Session{Value: "banner-1"})
+	require.NoError(t, err)
+
+	for _, tt := range []string{token, "garbage", "payload.signature"} {
+		_, ok := sessions.LoadWithExpire(httptest.NewRequest("GET", "/?token="+tt, nil))
+		assert.False(t, ok, tt)
+	}
+
+	expired := sessions.tokens.sign(Session{Value: "banner-1", expiry: time.Now().Add(-time.Second)})
+	_, ok := sessions.LoadWithExpire(httptest.NewRequest("GET", "/?token="+expired, nil))
+	assert.False(t, ok, "expired token")
+}
+
+func TestSessions_Token_Fallback(t *testing.T) {
+	sessions, err := New(Config{Backend: BackendToken, Fallback: BackendMemory, Secret: "secret"}, nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/", nil)
+
+	token, err := sessions.Start(req, Session{Value: "banner-1"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	// клиент не передал токен - сессия находится по отпечатку
+	session, ok := sessions.LoadWithExpire(req)
+	assert.True(t, ok)
+	assert.Equal(t, "banner-1", session.Value)
+}
+
+func TestMemory_Sweep(t *testing.T) {
+	mem := newMemory()
+	mem.sessions.Store("expired", Session{Value: "old", expiry: time.Now().Add(-time.Second)})
+	mem.swept = time.Now().Add(-2 * sweepInterval)
+
+	require.NoError(t, mem.save(t.Context(), "fresh", Session{Value: "new", expiry: time.Now().Add(time.Minute)}))
+
+	_, found := mem.sessions.Load("expired")
+	assert.False(t, found)
+
+	_, found = mem.sessions.Load("fresh")
+	assert.True(t, found)
+}
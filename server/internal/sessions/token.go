@@ -0,0 +1,51 @@
+package sessions
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"go.ads.coffee/platform/server/internal/tools/security"
+)
+
+// tokens кладет сессию в подписанный токен: base64(json).подпись.
+// Токен не хранится на сервере, поэтому работает с любой репликой.
+type tokens struct {
+	secret string
+}
+
+func newTokens(secret string) *tokens {
+	return &tokens{secret: secret}
+}
+
+func (t *tokens) sign(session Session) string {
+	// record состоит из строк и числа, ошибки не будет
+	data, _ := json.Marshal(newRecord(session))
+	payload := base64.RawURLEncoding.EncodeToString(data)
+
+	return payload + "." + security.HS256(payload, t.secret)
+}
+
+func (t *tokens) verify(token string) (Session, bool) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !security.Equal(signature, security.HS256(payload, t.secret)) {
+		return Session{}, false
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Session{}, false
+	}
+
+	r := record{}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Session{}, false
+	}
+
+	session := r.session()
+	if session.isExpired() {
+		return Session{}, false
+	}
+
+	return session, true
+}
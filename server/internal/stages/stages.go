@@ -20,3 +20,20 @@ func New(list []plugins.Stage) *Stages {
 func (i *Stages) Get(name string, cfg map[string]any) plugins.Stage {
 	return i.list[name].Copy(cfg)
 }
+
+// Has проверяет, что плагин зарегистрирован
+func (i *Stages) Has(name string) bool {
+	_, ok := i.list[name]
+
+	return ok
+}
+
+// Schema возвращает схему конфига, если плагин ее описывает
+func (i *Stages) Schema(name string) (plugins.Schema, bool) {
+	p, ok := i.list[name].(plugins.WithSchema)
+	if !ok {
+		return nil, false
+	}
+
+	return p.Schema(), true
+}
@@ -72,3 +72,12 @@ func TestStages_GetNonExistentStage(t *testing.T) {
 		stages.Get("non-existent", cfg)
 	})
 }
+
+func TestStages_Has(t *testing.T) {
+	list := New([]plugins.Stage{
+		&mockStage{name: "stages.banners"},
+	})
+
+	assert.True(t, list.Has("stages.banners"))
+	assert.False(t, list.Has("non-existent"))
+}
@@ -0,0 +1,121 @@
+package stats
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// query суммирует часовые агрегаты по баннерам. Имя базы
+// подставляется из конфига, время передается параметром
+const query = `SELECT banner_id, sum(impressions), sum(clicks), sum(conversions)
+FROM (
+	SELECT banner_id, count AS impressions, 0 AS clicks, 0 AS conversions
+	FROM %[1]s.impressions_hour WHERE timestamp >= {since:DateTime}
+	UNION ALL
+	SELECT banner_id, 0, count, 0
+	FROM %[1]s.clicks_hour WHERE timestamp >= {since:DateTime}
+	UNION ALL
+	SELECT banner_id, 0, 0, count
+	FROM %[1]s.conversions_hour WHERE timestamp >= {since:DateTime}
+)
+WHERE banner_id != ''
+GROUP BY banner_id
+FORMAT TabSeparated`
+
+// ClickHouse читает счетчики через http интерфейс,
+// отдельный драйвер для одного запроса не нужен
+type ClickHouse struct {
+	client *http.Client
+	cfg    ClickHouseConfig
+}
+
+func NewClickHouse(cfg ClickHouseConfig) *ClickHouse {
+	if cfg.Database == "" {
+		cfg.Database = defaultDatabase
+	}
+
+	return &ClickHouse{
+		client: &http.Client{Timeout: 30 * time.Second},
+		cfg:    cfg,
+	}
+}
+
+// Counters возвращает счетчики баннеров с момента since
+func (c *ClickHouse) Counters(ctx context.Context, since time.Time) (map[string]Counters, error) {
+	params := url.Values{}
+	params.Set("database", c.cfg.Database)
+	params.Set("param_since", since.UTC().Format(time.DateTime))
+
+	body := strings.NewReader(fmt.Sprintf(query, c.cfg.Database))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.URL+"/?"+params.Encode(), body)
+	if err != nil {
+		return nil, fmt.Errorf("error on create clickhouse request: %w", err)
+	}
+
+	if c.cfg.User != "" {
+		req.SetBasicAuth(c.cfg.User, c.cfg.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error on clickhouse request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return nil, fmt.Errorf("clickhouse status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return parse(resp.Body)
+}
+
+// parse читает строки banner_id, показы, клики, конверсии
+func parse(r io.Reader) (map[string]Counters, error) {
+	counters := map[string]Counters{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("invalid clickhouse row: %q", line)
+		}
+
+		values := [3]float64{}
+
+		for i, f := range fields[1:] {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid clickhouse row %q: %w", line, err)
+			}
+
+			values[i] = v
+		}
+
+		counters[fields[0]] = Counters{
+			Impressions: values[0],
+			Clicks:      values[1],
+			Conversions: values[2],
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error on read clickhouse response: %w", err)
+	}
+
+	return counters, nil
+}
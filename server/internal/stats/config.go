@@ -0,0 +1,26 @@
+package stats
+
+import "time"
+
+const (
+	defaultInterval = 5 * time.Minute
+	defaultWindow   = 72 * time.Hour
+	defaultDatabase = "analytics"
+)
+
+type Config struct {
+	// как часто перечитывать счетчики из redis и clickhouse
+	Interval time.Duration `yaml:"interval"`
+	// за какой период считать показы, клики и конверсии
+	Window     time.Duration    `yaml:"window"`
+	ClickHouse ClickHouseConfig `yaml:"clickhouse"`
+}
+
+// ClickHouseConfig - источник счетчиков. Без url счетчики
+// только читаются из redis, их обновляет другая реплика
+type ClickHouseConfig struct {
+	URL      string `yaml:"url"`
+	Database string `yaml:"database"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+}
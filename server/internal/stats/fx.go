@@ -0,0 +1,13 @@
+package stats
+
+import (
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module(
+	"stats",
+
+	fx.Provide(
+		New,
+	),
+)
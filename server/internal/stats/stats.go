@@ -0,0 +1,235 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/pkg/redispool"
+)
+
+// ключи в одном слоте кластера, чтобы новый набор
+// счетчиков подменял старый через RENAME
+const (
+	countersKey = "stats:{banners}"
+	nextKey     = "stats:{banners}:next"
+	lockKey     = "stats:{banners}:lock"
+)
+
+// Counters - показы, клики и конверсии баннера за окно
+type Counters struct {
+	Impressions float64
+	Clicks      float64
+	Conversions float64
+}
+
+type Source interface {
+	Counters(ctx context.Context, since time.Time) (map[string]Counters, error)
+}
+
+// Stats держит в памяти счетчики баннеров для ротации. Счетчики
+// хранятся в redis, одна из реплик по очереди обновляет их из
+// clickhouse, остальные только перечитывают.
+type Stats struct {
+	logger   *zap.Logger
+	redis    *redispool.Redis
+	source   Source
+	interval time.Duration
+	window   time.Duration
+	now      func() time.Time
+
+	counters atomic.Pointer[map[string]Counters]
+}
+
+func New(logger *zap.Logger, cfg Config, pool *redispool.Pool) (*Stats, error) {
+	rds, err := pool.GetPool("main")
+	if err != nil {
+		return nil, fmt.Errorf("redis pool error: %w", err)
+	}
+
+	var source Source
+	if cfg.ClickHouse.URL != "" {
+		source = NewClickHouse(cfg.ClickHouse)
+	}
+
+	return newStats(logger, rds, source, cfg), nil
+}
+
+func newStats(logger *zap.Logger, rds *redispool.Redis, source Source, cfg Config) *Stats {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	window := cfg.Window
+	if window <= 0 {
+		window = defaultWindow
+	}
+
+	s := &Stats{
+		logger:   logger,
+		redis:    rds,
+		source:   source,
+		interval: interval,
+		window:   window,
+		now:      time.Now,
+	}
+
+	s.counters.Store(&map[string]Counters{})
+
+	return s
+}
+
+// Get возвращает счетчики баннера. Нет счетчиков - баннер
+// новый, ротация использует для него априорные оценки
+func (s *Stats) Get(id string) (Counters, bool) {
+	if s == nil {
+		return Counters{}, false
+	}
+
+	c, ok := (*s.counters.Load())[id]
+
+	return c, ok
+}
+
+// Start обновляет счетчики до отмены контекста
+func (s *Stats) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.update(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Stats) update(ctx context.Context) {
+	if s.source != nil {
+		if err := s.refresh(ctx); err != nil {
+			s.logger.Error("error on refresh banner stats", zap.Error(err))
+		}
+	}
+
+	if err := s.load(ctx); err != nil {
+		s.logger.Error("error on load banner stats", zap.Error(err))
+	}
+}
+
+// refresh переносит счетчики из clickhouse в redis. Блокировка
+// на интервал не дает репликам делать один и тот же запрос
+func (s *Stats) refresh(ctx context.Context) error {
+	return s.redis.Call(ctx, "stats_refresh", func(ctx context.Context, clu *redis.ClusterClient, kf redispool.KeyFormatter) error {
+		locked, err := clu.SetNX(ctx, kf.FormatKey(lockKey), 1, s.interval).Result()
+		if err != nil {
+			return fmt.Errorf("error on lock: %w", err)
+		}
+
+		if !locked {
+			return nil
+		}
+
+		counters, err := s.source.Counters(ctx, s.now().Add(-s.window))
+		if err != nil {
+			return err
+		}
+
+		key := kf.FormatKey(countersKey)
+		next := kf.FormatKey(nextKey)
+
+		_, err = clu.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			if len(counters) == 0 {
+				p.Del(ctx, key)
+
+				return nil
+			}
+
+			values := make(map[string]any, len(counters))
+			for id, c := range counters {
+				values[id] = encode(c)
+			}
+
+			p.Del(ctx, next)
+			p.HSet(ctx, next, values)
+			p.Rename(ctx, next, key)
+			// если обновления прекратятся, ротация вернется
+			// к априорным оценкам, а не к устаревшим счетчикам
+			p.Expire(ctx, key, 3*s.interval)
+
+			return nil
+		})
+
+		return err
+	})
+}
+
+// load перечитывает счетчики из redis в память
+func (s *Stats) load(ctx context.Context) error {
+	return s.redis.Call(ctx, "stats_load", func(ctx context.Context, clu *redis.ClusterClient, kf redispool.KeyFormatter) error {
+		values, err := clu.HGetAll(ctx, kf.FormatKey(countersKey)).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+
+		counters := make(map[string]Counters, len(values))
+
+		for id, v := range values {
+			c, err := decode(v)
+			if err != nil {
+				s.logger.Warn("invalid banner stats", zap.String("banner", id), zap.Error(err))
+
+				continue
+			}
+
+			counters[id] = c
+		}
+
+		s.counters.Store(&counters)
+
+		return nil
+	})
+}
+
+// encode хранит счетчики одной строкой "показы:клики:конверсии"
+func encode(c Counters) string {
+	return strings.Join([]string{
+		strconv.FormatFloat(c.Impressions, 'f', -1, 64),
+		strconv.FormatFloat(c.Clicks, 'f', -1, 64),
+		strconv.FormatFloat(c.Conversions, 'f', -1, 64),
+	}, ":")
+}
+
+func decode(v string) (Counters, error) {
+	parts := strings.Split(v, ":")
+	if len(parts) != 3 {
+		return Counters{}, fmt.Errorf("invalid counters %q", v)
+	}
+
+	values := [3]float64{}
+
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return Counters{}, fmt.Errorf("invalid counters %q: %w", v, err)
+		}
+
+		values[i] = f
+	}
+
+	return Counters{
+		Impressions: values[0],
+		Clicks:      values[1],
+		Conversions: values[2],
+	}, nil
+}
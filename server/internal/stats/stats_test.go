@@ -0,0 +1,100 @@
+package stats
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	c := Counters{Impressions: 1000, Clicks: 12, Conversions: 0.5}
+
+	assert.Equal(t, "1000:12:0.5", encode(c))
+
+	decoded, err := decode(encode(c))
+	require.NoError(t, err)
+	assert.Equal(t, c, decoded)
+
+	_, err = decode("1000:12")
+	assert.Error(t, err)
+
+	_, err = decode("1000:x:0")
+	assert.Error(t, err)
+}
+
+func TestParse(t *testing.T) {
+	counters, err := parse(strings.NewReader("1\t1000\t10\t1\n2\t50\t0\t0\n\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]Counters{
+		"1": {Impressions: 1000, Clicks: 10, Conversions: 1},
+		"2": {Impressions: 50},
+	}, counters)
+
+	_, err = parse(strings.NewReader("1\t1000\n"))
+	assert.Error(t, err)
+}
+
+func TestClickHouse_Counters(t *testing.T) {
+	since := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "stats", r.URL.Query().Get("database"))
+		assert.Equal(t, "2026-10-15 12:00:00", r.URL.Query().Get("param_since"))
+
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "default", user)
+		assert.Equal(t, "secret", password)
+
+		body, _ := io.ReadAll(r.Body)
+		assert.Contains(t, string(body), "FROM stats.clicks_hour")
+
+		_, _ = w.Write([]byte("1\t1000\t10\t1\n"))
+	}))
+	defer srv.Close()
+
+	ch := NewClickHouse(ClickHouseConfig{URL: srv.URL, Database: "stats", User: "default", Password: "secret"})
+
+	counters, err := ch.Counters(context.Background(), since)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Counters{"1": {Impressions: 1000, Clicks: 10, Conversions: 1}}, counters)
+}
+
+func TestClickHouse_Counters_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Code: 60. Table does not exist", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	_, err := NewClickHouse(ClickHouseConfig{URL: srv.URL}).Counters(context.Background(), time.Now())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Table does not exist")
+}
+
+func TestStats_Get(t *testing.T) {
+	var empty *Stats
+
+	_, ok := empty.Get("1")
+	assert.False(t, ok)
+
+	s := newStats(nil, nil, nil, Config{})
+	assert.Equal(t, defaultInterval, s.interval)
+	assert.Equal(t, defaultWindow, s.window)
+
+	s.counters.Store(&map[string]Counters{"1": {Impressions: 10}})
+
+	c, ok := s.Get("1")
+	assert.True(t, ok)
+	assert.Equal(t, 10.0, c.Impressions)
+
+	_, ok = s.Get("2")
+	assert.False(t, ok)
+}
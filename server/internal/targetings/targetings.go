@@ -20,3 +20,20 @@ func New(list []plugins.Targeting) *Targetings {
 func (i *Targetings) Get(name string, cfg map[string]any) plugins.Targeting {
 	return i.list[name].Copy(cfg)
 }
+
+// Has проверяет, что плагин зарегистрирован
+func (i *Targetings) Has(name string) bool {
+	_, ok := i.list[name]
+
+	return ok
+}
+
+// Schema возвращает схему конфига, если плагин ее описывает
+func (i *Targetings) Schema(name string) (plugins.Schema, bool) {
+	p, ok := i.list[name].(plugins.WithSchema)
+	if !ok {
+		return nil, false
+	}
+
+	return p.Schema(), true
+}
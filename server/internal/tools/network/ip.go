@@ -0,0 +1,33 @@
+package network
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP возвращает адрес клиента с учетом
+// X-Forwarded-For от балансировщика
+func ClientIP(r *http.Request) string {
+	ip := address(r)
+
+	forwardedFor := r.Header.Get("X-Forwarded-For")
+	if forwardedFor != "" {
+		ips := strings.Split(forwardedFor, ",")
+		if len(ips) > 0 {
+			ip = strings.TrimSpace(ips[0])
+		}
+	}
+
+	return ip
+}
+
+func address(r *http.Request) string {
+	addr := r.RemoteAddr
+	ip, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return ip
+}
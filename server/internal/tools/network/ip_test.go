@@ -0,0 +1,26 @@
+package network
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+
+	assert.Equal(t, "10.0.0.1", ClientIP(req))
+
+	req.Header.Set("X-Forwarded-For", "1.2.3.4, 10.0.0.2")
+
+	assert.Equal(t, "1.2.3.4", ClientIP(req))
+}
+
+func TestClientIP_WithoutPort(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1"
+
+	assert.Equal(t, "10.0.0.1", ClientIP(req))
+}
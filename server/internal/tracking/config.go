@@ -0,0 +1,16 @@
+package tracking
+
+import "time"
+
+const defaultTTL = 24 * time.Hour
+
+type Config struct {
+	// ключ для подписи ссылок на трекер
+	Secret string `yaml:"secret"`
+	// сколько живет подписанная ссылка,
+	// столько же хранятся ключи дедупликации
+	TTL time.Duration `yaml:"ttl"`
+	// трекеры рекламодателя вызывает сервер, а не клиент.
+	// Тогда форматы не отдают их в ответе, иначе будет двойной счет
+	Fanout bool `yaml:"fanout"`
+}
@@ -0,0 +1,53 @@
+package tracking
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"go.ads.coffee/platform/pkg/redispool"
+)
+
+const dedupKeyTemplate = "tracking:%s:%s:%s" // action, request id, banner id
+
+// Dedup отбрасывает повторные срабатывания трекера
+// для одного ответа и баннера
+type Dedup struct {
+	redis *redispool.Redis
+	ttl   time.Duration
+}
+
+func NewDedup(pool *redispool.Pool, signer *Signer) (*Dedup, error) {
+	rds, err := pool.GetPool("main")
+	if err != nil {
+		return nil, fmt.Errorf("redis pool error: %w", err)
+	}
+
+	return &Dedup{
+		redis: rds,
+		ttl:   signer.TTL(),
+	}, nil
+}
+
+// First возвращает true, если событие пришло впервые.
+// Если redis выключен, все события считаются первыми.
+func (d *Dedup) First(ctx context.Context, action, requestID, bannerID string) (bool, error) {
+	first := true
+
+	err := d.redis.Call(ctx, "tracking_dedup", func(ctx context.Context, clu *redis.ClusterClient, kf redispool.KeyFormatter) error {
+		key := kf.FormatKey(fmt.Sprintf(dedupKeyTemplate, action, requestID, bannerID))
+
+		ok, err := clu.SetNX(ctx, key, 1, d.ttl).Result()
+		if err != nil {
+			return err
+		}
+
+		first = ok
+
+		return nil
+	})
+
+	return first, err
+}
@@ -0,0 +1,163 @@
+package tracking
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/pkg/circuitbreaker"
+	"go.ads.coffee/platform/pkg/telemetry"
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/repos/banners"
+)
+
+const (
+	// имя circuit для запросов к сторонним трекерам
+	FanoutCircuit = "trackers"
+
+	fanoutTimeout = 2 * time.Second
+
+	// вызовы идут через очередь с постоянным числом воркеров,
+	// чтобы медленный трекер не копил горутины
+	fanoutWorkers = 16
+	fanoutQueue   = 1024
+)
+
+type BannersCache interface {
+	One(ctx context.Context, id string) (ads.Banner, bool)
+}
+
+// Fanout вызывает трекеры рекламодателя с сервера. Нужен для
+// клиентов, которые не умеют сами дергать сторонние трекеры.
+type Fanout struct {
+	logger  *zap.Logger
+	banners BannersCache
+	circuit circuitbreaker.Circuit
+	client  *http.Client
+
+	queue chan string
+	// вызовы, которые не влезли в очередь
+	dropped atomic.Uint64
+}
+
+func NewFanout(
+	logger *zap.Logger,
+	pool *circuitbreaker.Pool,
+	cache *banners.Cache,
+	tel *telemetry.Telemetry,
+) (*Fanout, error) {
+	f := newFanout(logger, cache, pool.Get(FanoutCircuit), &http.Client{
+		Timeout: fanoutTimeout,
+	}, fanoutWorkers, fanoutQueue)
+
+	if err := tel.Register(f.metrics()...); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// newFanout запускает воркеры, они живут столько же, сколько процесс
+func newFanout(
+	logger *zap.Logger,
+	cache BannersCache,
+	circuit circuitbreaker.Circuit,
+	client *http.Client,
+	workers, queue int,
+) *Fanout {
+	f := &Fanout{
+		logger:  logger,
+		banners: cache,
+		circuit: circuit,
+		client:  client,
+		queue:   make(chan string, queue),
+	}
+
+	for range workers {
+		go f.work()
+	}
+
+	return f
+}
+
+// Fire ставит в очередь вызов трекера рекламодателя для показа
+// или клика, ответ не ждем. Если очередь полна, вызов теряется
+func (f *Fanout) Fire(ctx context.Context, action string, info ads.TrackerInfo) {
+	banner, ok := f.banners.One(ctx, info.BannerID)
+	if !ok {
+		return
+	}
+
+	link := ""
+
+	switch action {
+	case ads.ActionImpression:
+		link = banner.Imptracker
+	case ads.ActionCLick:
+		link = banner.Clicktracker
+	}
+
+	if link == "" {
+		return
+	}
+
+	select {
+	case f.queue <- link:
+	default:
+		f.dropped.Add(1)
+		f.logger.Warn("fanout queue is full, drop third-party tracker", zap.String("url", link))
+	}
+}
+
+func (f *Fanout) work() {
+	for link := range f.queue {
+		if err := f.circuit.Run(context.Background(), func(ctx context.Context) error {
+			return f.call(ctx, link)
+		}); err != nil {
+			f.logger.Warn("error on call third-party tracker", zap.String("url", link), zap.Error(err))
+		}
+	}
+}
+
+func (f *Fanout) call(ctx context.Context, link string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (f *Fanout) metrics() []prometheus.Collector {
+	return []prometheus.Collector{
+		prometheus.NewCounterFunc(
+			prometheus.CounterOpts{
+				Namespace: "tracking",
+				Subsystem: "fanout",
+				Name:      "dropped_total",
+				Help:      "Third-party tracker calls dropped because the fanout queue is full.",
+			},
+			func() float64 {
+				return float64(f.dropped.Load())
+			},
+		),
+	}
+}
@@ -0,0 +1,82 @@
+package tracking
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/pkg/circuitbreaker"
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+type cache map[string]ads.Banner
+
+func (c cache) One(ctx context.Context, id string) (ads.Banner, bool) {
+	b, ok := c[id]
+
+	return b, ok
+}
+
+func TestFanout_Fire(t *testing.T) {
+	calls := make(chan string, 2)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls <- r.URL.Path
+	}))
+	defer srv.Close()
+
+	var pool *circuitbreaker.Pool
+
+	fanout := newFanout(zap.NewNop(), cache{
+		"banner-1": {ID: "banner-1", Imptracker: srv.URL + "/imp", Clicktracker: srv.URL + "/click"},
+		"banner-2": {ID: "banner-2"},
+	}, pool.Get(FanoutCircuit), srv.Client(), 2, 10)
+
+	fanout.Fire(context.Background(), ads.ActionImpression, ads.TrackerInfo{BannerID: "banner-1"})
+	fanout.Fire(context.Background(), ads.ActionCLick, ads.TrackerInfo{BannerID: "banner-1"})
+
+	// без трекеров и неизвестный баннер - ничего не вызываем
+	fanout.Fire(context.Background(), ads.ActionImpression, ads.TrackerInfo{BannerID: "banner-2"})
+	fanout.Fire(context.Background(), ads.ActionImpression, ads.TrackerInfo{BannerID: "unknown"})
+
+	got := map[string]bool{}
+
+	for range 2 {
+		select {
+		case path := <-calls:
+			got[path] = true
+		case <-time.After(time.Second):
+			require.FailNow(t, "tracker was not called")
+		}
+	}
+
+	assert.Equal(t, map[string]bool{"/imp": true, "/click": true}, got)
+
+	select {
+	case path := <-calls:
+		assert.Failf(t, "unexpected call", "path %s", path)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestFanout_Fire_QueueFull(t *testing.T) {
+	var pool *circuitbreaker.Pool
+
+	// без воркеров очередь никто не разбирает
+	fanout := newFanout(zap.NewNop(), cache{
+		"banner-1": {ID: "banner-1", Imptracker: "http://tracker/imp"},
+	}, pool.Get(FanoutCircuit), http.DefaultClient, 0, 2)
+
+	for range 5 {
+		fanout.Fire(context.Background(), ads.ActionImpression, ads.TrackerInfo{BannerID: "banner-1"})
+	}
+
+	assert.Len(t, fanout.queue, 2)
+	assert.Equal(t, uint64(3), fanout.dropped.Load())
+}
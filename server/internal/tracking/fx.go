@@ -0,0 +1,15 @@
+package tracking
+
+import (
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module(
+	"tracking",
+
+	fx.Provide(
+		NewSigner,
+		NewDedup,
+		NewFanout,
+	),
+)
@@ -0,0 +1,122 @@
+package tracking
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.ads.coffee/platform/server/internal/tools/security"
+)
+
+// Параметры подписи
+const (
+	ParamTime      = "ts"
+	ParamSignature = "sig"
+)
+
+// NoticeParams подставляет биржа через макросы в nurl, burl
+// и lurl, поэтому в подпись они не входят
+var NoticeParams = []string{ParamPrice, ParamReason}
+
+// defaultSecret - заглушка из примеров конфига, с ней
+// подписи может подделать кто угодно
+const defaultSecret = "changeme"
+
+var (
+	ErrNoSecret         = errors.New("tracking secret is not configured")
+	ErrDefaultSecret    = errors.New("tracking secret must not be the default one")
+	ErrInvalidSignature = errors.New("invalid tracking signature")
+	ErrExpired          = errors.New("tracking link is expired")
+)
+
+// Signer подписывает ссылки на трекер, чтобы их нельзя было
+// подделать. В ссылку добавляется время выдачи, по нему
+// отбрасываются старые ссылки.
+type Signer struct {
+	secret string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewSigner(cfg Config) (*Signer, error) {
+	switch cfg.Secret {
+	case "":
+		return nil, ErrNoSecret
+	case defaultSecret:
+		return nil, ErrDefaultSecret
+	}
+
+	ttl := cfg.TTL
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	return &Signer{
+		secret: cfg.Secret,
+		ttl:    ttl,
+		now:    time.Now,
+	}, nil
+}
+
+func (s *Signer) TTL() time.Duration {
+	return s.ttl
+}
+
+// Sign добавляет к ссылке время и подпись. Подписываются
+// все параметры ссылки, отсортированные по имени.
+func (s *Signer) Sign(link string) (string, error) {
+	if s.secret == "" {
+		return "", ErrNoSecret
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+
+	values := u.Query()
+	values.Del(ParamSignature)
+	values.Set(ParamTime, strconv.FormatInt(s.now().Unix(), 10))
+	values.Set(ParamSignature, security.HS256(values.Encode(), s.secret))
+
+	u.RawQuery = values.Encode()
+
+	return u.String(), nil
+}
+
+// Verify проверяет подпись и срок жизни ссылки. Параметры
+// из unsigned в проверку не входят
+func (s *Signer) Verify(values url.Values, unsigned ...string) error {
+	if s.secret == "" {
+		return ErrNoSecret
+	}
+
+	signature := values.Get(ParamSignature)
+	if signature == "" {
+		return ErrInvalidSignature
+	}
+
+	signed := url.Values{}
+	for k, v := range values {
+		if k != ParamSignature && !slices.Contains(unsigned, k) {
+			signed[k] = v
+		}
+	}
+
+	if !security.Equal(signature, security.HS256(signed.Encode(), s.secret)) {
+		return ErrInvalidSignature
+	}
+
+	ts, err := strconv.ParseInt(values.Get(ParamTime), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if s.now().Sub(time.Unix(ts, 0)) > s.ttl {
+		return ErrExpired
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package tracking
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+func TestSigner_SignVerify(t *testing.T) {
+	signer, err := NewSigner(Config{Secret: "secret"})
+	require.NoError(t, err)
+
+	link, err := signer.Sign(URL("http://ads.coffee/tracker", ads.ActionImpression, ads.TrackerInfo{
+		RequestID: "request-1",
+		BannerID:  "banner-1",
+		Price:     10,
+	}))
+	require.NoError(t, err)
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ads.coffee", u.Host)
+	assert.NotEmpty(t, u.Query().Get(ParamSignature))
+	assert.NotEmpty(t, u.Query().Get(ParamTime))
+	assert.NoError(t, signer.Verify(u.Query()))
+
+	// подмена параметра ломает подпись
+	values := u.Query()
+	values.Set(ParamPrice, "100")
+	assert.ErrorIs(t, signer.Verify(values), ErrInvalidSignature)
+
+	values = u.Query()
+	values.Del(ParamSignature)
+	assert.ErrorIs(t, signer.Verify(values), ErrInvalidSignature)
+}
+
+func TestSigner_Notice(t *testing.T) {
+	signer, err := NewSigner(Config{Secret: "secret"})
+	require.NoError(t, err)
+
+	link, err := signer.Sign(URL("http://ads.coffee/tracker", ads.ActionBilling, ads.TrackerInfo{
+		RequestID: "request-1",
+		BannerID:  "banner-1",
+	}))
+	require.NoError(t, err)
+
+	// цену биржа подставит в макрос после подписи
+	u, err := url.Parse(Macro(link, ParamPrice, "12.5"))
+	require.NoError(t, err)
+
+	assert.NoError(t, signer.Verify(u.Query(), NoticeParams...))
+	assert.ErrorIs(t, signer.Verify(u.Query()), ErrInvalidSignature)
+
+	// остальные параметры подписаны
+	values := u.Query()
+	values.Set(ParamBanner, "banner-2")
+	assert.ErrorIs(t, signer.Verify(values, NoticeParams...), ErrInvalidSignature)
+}
+
+func TestSigner_Expired(t *testing.T) {
+	signer, err := NewSigner(Config{Secret: "secret", TTL: time.Hour})
+	require.NoError(t, err)
+
+	issued := time.Date(2025, 12, 6, 10, 0, 0, 0, time.UTC)
+	signer.now = func() time.Time { return issued }
+
+	link, err := signer.Sign("http://ads.coffee/tracker?action=click")
+	require.NoError(t, err)
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+
+	signer.now = func() time.Time { return issued.Add(59 * time.Minute) }
+	assert.NoError(t, signer.Verify(u.Query()))
+
+	signer.now = func() time.Time { return issued.Add(61 * time.Minute) }
+	assert.ErrorIs(t, signer.Verify(u.Query()), ErrExpired)
+}
+
+func TestSigner_NoSecret(t *testing.T) {
+	_, err := NewSigner(Config{})
+	assert.ErrorIs(t, err, ErrNoSecret)
+
+	// заглушку из примера конфига знают все
+	_, err = NewSigner(Config{Secret: "changeme"})
+	assert.ErrorIs(t, err, ErrDefaultSecret)
+
+	signer := &Signer{}
+
+	_, err = signer.Sign("http://ads.coffee/tracker?action=click")
+	assert.ErrorIs(t, err, ErrNoSecret)
+
+	assert.ErrorIs(t, signer.Verify(url.Values{}), ErrNoSecret)
+}
+
+func TestSigner_TTL(t *testing.T) {
+	signer, err := NewSigner(Config{Secret: "secret"})
+	require.NoError(t, err)
+
+	assert.Equal(t, defaultTTL, signer.TTL())
+}
@@ -0,0 +1,137 @@
+package tracking
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+// Параметры ссылки на трекер
+const (
+	ParamAction     = "action"
+	ParamRequest    = "rid"
+	ParamUser       = "uid"
+	ParamPlacement  = "pid"
+	ParamClick      = "cid"
+	ParamBanner     = "bid"
+	ParamGroup      = "gid"
+	ParamCampaign   = "cmp"
+	ParamAdvertiser = "adv"
+	ParamBundle     = "bundle"
+	ParamNetwork    = "net"
+	ParamCountry    = "country"
+	ParamRegion     = "region"
+	ParamCity       = "city"
+	ParamSize       = "size"
+	ParamMake       = "make"
+	ParamGAID       = "gaid"
+	ParamOAID       = "oaid"
+	ParamPrice      = "price"
+	ParamReason     = "reason"
+)
+
+// MacroClickID подставляется в ссылку рекламодателя, чтобы
+// трекер вернул click id в постбеке конверсии
+const MacroClickID = "{click_id}"
+
+// URL собирает ссылку на трекер с данными показа.
+// Макросы можно добавить к ссылке через Macro - они
+// не экранируются, чтобы их могла подставить биржа.
+func URL(base string, action string, info ads.TrackerInfo) string {
+	values := url.Values{}
+	values.Set(ParamAction, action)
+
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	set(ParamRequest, info.RequestID)
+	set(ParamUser, info.UserID)
+	set(ParamPlacement, info.PlacementID)
+	set(ParamClick, info.ClickID)
+	set(ParamBanner, info.BannerID)
+	set(ParamGroup, info.GroupID)
+	set(ParamCampaign, info.CampaignID)
+	set(ParamAdvertiser, info.AdvertiserID)
+	set(ParamBundle, info.Bundle)
+	set(ParamNetwork, info.Network)
+	set(ParamCountry, info.Country)
+	set(ParamRegion, info.Region)
+	set(ParamCity, info.City)
+	set(ParamSize, info.Size)
+	set(ParamMake, info.Make)
+	set(ParamGAID, info.GAID)
+	set(ParamOAID, info.OAID)
+
+	if info.Price != 0 {
+		values.Set(ParamPrice, strconv.FormatFloat(info.Price, 'f', -1, 64))
+	}
+
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+
+	return base + sep + values.Encode()
+}
+
+// Macro добавляет к ссылке параметр с макросом без экранирования
+func Macro(link string, key string, macro string) string {
+	return link + "&" + key + "=" + macro
+}
+
+// Target подставляет click id в ссылку рекламодателя
+func Target(target string, clickID string) string {
+	return strings.ReplaceAll(target, MacroClickID, url.QueryEscape(clickID))
+}
+
+// Parse достает экшен и данные показа из параметров трекера
+func Parse(values url.Values) (string, ads.TrackerInfo, error) {
+	info := ads.TrackerInfo{
+		RequestID:    values.Get(ParamRequest),
+		UserID:       values.Get(ParamUser),
+		PlacementID:  values.Get(ParamPlacement),
+		ClickID:      values.Get(ParamClick),
+		BannerID:     values.Get(ParamBanner),
+		GroupID:      values.Get(ParamGroup),
+		CampaignID:   values.Get(ParamCampaign),
+		AdvertiserID: values.Get(ParamAdvertiser),
+		Bundle:       values.Get(ParamBundle),
+		Network:      values.Get(ParamNetwork),
+		Country:      values.Get(ParamCountry),
+		Region:       values.Get(ParamRegion),
+		City:         values.Get(ParamCity),
+		Size:         values.Get(ParamSize),
+		Make:         values.Get(ParamMake),
+		GAID:         values.Get(ParamGAID),
+		OAID:         values.Get(ParamOAID),
+		Reason:       values.Get(ParamReason),
+	}
+
+	action := values.Get(ParamAction)
+	if action == "" {
+		return "", info, fmt.Errorf("action is empty")
+	}
+
+	if price := values.Get(ParamPrice); price != "" {
+		v, err := strconv.ParseFloat(price, 64)
+		if err != nil {
+			return action, info, fmt.Errorf("invalid price %q: %w", price, err)
+		}
+
+		// цена уходит в бюджеты, отрицательная вернула бы деньги
+		if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return action, info, fmt.Errorf("invalid price %q", price)
+		}
+
+		info.Price = v
+	}
+
+	return action, info, nil
+}
@@ -0,0 +1,75 @@
+package tracking
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+)
+
+func TestURL_Parse(t *testing.T) {
+	info := ads.TrackerInfo{
+		RequestID:    "request-1",
+		UserID:       "user-1",
+		PlacementID:  "placement-1",
+		ClickID:      "click-1",
+		BannerID:     "banner-1",
+		GroupID:      "group-1",
+		CampaignID:   "campaign-1",
+		AdvertiserID: "advertiser-1",
+		Bundle:       "com.example.app",
+		Price:        12.5,
+	}
+
+	link := URL("http://ads.coffee/tracker", ads.ActionImpression, info)
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+
+	action, parsed, err := Parse(u.Query())
+	require.NoError(t, err)
+
+	assert.Equal(t, ads.ActionImpression, action)
+	assert.Equal(t, info, parsed)
+}
+
+func TestURL_WithQuery(t *testing.T) {
+	link := URL("http://ads.coffee/tracker?source=test", ads.ActionWin, ads.TrackerInfo{})
+
+	assert.Equal(t, "http://ads.coffee/tracker?source=test&action=win", link)
+}
+
+func TestMacro(t *testing.T) {
+	link := Macro("http://ads.coffee/tracker?action=win", ParamPrice, "${AUCTION_PRICE}")
+
+	assert.Equal(t, "http://ads.coffee/tracker?action=win&price=${AUCTION_PRICE}", link)
+}
+
+func TestTarget(t *testing.T) {
+	link := Target("https://app.appsflyer.com/com.example?clickid={click_id}", "click-1")
+
+	assert.Equal(t, "https://app.appsflyer.com/com.example?clickid=click-1", link)
+	assert.Equal(t, "https://example.com", Target("https://example.com", "click-1"))
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, _, err := Parse(url.Values{})
+	assert.Error(t, err)
+
+	_, _, err = Parse(url.Values{
+		ParamAction: {ads.ActionWin},
+		ParamPrice:  {"${AUCTION_PRICE}"},
+	})
+	assert.Error(t, err)
+
+	for _, price := range []string{"-1e9", "NaN", "+Inf"} {
+		_, _, err = Parse(url.Values{
+			ParamAction: {ads.ActionBilling},
+			ParamPrice:  {price},
+		})
+		assert.Error(t, err, price)
+	}
+}
@@ -0,0 +1,13 @@
+package adapters
+
+import (
+	"go.uber.org/fx"
+
+	"go.ads.coffee/platform/server/plugins/adapters/openrtb"
+)
+
+var Module = fx.Module(
+	"adapters.adapters",
+
+	openrtb.Module,
+)
@@ -0,0 +1,261 @@
+package openrtb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/fx"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/openrtb"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+var Module = fx.Module(
+	"adapters.openrtb",
+
+	fx.Provide(
+		fx.Annotate(
+			New,
+			fx.As(new(plugins.Adapter)),
+			fx.ResultTags(`group:"adapters"`),
+		),
+	),
+)
+
+const (
+	endpointKey = "endpoint"
+	curKey      = "cur"
+
+	// нативный запрос без ассетов: сеть сама решает, что вернуть
+	defaultNative = `{"ver":"1.2"}`
+)
+
+// OpenRTB запрашивает ставку у сети, которая принимает
+// запросы по OpenRTB 2.5. Блок сети передается в imp.tagid
+type OpenRTB struct {
+	client   *http.Client
+	endpoint string
+	cur      string
+}
+
+func New() *OpenRTB {
+	return &OpenRTB{
+		client: &http.Client{},
+	}
+}
+
+func (o *OpenRTB) Name() string {
+	return "adapters.openrtb"
+}
+
+func (o *OpenRTB) Copy(cfg map[string]any) plugins.Adapter {
+	endpoint, _ := cfg[endpointKey].(string)
+	cur, _ := cfg[curKey].(string)
+
+	return &OpenRTB{
+		client:   o.client,
+		endpoint: endpoint,
+		cur:      cur,
+	}
+}
+
+func (o *OpenRTB) Schema() plugins.Schema {
+	return plugins.Schema{
+		{Name: endpointKey, Type: plugins.TypeString, Required: true},
+		{Name: curKey, Type: plugins.TypeString},
+	}
+}
+
+func (o *OpenRTB) Bid(ctx context.Context, state *plugins.State, unit ads.Unit, floor float64) (plugins.Bid, bool, error) {
+	data, err := json.Marshal(o.request(ctx, state, unit, floor))
+	if err != nil {
+		return plugins.Bid{}, false, fmt.Errorf("error on marshal bid request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return plugins.Bid{}, false, fmt.Errorf("error on create bid request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(openrtb.HeaderVersion, openrtb.Version25)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return plugins.Bid{}, false, fmt.Errorf("error on bid request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return plugins.Bid{}, false, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		return plugins.Bid{}, false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	res := openrtb.BidResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return plugins.Bid{}, false, fmt.Errorf("error on decode bid response: %w", err)
+	}
+
+	bid, ok := best(res, floor)
+	if !ok {
+		return plugins.Bid{}, false, nil
+	}
+
+	// сеть получает уведомление, когда клиент покажет креатив
+	tracker := bid.BURL
+	if tracker == "" {
+		tracker = bid.NURL
+	}
+
+	price := strconv.FormatFloat(bid.Price, 'f', -1, 64)
+
+	return plugins.Bid{
+		Price:   bid.Price,
+		Markup:  macros(bid.AdM, res.ID, bid.ImpID, price),
+		Tracker: macros(tracker, res.ID, bid.ImpID, price),
+	}, true, nil
+}
+
+func (o *OpenRTB) request(ctx context.Context, state *plugins.State, unit ads.Unit, floor float64) openrtb.BidRequest {
+	req := openrtb.BidRequest{
+		ID:  state.RequestID,
+		Imp: []openrtb.Imp{o.imp(state, unit, floor)},
+		At:  1,
+	}
+
+	if o.cur != "" {
+		req.Cur = []string{o.cur}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Tmax = max(time.Until(deadline).Milliseconds(), 1)
+	}
+
+	if state.App != nil {
+		req.App = &openrtb.App{
+			ID:     state.App.ID,
+			Bundle: state.App.Bundle,
+			Domain: state.App.Domain,
+			Cat:    state.App.Cat,
+		}
+	}
+
+	if state.Device != nil {
+		req.Device = &openrtb.Device{
+			UA:         state.Device.UA,
+			IP:         state.Device.IP,
+			IFA:        state.Device.IFA,
+			Make:       state.Device.Make,
+			Model:      state.Device.Model,
+			OS:         state.Device.OS,
+			OSV:        state.Device.OSV,
+			DeviceType: state.Device.Type,
+		}
+
+		if state.Geo != nil {
+			req.Device.Geo = &openrtb.Geo{
+				Country: state.Geo.Country,
+				Region:  state.Geo.Region,
+				City:    state.Geo.City,
+			}
+		}
+	}
+
+	if state.User != nil {
+		req.User = &openrtb.User{
+			ID:      state.User.ID,
+			Yob:     state.User.Yob,
+			Gender:  state.User.Gender,
+			Consent: state.User.Consent,
+		}
+	}
+
+	if state.Restrictions != nil {
+		req.Bcat = state.Restrictions.Bcat
+		req.Badv = state.Restrictions.Badv
+		req.Bapp = state.Restrictions.Bapp
+	}
+
+	return req
+}
+
+// imp повторяет imp исходного запроса, если он пришел по OpenRTB,
+// иначе описывает формат блока
+func (o *OpenRTB) imp(state *plugins.State, unit ads.Unit, floor float64) openrtb.Imp {
+	imp := openrtb.Imp{}
+
+	if state.BidRequest != nil && state.Placement != nil {
+		for _, i := range state.BidRequest.Imp {
+			if i.ID == state.Placement.ImpID {
+				imp = i
+
+				break
+			}
+		}
+	}
+
+	if imp.Banner == nil && imp.Native == nil && imp.Video == nil {
+		switch unit.Format {
+		case ads.CreativeTypeNative:
+			imp.Native = &openrtb.Native{Request: defaultNative}
+		case ads.CreativeTypeVideo:
+			imp.Video = &openrtb.Video{Mimes: []string{"video/mp4"}}
+		default:
+			imp.Banner = &openrtb.Banner{}
+		}
+	}
+
+	imp.ID = "1"
+	imp.TagID = unit.ID
+	imp.BidFloor = floor
+	imp.BidFloorCur = o.cur
+	imp.Ext = nil
+
+	return imp
+}
+
+// best - самая высокая ставка не ниже floor
+func best(res openrtb.BidResponse, floor float64) (openrtb.Bid, bool) {
+	result := openrtb.Bid{}
+	found := false
+
+	for _, seat := range res.SeatBid {
+		for _, bid := range seat.Bid {
+			if bid.Price < floor || bid.AdM == "" {
+				continue
+			}
+
+			if !found || bid.Price > result.Price {
+				result = bid
+				found = true
+			}
+		}
+	}
+
+	return result, found
+}
+
+func macros(s, auction, imp, price string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.NewReplacer(
+		openrtb.MacroAuctionID, auction,
+		openrtb.MacroAuctionImpID, imp,
+		openrtb.MacroAuctionPrice, price,
+	).Replace(s)
+}
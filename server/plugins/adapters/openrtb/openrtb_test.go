@@ -0,0 +1,126 @@
+package openrtb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/openrtb"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+func state() *plugins.State {
+	return &plugins.State{
+		RequestID: "request-1",
+		App:       &plugins.App{Bundle: "com.example.app"},
+		Device:    &plugins.Device{IP: "10.0.0.1", OS: "android", IFA: "ifa"},
+		Geo:       &plugins.Geo{Country: "RU"},
+		User:      &plugins.User{ID: "user-1"},
+		Placement: &plugins.Placement{ID: "placement-1"},
+	}
+}
+
+func TestOpenRTB_Bid(t *testing.T) {
+	var got openrtb.BidRequest
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, openrtb.Version25, r.Header.Get(openrtb.HeaderVersion))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+
+		_ = json.NewEncoder(w).Encode(openrtb.BidResponse{
+			ID: got.ID,
+			SeatBid: []openrtb.SeatBid{
+				{Bid: []openrtb.Bid{
+					{ID: "1", ImpID: "1", Price: 120, AdM: "<b>low</b>"},
+					{ID: "2", ImpID: "1", Price: 180, AdM: "<b>${AUCTION_PRICE}</b>", NURL: "http://net/win?id=${AUCTION_ID}"},
+				}},
+				// ниже минимальной цены
+				{Bid: []openrtb.Bid{{ID: "3", ImpID: "1", Price: 90, AdM: "<b>floor</b>"}}},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	a := New().Copy(map[string]any{"endpoint": srv.URL, "cur": "RUB"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	bid, ok, err := a.Bid(ctx, state(), ads.Unit{ID: "R-A-1-1", Format: ads.CreativeTypeNative}, 100)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	assert.Equal(t, plugins.Bid{
+		Price:   180,
+		Markup:  "<b>180</b>",
+		Tracker: "http://net/win?id=request-1",
+	}, bid)
+
+	require.Len(t, got.Imp, 1)
+	assert.Equal(t, "request-1", got.ID)
+	assert.Equal(t, "R-A-1-1", got.Imp[0].TagID)
+	assert.Equal(t, 100.0, got.Imp[0].BidFloor)
+	assert.Equal(t, "RUB", got.Imp[0].BidFloorCur)
+	assert.NotNil(t, got.Imp[0].Native)
+	assert.Equal(t, "com.example.app", got.App.Bundle)
+	assert.Equal(t, "RU", got.Device.Geo.Country)
+	assert.Equal(t, "user-1", got.User.ID)
+	assert.Positive(t, got.Tmax)
+	assert.LessOrEqual(t, got.Tmax, int64(1000))
+}
+
+func TestOpenRTB_Bid_SourceImp(t *testing.T) {
+	var got openrtb.BidRequest
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s := state()
+	s.Placement.ImpID = "imp-2"
+	s.BidRequest = &openrtb.BidRequest{
+		Imp: []openrtb.Imp{
+			{ID: "imp-1", Native: &openrtb.Native{Request: "{}"}},
+			{ID: "imp-2", Video: &openrtb.Video{Mimes: []string{"video/webm"}}, BidFloor: 1},
+		},
+	}
+
+	_, ok, err := New().Copy(map[string]any{"endpoint": srv.URL}).Bid(context.Background(), s, ads.Unit{ID: "unit"}, 50)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// формат берется из исходного imp, цена и блок - свои
+	require.Len(t, got.Imp, 1)
+	assert.Equal(t, []string{"video/webm"}, got.Imp[0].Video.Mimes)
+	assert.Nil(t, got.Imp[0].Banner)
+	assert.Equal(t, "unit", got.Imp[0].TagID)
+	assert.Equal(t, 50.0, got.Imp[0].BidFloor)
+}
+
+func TestOpenRTB_Bid_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/invalid" {
+			_, _ = w.Write([]byte("{"))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	_, _, err := New().Copy(map[string]any{"endpoint": srv.URL}).Bid(context.Background(), state(), ads.Unit{}, 0)
+	assert.ErrorContains(t, err, "unexpected status 502")
+
+	_, _, err = New().Copy(map[string]any{"endpoint": srv.URL + "/invalid"}).Bid(context.Background(), state(), ads.Unit{}, 0)
+	assert.ErrorContains(t, err, "error on decode bid response")
+}
@@ -3,6 +3,7 @@ package plugins
 import (
 	"go.uber.org/fx"
 
+	"go.ads.coffee/platform/server/internal/adapters"
 	"go.ads.coffee/platform/server/internal/inputs"
 	"go.ads.coffee/platform/server/internal/outputs"
 	"go.ads.coffee/platform/server/internal/pipeline"
@@ -22,6 +23,9 @@ var Module = fx.Module(
 	// targetings
 	targetings.Module,
 
+	// mediation adapters
+	adapters.Module,
+
 	// output
 	outputs.Module,
 
@@ -4,6 +4,7 @@ import (
 	"go.uber.org/fx"
 
 	"go.ads.coffee/platform/server/plugins/inputs/postback"
+	"go.ads.coffee/platform/server/plugins/inputs/prebid"
 	"go.ads.coffee/platform/server/plugins/inputs/rtb"
 	"go.ads.coffee/platform/server/plugins/inputs/static"
 	"go.ads.coffee/platform/server/plugins/inputs/tracker"
@@ -14,6 +15,7 @@ var Module = fx.Module(
 	"inputs.inputs",
 
 	rtb.Module,
+	prebid.Module,
 	web.Module,
 	postback.Module,
 	tracker.Module,
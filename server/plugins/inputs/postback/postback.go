@@ -2,12 +2,61 @@ package postback
 
 import (
 	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 
+	"go.ads.coffee/platform/server/internal/analytics"
+	"go.ads.coffee/platform/server/internal/conversions"
+	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/tools/network"
 )
 
+const (
+	windowKey = "window"
+	layoutKey = "layout"
+	paramsKey = "params"
+
+	defaultWindow = 7 * 24 * time.Hour
+)
+
+// Поля постбека
+const (
+	FieldClickID  = "click_id"
+	FieldEvent    = "event"
+	FieldRevenue  = "revenue"
+	FieldCurrency = "currency"
+)
+
+// Раскладки параметров трекеров. Для каждого поля
+// берется первый непустой параметр из списка
+var layouts = map[string]map[string][]string{
+	"default": {
+		FieldClickID:  {"click_id", "clickid", "cid"},
+		FieldEvent:    {"event", "event_name"},
+		FieldRevenue:  {"revenue"},
+		FieldCurrency: {"currency"},
+	},
+	"appsflyer": {
+		FieldClickID:  {"clickid"},
+		FieldEvent:    {"event_name"},
+		FieldRevenue:  {"event_revenue"},
+		FieldCurrency: {"event_revenue_currency"},
+	},
+	"adjust": {
+		FieldClickID:  {"click_id", "label"},
+		FieldEvent:    {"event_name", "activity_kind"},
+		FieldRevenue:  {"revenue_float", "revenue"},
+		FieldCurrency: {"currency"},
+	},
+}
+
 var Module = fx.Module(
 	"inputs.postback",
 
@@ -20,11 +69,37 @@ var Module = fx.Module(
 	),
 )
 
+type Analytics interface {
+	LogConversion(ctx context.Context, data ads.TrackerInfo) error
+}
+
+type Conversions interface {
+	Click(ctx context.Context, clickID string) (ads.TrackerInfo, bool, error)
+	First(ctx context.Context, clickID, event string) (bool, error)
+}
+
 type Postback struct {
+	window      time.Duration
+	params      map[string][]string
+	logger      *zap.Logger
+	analytics   Analytics
+	conversions Conversions
+	now         func() time.Time
 }
 
-func New() *Postback {
-	return &Postback{}
+func New(
+	logger *zap.Logger,
+	analytics *analytics.Analytics,
+	conversions *conversions.Conversions,
+) *Postback {
+	return &Postback{
+		window:      defaultWindow,
+		params:      layouts["default"],
+		logger:      logger,
+		analytics:   analytics,
+		conversions: conversions,
+		now:         time.Now,
+	}
 }
 
 func (s *Postback) Name() string {
@@ -32,14 +107,177 @@ func (s *Postback) Name() string {
 }
 
 func (s *Postback) Copy(cfg map[string]any) plugins.Input {
-	return &Postback{}
+	window := defaultWindow
+	if v, ok := cfg[windowKey].(string); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			s.logger.Warn("invalid postback window, use default",
+				zap.String("window", v),
+				zap.Duration("default", defaultWindow),
+			)
+		} else {
+			window = d
+		}
+	}
+
+	layout, _ := cfg[layoutKey].(string)
+	if layout == "" {
+		layout = "default"
+	}
+
+	base, ok := layouts[layout]
+	if !ok {
+		s.logger.Warn("unknown postback layout, use default", zap.String("layout", layout))
+
+		base = layouts["default"]
+	}
+
+	overrides, _ := cfg[paramsKey].(map[string]any)
+
+	return &Postback{
+		window:      window,
+		params:      Params(base, overrides),
+		logger:      s.logger,
+		analytics:   s.analytics,
+		conversions: s.conversions,
+		now:         s.now,
+	}
 }
 
-func (stages *Postback) Do(ctx context.Context, state *plugins.State) bool {
-	// нужно получить данные пользователя из запроса
+func (s *Postback) Schema() plugins.Schema {
+	return plugins.Schema{
+		{Name: windowKey, Type: plugins.TypeDuration, Default: defaultWindow.String()},
+		{Name: layoutKey, Type: plugins.TypeString, Default: "default", Values: []string{"default", "appsflyer", "adjust"}},
+		{Name: paramsKey, Type: plugins.TypeMap},
+	}
+}
 
+func (s *Postback) Do(ctx context.Context, state *plugins.State) bool {
 	state.User = &plugins.User{}
-	state.Device = &plugins.Device{}
+	state.Device = &plugins.Device{
+		UA: state.Request.UserAgent(),
+		IP: network.ClientIP(state.Request),
+	}
+
+	query := state.Request.URL.Query()
+
+	clickID := s.value(query, FieldClickID)
+	if clickID == "" {
+		s.logger.Warn("postback without click id")
+
+		state.Response.WriteHeader(http.StatusBadRequest)
+
+		return false
+	}
+
+	event := s.value(query, FieldEvent)
+	if event == "" {
+		event = ads.ActionConversion
+	}
+
+	revenue := 0.0
+	if v := s.value(query, FieldRevenue); v != "" {
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			s.logger.Warn("invalid postback revenue", zap.String("revenue", v), zap.Error(err))
+
+			state.Response.WriteHeader(http.StatusBadRequest)
+
+			return false
+		}
+
+		revenue = r
+	}
+
+	info, found, err := s.conversions.Click(ctx, clickID)
+	if err != nil {
+		s.logger.Error("error on load click", zap.String("click", clickID), zap.Error(err))
+
+		state.Response.WriteHeader(http.StatusInternalServerError)
+
+		return false
+	}
+
+	if !found {
+		s.logger.Warn("postback for unknown click", zap.String("click", clickID))
+
+		state.Response.WriteHeader(http.StatusNotFound)
+
+		return false
+	}
+
+	now := s.now()
+
+	// клик старше окна атрибуции не учитываем
+	if now.Sub(time.Unix(info.Timestamp, 0)) > s.window {
+		s.logger.Warn("postback out of attribution window",
+			zap.String("click", clickID),
+			zap.Duration("window", s.window),
+		)
+
+		state.Response.WriteHeader(http.StatusGone)
+
+		return false
+	}
+
+	first, err := s.conversions.First(ctx, clickID, event)
+	if err != nil {
+		s.logger.Warn("error on dedup postback", zap.String("click", clickID), zap.Error(err))
+	} else if !first {
+		s.logger.Debug("duplicate postback", zap.String("click", clickID), zap.String("event", event))
+
+		state.Response.WriteHeader(http.StatusConflict)
+
+		return false
+	}
+
+	info.Timestamp = now.Unix()
+	info.Event = event
+	info.Revenue = revenue
+	info.Currency = strings.ToUpper(s.value(query, FieldCurrency))
+
+	if err := s.analytics.LogConversion(ctx, info); err != nil {
+		s.logger.Error("error on log conversion", zap.String("click", clickID), zap.Error(err))
+	}
 
 	return true
 }
+
+func (s *Postback) value(query url.Values, field string) string {
+	for _, name := range s.params[field] {
+		if v := query.Get(name); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// Params накладывает параметры из конфига на раскладку.
+// Значение может быть строкой или списком строк.
+func Params(base map[string][]string, overrides map[string]any) map[string][]string {
+	params := map[string][]string{}
+
+	for field, names := range base {
+		params[field] = names
+	}
+
+	for field, v := range overrides {
+		switch names := v.(type) {
+		case string:
+			params[field] = []string{names}
+		case []any:
+			list := []string{}
+
+			for _, n := range names {
+				if name, ok := n.(string); ok {
+					list = append(list, name)
+				}
+			}
+
+			params[field] = list
+		}
+	}
+
+	return params
+}
@@ -2,54 +2,278 @@ package postback
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 )
 
-func TestNew(t *testing.T) {
-	postback := New()
+// MockAnalytics is a mock implementation of the Analytics interface
+type MockAnalytics struct {
+	mock.Mock
+}
 
-	assert.NotNil(t, postback)
+func (m *MockAnalytics) LogConversion(ctx context.Context, data ads.TrackerInfo) error {
+	args := m.Called(ctx, data)
+	return args.Error(0)
 }
 
-func TestPostback_Name(t *testing.T) {
-	postback := New()
+// MockConversions is a mock implementation of the Conversions interface
+type MockConversions struct {
+	mock.Mock
+}
+
+func (m *MockConversions) Click(ctx context.Context, clickID string) (ads.TrackerInfo, bool, error) {
+	args := m.Called(ctx, clickID)
+	return args.Get(0).(ads.TrackerInfo), args.Bool(1), args.Error(2)
+}
+
+func (m *MockConversions) First(ctx context.Context, clickID, event string) (bool, error) {
+	args := m.Called(ctx, clickID, event)
+	return args.Bool(0), args.Error(1)
+}
 
-	name := postback.Name()
+var now = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
 
-	assert.Equal(t, "inputs.postback", name)
+func click() ads.TrackerInfo {
+	return ads.TrackerInfo{
+		Timestamp:    now.Add(-time.Hour).Unix(),
+		Action:       ads.ActionCLick,
+		RequestID:    "request-1",
+		ClickID:      "click-1",
+		BannerID:     "1",
+		GroupID:      "2",
+		CampaignID:   "3",
+		AdvertiserID: "4",
+		Bundle:       "com.example.app",
+		Country:      "RU",
+		City:         "Moscow",
+	}
+}
+
+func newPostback(cfg map[string]any, a Analytics, c Conversions) plugins.Input {
+	p := &Postback{
+		logger:      zap.NewNop(),
+		analytics:   a,
+		conversions: c,
+		now:         func() time.Time { return now },
+	}
+
+	return p.Copy(cfg)
+}
+
+func state(query string) (*plugins.State, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+
+	return &plugins.State{
+		Request:  httptest.NewRequest(http.MethodGet, "/postback?"+query, nil),
+		Response: w,
+	}, w
+}
+
+func TestPostback_Name(t *testing.T) {
+	assert.Equal(t, "inputs.postback", (&Postback{}).Name())
 }
 
 func TestPostback_Copy(t *testing.T) {
-	postback := New()
+	postback := &Postback{logger: zap.NewNop()}
 
-	cfgMap := map[string]any{"key": "value"}
-	copied := postback.Copy(cfgMap)
+	copied := postback.Copy(map[string]any{
+		"window": "24h",
+		"layout": "appsflyer",
+		"params": map[string]any{
+			"event": []any{"af_event", "event_name"},
+		},
+	})
 
-	assert.NotNil(t, copied)
 	assert.IsType(t, &Postback{}, copied)
+	assert.Equal(t, 24*time.Hour, copied.(*Postback).window)
+	assert.Equal(t, []string{"clickid"}, copied.(*Postback).params[FieldClickID])
+	assert.Equal(t, []string{"af_event", "event_name"}, copied.(*Postback).params[FieldEvent])
+
+	// неверные значения заменяются значениями по умолчанию
+	copied = postback.Copy(map[string]any{"window": "week", "layout": "unknown"})
+	assert.Equal(t, defaultWindow, copied.(*Postback).window)
+	assert.Equal(t, layouts["default"], copied.(*Postback).params)
 }
 
 func TestPostback_Do(t *testing.T) {
-	postback := New()
-
-	ctx := context.Background()
-	state := &plugins.State{
-		Request:    &http.Request{},
-		Response:   nil,
-		User:       nil,
-		Device:     nil,
-		Candidates: []ads.Banner{},
-		Winners:    []ads.Banner{},
+	analytics := new(MockAnalytics)
+	conversions := new(MockConversions)
+
+	conversions.On("Click", mock.Anything, "click-1").Return(click(), true, nil)
+	conversions.On("First", mock.Anything, "click-1", "purchase").Return(true, nil)
+
+	expected := click()
+	expected.Timestamp = now.Unix()
+	expected.Event = "purchase"
+	expected.Revenue = 9.99
+	expected.Currency = "USD"
+
+	analytics.On("LogConversion", mock.Anything, expected).Return(nil)
+
+	postback := newPostback(map[string]any{}, analytics, conversions)
+	st, _ := state("click_id=click-1&event=purchase&revenue=9.99&currency=usd")
+
+	assert.True(t, postback.Do(context.Background(), st))
+	assert.NotNil(t, st.User)
+	assert.NotNil(t, st.Device)
+
+	analytics.AssertExpectations(t)
+	conversions.AssertExpectations(t)
+}
+
+func TestPostback_Do_Layouts(t *testing.T) {
+	tests := []struct {
+		layout string
+		query  string
+		event  string
+	}{
+		{
+			layout: "appsflyer",
+			query:  "clickid=click-1&event_name=af_purchase&event_revenue=5&event_revenue_currency=EUR",
+			event:  "af_purchase",
+		},
+		{
+			layout: "adjust",
+			query:  "label=click-1&activity_kind=install&revenue_float=5&currency=EUR",
+			event:  "install",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			analytics := new(MockAnalytics)
+			conversions := new(MockConversions)
+
+			conversions.On("Click", mock.Anything, "click-1").Return(click(), true, nil)
+			conversions.On("First", mock.Anything, "click-1", tt.event).Return(true, nil)
+
+			analytics.On("LogConversion", mock.Anything, mock.MatchedBy(func(info ads.TrackerInfo) bool {
+				return info.Event == tt.event && info.Revenue == 5 && info.Currency == "EUR" && info.BannerID == "1"
+			})).Return(nil)
+
+			postback := newPostback(map[string]any{"layout": tt.layout}, analytics, conversions)
+			st, _ := state(tt.query)
+
+			assert.True(t, postback.Do(context.Background(), st))
+
+			analytics.AssertExpectations(t)
+		})
 	}
+}
+
+func TestPostback_Do_DefaultEvent(t *testing.T) {
+	analytics := new(MockAnalytics)
+	conversions := new(MockConversions)
+
+	conversions.On("Click", mock.Anything, "click-1").Return(click(), true, nil)
+	conversions.On("First", mock.Anything, "click-1", ads.ActionConversion).Return(true, nil)
+	analytics.On("LogConversion", mock.Anything, mock.Anything).Return(nil)
+
+	postback := newPostback(map[string]any{}, analytics, conversions)
+	st, _ := state("cid=click-1")
+
+	assert.True(t, postback.Do(context.Background(), st))
+
+	conversions.AssertExpectations(t)
+}
+
+func TestPostback_Do_Rejects(t *testing.T) {
+	expired := click()
+	expired.Timestamp = now.Add(-48 * time.Hour).Unix()
+
+	tests := []struct {
+		name   string
+		query  string
+		setup  func(c *MockConversions)
+		status int
+	}{
+		{
+			name:   "no click id",
+			query:  "event=install",
+			setup:  func(c *MockConversions) {},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid revenue",
+			query:  "click_id=click-1&revenue=abc",
+			setup:  func(c *MockConversions) {},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:  "unknown click",
+			query: "click_id=click-1",
+			setup: func(c *MockConversions) {
+				c.On("Click", mock.Anything, "click-1").Return(ads.TrackerInfo{}, false, nil)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:  "redis error",
+			query: "click_id=click-1",
+			setup: func(c *MockConversions) {
+				c.On("Click", mock.Anything, "click-1").Return(ads.TrackerInfo{}, false, errors.New("redis error"))
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:  "out of window",
+			query: "click_id=click-1",
+			setup: func(c *MockConversions) {
+				c.On("Click", mock.Anything, "click-1").Return(expired, true, nil)
+			},
+			status: http.StatusGone,
+		},
+		{
+			name:  "duplicate",
+			query: "click_id=click-1",
+			setup: func(c *MockConversions) {
+				c.On("Click", mock.Anything, "click-1").Return(click(), true, nil)
+				c.On("First", mock.Anything, "click-1", ads.ActionConversion).Return(false, nil)
+			},
+			status: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analytics := new(MockAnalytics)
+			conversions := new(MockConversions)
+			tt.setup(conversions)
+
+			postback := newPostback(map[string]any{"window": "24h"}, analytics, conversions)
+			st, w := state(tt.query)
+
+			assert.False(t, postback.Do(context.Background(), st))
+			assert.Equal(t, tt.status, w.Code)
+
+			analytics.AssertNotCalled(t, "LogConversion", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestPostback_Do_DedupError(t *testing.T) {
+	analytics := new(MockAnalytics)
+	conversions := new(MockConversions)
+
+	conversions.On("Click", mock.Anything, "click-1").Return(click(), true, nil)
+	conversions.On("First", mock.Anything, "click-1", ads.ActionConversion).Return(false, errors.New("redis error"))
+	analytics.On("LogConversion", mock.Anything, mock.Anything).Return(nil)
+
+	postback := newPostback(map[string]any{}, analytics, conversions)
+	st, _ := state("click_id=click-1")
 
-	result := postback.Do(ctx, state)
+	// если redis недоступен, конверсия учитывается
+	assert.True(t, postback.Do(context.Background(), st))
 
-	assert.True(t, result)
-	assert.NotNil(t, state.User)
-	assert.NotNil(t, state.Device)
+	analytics.AssertExpectations(t)
 }
@@ -0,0 +1,159 @@
+package prebid
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/pkg/geoip"
+	"go.ads.coffee/platform/server/internal/analytics"
+	"go.ads.coffee/platform/server/internal/domain/openrtb"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/plugins/inputs/rtb"
+)
+
+const (
+	bidderKey = "bidder"
+
+	defaultBidder = "adscoffee"
+)
+
+var Module = fx.Module(
+	"inputs.prebid",
+
+	fx.Provide(
+		fx.Annotate(
+			New,
+			fx.As(new(plugins.Input)),
+			fx.ResultTags(`group:"inputs"`),
+		),
+	),
+)
+
+type Analytics interface {
+	LogRequest(ctx context.Context, state *plugins.State) error
+}
+
+// Parser разбирает OpenRTB запрос, это inputs.rtb
+type Parser interface {
+	plugins.Input
+	plugins.WithSchema
+
+	Parse(ctx context.Context, state *plugins.State) bool
+}
+
+// Params - параметры бидера, которые паблишер указывает
+// в настройках Prebid для нашего блока
+type Params struct {
+	PlacementID string  `json:"placementId"`
+	BidFloor    float64 `json:"bidFloor"`
+}
+
+// Prebid принимает запросы от Prebid Server. Это OpenRTB,
+// поэтому запрос разбирает inputs.rtb, а здесь плейсмент
+// берется из параметров бидера в imp.ext
+type Prebid struct {
+	bidder    string
+	logger    *zap.Logger
+	rtb       Parser
+	analytics Analytics
+}
+
+func New(logger *zap.Logger, analytics *analytics.Analytics, geoip *geoip.Geoip) *Prebid {
+	return &Prebid{
+		bidder:    defaultBidder,
+		logger:    logger,
+		rtb:       rtb.New(logger, analytics, geoip),
+		analytics: analytics,
+	}
+}
+
+func (p *Prebid) Name() string {
+	return "inputs.prebid"
+}
+
+func (p *Prebid) Copy(cfg map[string]any) plugins.Input {
+	bidder, _ := cfg[bidderKey].(string)
+	if bidder == "" {
+		bidder = defaultBidder
+	}
+
+	return &Prebid{
+		bidder:    bidder,
+		logger:    p.logger,
+		rtb:       p.rtb.Copy(cfg).(Parser),
+		analytics: p.analytics,
+	}
+}
+
+// Schema - ключи inputs.rtb и имя бидера в Prebid
+func (p *Prebid) Schema() plugins.Schema {
+	return append(p.rtb.Schema(),
+		plugins.Field{Name: bidderKey, Type: plugins.TypeString, Default: defaultBidder},
+	)
+}
+
+func (p *Prebid) Do(ctx context.Context, state *plugins.State) bool {
+	if ok := p.rtb.Parse(ctx, state); !ok {
+		return false
+	}
+
+	params, ok, err := p.params(state)
+	if err != nil {
+		p.logger.Warn("invalid prebid bidder params", zap.String("bidder", p.bidder), zap.Error(err))
+		p.nobid(state, openrtb.NbrInvalidRequest)
+
+		return false
+	}
+
+	// без параметров остается плейсмент из tagid
+	if ok {
+		if params.PlacementID != "" {
+			state.Placement.ID = params.PlacementID
+		}
+
+		state.Placement.BidFloor = max(state.Placement.BidFloor, params.BidFloor)
+	}
+
+	// check error
+	_ = p.analytics.LogRequest(ctx, state)
+
+	return true
+}
+
+func (p *Prebid) params(state *plugins.State) (Params, bool, error) {
+	params := Params{}
+
+	for _, imp := range state.BidRequest.Imp {
+		if imp.ID != state.Placement.ImpID || len(imp.Ext) == 0 {
+			continue
+		}
+
+		ext := openrtb.PrebidImpExt{}
+		if err := json.Unmarshal(imp.Ext, &ext); err != nil {
+			return params, false, err
+		}
+
+		raw, ok := ext.Params(p.bidder)
+		if !ok {
+			return params, false, nil
+		}
+
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return params, false, err
+		}
+
+		return params, true, nil
+	}
+
+	return params, false, nil
+}
+
+func (p *Prebid) nobid(state *plugins.State, nbr int) {
+	state.Response.Header().Set(openrtb.HeaderNbr, strconv.Itoa(nbr))
+	state.Response.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,180 @@
+package prebid
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/pkg/geoip"
+	"go.ads.coffee/platform/server/internal/domain/openrtb"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/plugins/inputs/rtb"
+)
+
+// MockAnalytics is a mock implementation of the Analytics interface
+type MockAnalytics struct {
+	mock.Mock
+}
+
+func (m *MockAnalytics) LogRequest(ctx context.Context, state *plugins.State) error {
+	args := m.Called(ctx, state)
+	return args.Error(0)
+}
+
+// backend - база geoip без локаций
+type backend struct{}
+
+func (backend) Lookup(ip net.IP) (geoip.Location, error) {
+	return geoip.Location{}, nil
+}
+
+func (backend) Close() error {
+	return nil
+}
+
+func request(ext string) string {
+	return `{
+		"id": "req-1",
+		"imp": [{
+			"id": "1",
+			"tagid": "/1234/banner",
+			"bidfloor": 1.5,
+			"banner": {"w": 320, "h": 50},
+			"ext": ` + ext + `
+		}],
+		"app": {"id": "app-1", "bundle": "com.example.app"},
+		"device": {"ua": "Mozilla/5.0", "ip": "192.0.2.1"}
+	}`
+}
+
+func newPrebid(analytics Analytics) *Prebid {
+	logger := zap.NewNop()
+
+	p := &Prebid{
+		bidder:    defaultBidder,
+		logger:    logger,
+		rtb:       rtb.New(logger, nil, geoip.New(geoip.Config{}, backend{})),
+		analytics: analytics,
+	}
+
+	return p.Copy(map[string]any{}).(*Prebid)
+}
+
+func newState(body string) (*plugins.State, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+
+	return &plugins.State{
+		Request:  httptest.NewRequest(http.MethodPost, "/prebid", strings.NewReader(body)),
+		Response: w,
+	}, w
+}
+
+func TestPrebid_Name(t *testing.T) {
+	p := &Prebid{}
+
+	assert.Equal(t, "inputs.prebid", p.Name())
+}
+
+func TestPrebid_Copy(t *testing.T) {
+	p := newPrebid(new(MockAnalytics))
+
+	copied := p.Copy(map[string]any{"bidder": "coffee"}).(*Prebid)
+
+	assert.Equal(t, "coffee", copied.bidder)
+	assert.Equal(t, p.analytics, copied.analytics)
+	assert.NotNil(t, copied.rtb)
+
+	assert.Equal(t, defaultBidder, p.Copy(map[string]any{}).(*Prebid).bidder)
+}
+
+func TestPrebid_Schema(t *testing.T) {
+	p := newPrebid(new(MockAnalytics))
+
+	names := []string{}
+	for _, f := range p.Schema() {
+		names = append(names, f.Name)
+	}
+
+	assert.Contains(t, names, bidderKey)
+	assert.Contains(t, names, "tmax_margin")
+}
+
+func TestPrebid_Do_BidderParams(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogRequest", mock.Anything, mock.Anything).Return(nil)
+
+	p := newPrebid(mockAnalytics)
+	state, _ := newState(request(`{"bidder": {"placementId": "placement-2", "bidFloor": 3}}`))
+
+	require.True(t, p.Do(context.Background(), state))
+
+	assert.Equal(t, "placement-2", state.Placement.ID)
+	assert.Equal(t, "1", state.Placement.ImpID)
+	assert.Equal(t, 3.0, state.Placement.BidFloor)
+
+	// в аналитику попадает уже плейсмент из параметров
+	logged := mockAnalytics.Calls[0].Arguments.Get(1).(*plugins.State)
+	assert.Equal(t, "placement-2", logged.Placement.ID)
+}
+
+func TestPrebid_Do_PrebidBidder(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogRequest", mock.Anything, mock.Anything).Return(nil)
+
+	p := newPrebid(mockAnalytics)
+	state, _ := newState(request(`{"prebid": {"bidder": {"adscoffee": {"placementId": "placement-3", "bidFloor": 1}}}}`))
+
+	require.True(t, p.Do(context.Background(), state))
+
+	assert.Equal(t, "placement-3", state.Placement.ID)
+	// минимальная цена запроса выше, чем в параметрах
+	assert.Equal(t, 1.5, state.Placement.BidFloor)
+}
+
+func TestPrebid_Do_NoParams(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogRequest", mock.Anything, mock.Anything).Return(nil)
+
+	p := newPrebid(mockAnalytics)
+	state, _ := newState(request(`{"prebid": {"bidder": {"other": {"placementId": "placement-4"}}}}`))
+
+	require.True(t, p.Do(context.Background(), state))
+
+	assert.Equal(t, "/1234/banner", state.Placement.ID)
+	assert.Equal(t, 1.5, state.Placement.BidFloor)
+	mockAnalytics.AssertNumberOfCalls(t, "LogRequest", 1)
+}
+
+func TestPrebid_Do_InvalidParams(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+
+	p := newPrebid(mockAnalytics)
+	state, w := newState(request(`{"bidder": {"placementId": 42}}`))
+
+	assert.False(t, p.Do(context.Background(), state))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, strconv.Itoa(openrtb.NbrInvalidRequest), w.Header().Get(openrtb.HeaderNbr))
+	mockAnalytics.AssertNotCalled(t, "LogRequest", mock.Anything, mock.Anything)
+}
+
+func TestPrebid_Do_InvalidRequest(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+
+	p := newPrebid(mockAnalytics)
+	state, w := newState(`{"id": `)
+
+	assert.False(t, p.Do(context.Background(), state))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockAnalytics.AssertNotCalled(t, "LogRequest", mock.Anything, mock.Anything)
+}
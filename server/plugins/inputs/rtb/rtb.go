@@ -2,12 +2,31 @@ package rtb
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
 
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 
+	"go.ads.coffee/platform/pkg/geoip"
+	"go.ads.coffee/platform/server/internal/analytics"
+	"go.ads.coffee/platform/server/internal/domain/openrtb"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 )
 
+const (
+	// максимальный размер тела запроса
+	maxBodySize = 1 << 20
+
+	networkKey = "network"
+	marginKey  = "tmax_margin"
+
+	// запас от tmax на сеть и отрисовку ответа
+	defaultMargin = 10 * time.Millisecond
+)
+
 var Module = fx.Module(
 	"inputs.rtb",
 
@@ -20,11 +39,28 @@ var Module = fx.Module(
 	),
 )
 
+type Analytics interface {
+	LogRequest(ctx context.Context, state *plugins.State) error
+}
+
+type Geoip interface {
+	Lookup(ip string) (geoip.Location, bool)
+}
+
 type Rtb struct {
+	network   string
+	margin    time.Duration
+	logger    *zap.Logger
+	analytics Analytics
+	geoip     Geoip
 }
 
-func New() *Rtb {
-	return &Rtb{}
+func New(logger *zap.Logger, analytics *analytics.Analytics, geoip *geoip.Geoip) *Rtb {
+	return &Rtb{
+		logger:    logger,
+		analytics: analytics,
+		geoip:     geoip,
+	}
 }
 
 func (rtb *Rtb) Name() string {
@@ -32,17 +68,225 @@ func (rtb *Rtb) Name() string {
 }
 
 func (rtb *Rtb) Copy(cfg map[string]any) plugins.Input {
-	return &Rtb{}
+	network, _ := cfg[networkKey].(string)
+
+	margin := defaultMargin
+	if v, ok := cfg[marginKey].(string); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			rtb.logger.Warn("invalid tmax margin, use default",
+				zap.String("margin", v),
+				zap.Duration("default", defaultMargin),
+			)
+		} else {
+			margin = d
+		}
+	}
+
+	return &Rtb{
+		network:   network,
+		margin:    margin,
+		logger:    rtb.logger,
+		analytics: rtb.analytics,
+		geoip:     rtb.geoip,
+	}
+}
+
+func (rtb *Rtb) Schema() plugins.Schema {
+	return plugins.Schema{
+		{Name: networkKey, Type: plugins.TypeString},
+		{Name: marginKey, Type: plugins.TypeDuration, Default: defaultMargin.String()},
+	}
 }
 
 func (rtb *Rtb) Do(ctx context.Context, state *plugins.State) bool {
+	if ok := rtb.Parse(ctx, state); !ok {
+		return false
+	}
+
+	// check error
+	_ = rtb.analytics.LogRequest(ctx, state)
 
-	// обработка разных типов запросов тоже
-	// может быть вынесена в пллагины
+	return true
+}
+
+// Parse разбирает запрос в state без записи в аналитику, чтобы
+// входы поверх OpenRTB могли уточнить плейсмент до нее
+func (rtb *Rtb) Parse(ctx context.Context, state *plugins.State) bool {
+	start := time.Now()
+
+	if state.Request.Method != http.MethodPost {
+		rtb.nobid(state, openrtb.NbrInvalidRequest)
+
+		return false
+	}
+
+	data, err := io.ReadAll(io.LimitReader(state.Request.Body, maxBodySize))
+	if err != nil {
+		rtb.logger.Warn("error on read rtb request", zap.Error(err))
+		rtb.nobid(state, openrtb.NbrTechnicalError)
+
+		return false
+	}
 
-	// тут я могу понять какие форматы мне нужны
+	req, err := openrtb.Parse(data, state.Request.Header.Get(openrtb.HeaderVersion))
+	if err != nil {
+		rtb.logger.Warn("invalid rtb request", zap.Error(err))
+		rtb.nobid(state, openrtb.NbrInvalidRequest)
 
-	// разбираем rtb запрос
+		return false
+	}
+
+	state.BidRequest = req
+	state.Deadline = rtb.deadline(start, req.Tmax)
+	state.Network = rtb.network
+	state.User = user(req)
+	state.Device = device(req)
+	state.Geo = rtb.geo(req, state.Device.IP)
+	state.App = app(req)
+	state.Restrictions = &plugins.Restrictions{
+		Bcat: req.Bcat,
+		Badv: req.Badv,
+		Bapp: req.Bapp,
+	}
+
+	// пока обрабатываем только первый imp,
+	// остальные остаются в state.BidRequest
+	imp := req.Imp[0]
+
+	id := imp.TagID
+	if id == "" {
+		id = imp.ID
+	}
+
+	state.Placement = &plugins.Placement{
+		ID:       id,
+		ImpID:    imp.ID,
+		Formats:  imp.Formats(),
+		BidFloor: imp.BidFloor,
+	}
 
 	return true
 }
+
+// deadline считает время, до которого нужно ответить бирже.
+// Если tmax не задан, остается дедлайн пайплайна
+func (rtb *Rtb) deadline(start time.Time, tmax int64) time.Time {
+	if tmax <= 0 {
+		return time.Time{}
+	}
+
+	d := time.Duration(tmax) * time.Millisecond
+	if d > rtb.margin {
+		d -= rtb.margin
+	}
+
+	return start.Add(d)
+}
+
+// nobid отвечает 204 без тела, причину отказа
+// передаем в заголовке
+func (rtb *Rtb) nobid(state *plugins.State, nbr int) {
+	state.Response.Header().Set(openrtb.HeaderNbr, strconv.Itoa(nbr))
+	state.Response.WriteHeader(http.StatusNoContent)
+}
+
+func user(req *openrtb.BidRequest) *plugins.User {
+	if req.User == nil {
+		return &plugins.User{}
+	}
+
+	u := &plugins.User{
+		ID:       req.User.ID,
+		BuyerUID: req.User.BuyerUID,
+		Yob:      req.User.Yob,
+		Gender:   req.User.Gender,
+		Consent:  req.User.Consent,
+		Apps:     req.User.Apps(),
+	}
+
+	if u.ID == "" {
+		u.ID = req.User.BuyerUID
+	}
+
+	return u
+}
+
+func device(req *openrtb.BidRequest) *plugins.Device {
+	if req.Device == nil {
+		return &plugins.Device{}
+	}
+
+	d := &plugins.Device{
+		UA:    req.Device.UA,
+		IP:    req.Device.IP,
+		IFA:   req.Device.IFA,
+		Make:  req.Device.Make,
+		Model: req.Device.Model,
+		OS:    req.Device.OS,
+		OSV:   req.Device.OSV,
+		Type:  req.Device.DeviceType,
+	}
+
+	if d.IP == "" {
+		d.IP = req.Device.IPv6
+	}
+
+	return d
+}
+
+// geo определяем по ip, так значения совпадут с таргетингами.
+// Если по ip ничего не нашли, то берем из device или user
+func (rtb *Rtb) geo(req *openrtb.BidRequest, ip string) *plugins.Geo {
+	if location, ok := rtb.geoip.Lookup(ip); ok {
+		return &plugins.Geo{
+			Country: location.Country,
+			Region:  location.Region,
+			City:    location.City,
+		}
+	}
+
+	var g *openrtb.Geo
+
+	switch {
+	case req.Device != nil && req.Device.Geo != nil:
+		g = req.Device.Geo
+	case req.User != nil && req.User.Geo != nil:
+		g = req.User.Geo
+	default:
+		return &plugins.Geo{}
+	}
+
+	return &plugins.Geo{
+		Country: openrtb.CountryAlpha2(g.Country),
+		Region:  g.Region,
+		City:    g.City,
+	}
+}
+
+func app(req *openrtb.BidRequest) *plugins.App {
+	a := &plugins.App{}
+
+	switch {
+	case req.App != nil:
+		a.ID = req.App.ID
+		a.Bundle = req.App.Bundle
+		a.Domain = req.App.Domain
+		a.Cat = req.App.Cat
+
+		if req.App.Publisher != nil {
+			a.Publisher = req.App.Publisher.ID
+		}
+	case req.Site != nil:
+		a.ID = req.Site.ID
+		a.Bundle = req.Site.Domain
+		a.Domain = req.Site.Domain
+		a.Cat = req.Site.Cat
+
+		if req.Site.Publisher != nil {
+			a.Publisher = req.Site.Publisher.ID
+		}
+	}
+
+	return a
+}
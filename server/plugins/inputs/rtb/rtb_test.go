@@ -0,0 +1,260 @@
+package rtb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/pkg/geoip"
+	"go.ads.coffee/platform/server/internal/domain/openrtb"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+// MockAnalytics is a mock implementation of the Analytics interface
+type MockAnalytics struct {
+	mock.Mock
+}
+
+func (m *MockAnalytics) LogRequest(ctx context.Context, state *plugins.State) error {
+	args := m.Called(ctx, state)
+	return args.Error(0)
+}
+
+// MockGeoip is a mock implementation of the Geoip interface
+type MockGeoip struct {
+	mock.Mock
+}
+
+func (m *MockGeoip) Lookup(ip string) (geoip.Location, bool) {
+	args := m.Called(ip)
+	return args.Get(0).(geoip.Location), args.Bool(1)
+}
+
+const request = `{
+	"id": "req-1",
+	"imp": [{
+		"id": "1",
+		"tagid": "placement-1",
+		"bidfloor": 1.5,
+		"banner": {"w": 320, "h": 50},
+		"native": {"request": "{\"ver\":\"1.2\"}", "ver": "1.2"}
+	}],
+	"app": {"id": "app-1", "bundle": "com.example.app", "publisher": {"id": "pub-1"}},
+	"device": {"ua": "Mozilla/5.0", "ipv6": "2001:db8::1", "ifa": "ifa-1", "make": "Apple", "os": "iOS", "devicetype": 4, "geo": {"country": "RUS", "region": "MOW", "city": "Moscow"}},
+	"user": {"buyeruid": "buyer-1", "ext": {"consent": "consent-string", "apps": ["com.installed.app"]}},
+	"regs": {"ext": {"gdpr": 1}},
+	"tmax": 120,
+	"bcat": ["IAB25"],
+	"badv": ["example.com"],
+	"bapp": ["com.blocked.app"]
+}`
+
+func newRequest(method, body, version string) *http.Request {
+	req := httptest.NewRequest(method, "/dsp", strings.NewReader(body))
+	if version != "" {
+		req.Header.Set(openrtb.HeaderVersion, version)
+	}
+
+	return req
+}
+
+func TestRtb_Name(t *testing.T) {
+	rtb := &Rtb{}
+
+	assert.Equal(t, "inputs.rtb", rtb.Name())
+}
+
+func TestRtb_Copy(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	logger := zap.NewNop()
+
+	rtb := &Rtb{
+		logger:    logger,
+		analytics: mockAnalytics,
+	}
+
+	copied := rtb.Copy(map[string]any{"network": "exchange"})
+
+	assert.IsType(t, &Rtb{}, copied)
+	assert.Equal(t, mockAnalytics, copied.(*Rtb).analytics)
+	assert.Equal(t, logger, copied.(*Rtb).logger)
+	assert.Equal(t, "exchange", copied.(*Rtb).network)
+	assert.Equal(t, defaultMargin, copied.(*Rtb).margin)
+
+	copied = rtb.Copy(map[string]any{"tmax_margin": "25ms"})
+	assert.Equal(t, 25*time.Millisecond, copied.(*Rtb).margin)
+}
+
+func TestRtb_Do(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogRequest", mock.Anything, mock.Anything).Return(nil)
+
+	mockGeoip := new(MockGeoip)
+	mockGeoip.On("Lookup", "2001:db8::1").Return(geoip.Location{}, false)
+
+	rtb := &Rtb{
+		logger:    zap.NewNop(),
+		analytics: mockAnalytics,
+		geoip:     mockGeoip,
+		margin:    10 * time.Millisecond,
+	}
+
+	w := httptest.NewRecorder()
+	state := &plugins.State{
+		Request:  newRequest(http.MethodPost, request, "2.6"),
+		Response: w,
+	}
+
+	start := time.Now()
+	ok := rtb.Do(context.Background(), state)
+
+	assert.True(t, ok)
+	assert.NotNil(t, state.BidRequest)
+	assert.Equal(t, int64(120), state.BidRequest.Tmax)
+
+	// tmax 120ms минус запас на ответ
+	assert.WithinRange(t, state.Deadline, start.Add(110*time.Millisecond), time.Now().Add(110*time.Millisecond))
+
+	// 2.5 ext поля переносятся в поля 2.6
+	assert.Equal(t, int8(1), *state.BidRequest.Regs.GDPR)
+	assert.Equal(t, "consent-string", state.User.Consent)
+
+	assert.Equal(t, "buyer-1", state.User.ID)
+	assert.Equal(t, []string{"com.installed.app"}, state.User.Apps)
+	assert.Equal(t, "2001:db8::1", state.Device.IP)
+	assert.Equal(t, "ifa-1", state.Device.IFA)
+	assert.Equal(t, int64(4), state.Device.Type)
+
+	// по ip ничего не нашли, берем geo из запроса
+	assert.Equal(t, &plugins.Geo{Country: "RU", Region: "MOW", City: "Moscow"}, state.Geo)
+
+	assert.Equal(t, "com.example.app", state.App.Bundle)
+	assert.Equal(t, "pub-1", state.App.Publisher)
+
+	assert.Equal(t, []string{"IAB25"}, state.Restrictions.Bcat)
+	assert.Equal(t, []string{"example.com"}, state.Restrictions.Badv)
+	assert.Equal(t, []string{"com.blocked.app"}, state.Restrictions.Bapp)
+
+	assert.Equal(t, "placement-1", state.Placement.ID)
+	assert.Equal(t, "1", state.Placement.ImpID)
+	assert.Equal(t, []string{"banner", "native"}, state.Placement.Formats)
+	assert.Equal(t, 1.5, state.Placement.BidFloor)
+
+	mockAnalytics.AssertCalled(t, "LogRequest", mock.Anything, state)
+}
+
+func TestRtb_Do_GeoByIP(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogRequest", mock.Anything, mock.Anything).Return(nil)
+
+	mockGeoip := new(MockGeoip)
+	mockGeoip.On("Lookup", "2001:db8::1").Return(geoip.Location{Country: "KZ", Region: "Almaty", City: "Almaty"}, true)
+
+	rtb := &Rtb{
+		logger:    zap.NewNop(),
+		analytics: mockAnalytics,
+		geoip:     mockGeoip,
+	}
+
+	state := &plugins.State{
+		Request:  newRequest(http.MethodPost, request, ""),
+		Response: httptest.NewRecorder(),
+	}
+
+	ok := rtb.Do(context.Background(), state)
+
+	assert.True(t, ok)
+	assert.Equal(t, &plugins.Geo{Country: "KZ", Region: "Almaty", City: "Almaty"}, state.Geo)
+}
+
+func TestRtb_Do_NoBid(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		version string
+		nbr     string
+	}{
+		{
+			name:   "wrong method",
+			method: http.MethodGet,
+			body:   request,
+			nbr:    "2",
+		},
+		{
+			name:   "malformed json",
+			method: http.MethodPost,
+			body:   `{"id":`,
+			nbr:    "2",
+		},
+		{
+			name:    "unsupported version",
+			method:  http.MethodPost,
+			body:    request,
+			version: "3.0",
+			nbr:     "2",
+		},
+		{
+			name:   "empty id",
+			method: http.MethodPost,
+			body:   `{"imp":[{"id":"1","banner":{}}]}`,
+			nbr:    "2",
+		},
+		{
+			name:   "no imps",
+			method: http.MethodPost,
+			body:   `{"id":"1","imp":[]}`,
+			nbr:    "2",
+		},
+		{
+			name:   "imp without formats",
+			method: http.MethodPost,
+			body:   `{"id":"1","imp":[{"id":"1"}]}`,
+			nbr:    "2",
+		},
+		{
+			name:   "duplicated imp",
+			method: http.MethodPost,
+			body:   `{"id":"1","imp":[{"id":"1","banner":{}},{"id":"1","banner":{}}]}`,
+			nbr:    "2",
+		},
+		{
+			name:   "site and app",
+			method: http.MethodPost,
+			body:   `{"id":"1","imp":[{"id":"1","banner":{}}],"site":{},"app":{}}`,
+			nbr:    "2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAnalytics := new(MockAnalytics)
+
+			rtb := &Rtb{
+				logger:    zap.NewNop(),
+				analytics: mockAnalytics,
+			}
+
+			w := httptest.NewRecorder()
+			state := &plugins.State{
+				Request:  newRequest(tt.method, tt.body, tt.version),
+				Response: w,
+			}
+
+			ok := rtb.Do(context.Background(), state)
+
+			assert.False(t, ok)
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Equal(t, tt.nbr, w.Header().Get(openrtb.HeaderNbr))
+			assert.Empty(t, w.Body.String())
+			mockAnalytics.AssertNotCalled(t, "LogRequest", mock.Anything, mock.Anything)
+		})
+	}
+}
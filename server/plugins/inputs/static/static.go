@@ -8,16 +8,21 @@ import (
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 
+	"go.ads.coffee/platform/pkg/geoip"
 	"go.ads.coffee/platform/server/internal/analytics"
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 	"go.ads.coffee/platform/server/internal/repos/banners"
+	"go.ads.coffee/platform/server/internal/repos/placements"
 	"go.ads.coffee/platform/server/internal/sessions"
+	"go.ads.coffee/platform/server/internal/tools/network"
+	"go.ads.coffee/platform/server/internal/tracking"
 )
 
 const (
 	actionClick = "click"
 	actionKey   = "action"
+	networkKey  = "network"
 )
 
 var Module = fx.Module(
@@ -44,11 +49,22 @@ type Session interface {
 	LoadWithExpire(r *http.Request) (sessions.Session, bool)
 }
 
+type Geoip interface {
+	Lookup(ip string) (geoip.Location, bool)
+}
+
+type Placements interface {
+	One(ctx context.Context, id string) (ads.Placement, bool)
+}
+
 type Static struct {
-	logger    *zap.Logger
-	cache     Cache
-	sessions  Session
-	analytics Analytics
+	network    string
+	logger     *zap.Logger
+	cache      Cache
+	sessions   Session
+	analytics  Analytics
+	geoip      Geoip
+	placements Placements
 }
 
 func New(
@@ -56,12 +72,16 @@ func New(
 	cache *banners.Cache,
 	sessions *sessions.Sessions,
 	analytics *analytics.Analytics,
+	geoip *geoip.Geoip,
+	placements *placements.Cache,
 ) *Static {
 	return &Static{
-		logger:    logger,
-		cache:     cache,
-		sessions:  sessions,
-		analytics: analytics,
+		logger:     logger,
+		cache:      cache,
+		sessions:   sessions,
+		analytics:  analytics,
+		geoip:      geoip,
+		placements: placements,
 	}
 }
 
@@ -70,11 +90,22 @@ func (s *Static) Name() string {
 }
 
 func (s *Static) Copy(cfg map[string]any) plugins.Input {
+	network, _ := cfg[networkKey].(string)
+
 	return &Static{
-		cache:     s.cache,
-		logger:    s.logger,
-		sessions:  s.sessions,
-		analytics: s.analytics,
+		network:    network,
+		cache:      s.cache,
+		logger:     s.logger,
+		sessions:   s.sessions,
+		analytics:  s.analytics,
+		geoip:      s.geoip,
+		placements: s.placements,
+	}
+}
+
+func (s *Static) Schema() plugins.Schema {
+	return plugins.Schema{
+		{Name: networkKey, Type: plugins.TypeString},
 	}
 }
 
@@ -84,25 +115,30 @@ func (s *Static) Do(ctx context.Context, state *plugins.State) bool {
 
 	// нужно получить данные пользователя из запроса
 
+	state.Network = s.network
 	state.User = &plugins.User{}
-	state.Device = &plugins.Device{}
-
-	// проверить наличие placement
-
-	placement := chi.URLParam(state.Request, "placement")
-
-	state.Placement = &plugins.Placement{
-		ID: placement,
-		Units: []ads.Unit{
-			{
-				ID:      "yandex-1",
-				Network: "yandex",
-				Price:   10,
-				Format:  "banner",
-			},
-		},
+	state.Device = &plugins.Device{
+		UA: state.Request.UserAgent(),
+		IP: network.ClientIP(state.Request),
+	}
+
+	location, _ := s.geoip.Lookup(state.Device.IP)
+	state.Geo = &plugins.Geo{
+		Country: location.Country,
+		Region:  location.Region,
+		City:    location.City,
+	}
+
+	// неизвестный плейсмент - пустой ответ без рекламы
+	placement, ok := s.placements.One(ctx, chi.URLParam(state.Request, "placement"))
+	if !ok {
+		state.Response.WriteHeader(http.StatusNoContent)
+
+		return false
 	}
 
+	state.Placement = plugins.NewPlacement(placement)
+
 	// проверяем есть ли в сессии баннер для экшена click
 	// если баннер в сессии, то редиректим на трекер url
 	if action == actionClick {
@@ -124,10 +160,17 @@ func (s *Static) Do(ctx context.Context, state *plugins.State) bool {
 			return false
 		}
 
+		// клик продолжает цепочку событий показа
+		if session.ClickID != "" {
+			state.ClickID = session.ClickID
+		}
+
+		info := state.TrackerInfo(banner)
+
 		// check err
-		_ = s.analytics.LogClick(ctx, ads.TrackerInfo{})
+		_ = s.analytics.LogClick(ctx, info)
 
-		http.Redirect(state.Response, state.Request, banner.Target, http.StatusSeeOther)
+		http.Redirect(state.Response, state.Request, tracking.Target(banner.Target, info.ClickID), http.StatusSeeOther)
 
 		return false
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap/zaptest"
 
+	"go.ads.coffee/platform/pkg/geoip"
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 	"go.ads.coffee/platform/server/internal/sessions"
@@ -48,6 +49,52 @@ func (m *MockAnalytics) LogClick(ctx context.Context, data ads.TrackerInfo) erro
 	return args.Error(0)
 }
 
+// MockGeoip is a mock implementation of the Geoip interface
+type MockGeoip struct {
+	mock.Mock
+}
+
+func (m *MockGeoip) Lookup(ip string) (geoip.Location, bool) {
+	args := m.Called(ip)
+	return args.Get(0).(geoip.Location), args.Bool(1)
+}
+
+func newMockGeoip() *MockGeoip {
+	m := &MockGeoip{}
+	m.On("Lookup", mock.Anything).Return(geoip.Location{}, false)
+
+	return m
+}
+
+// MockPlacements is a mock implementation of the Placements interface
+type MockPlacements struct {
+	mock.Mock
+}
+
+func (m *MockPlacements) One(ctx context.Context, id string) (ads.Placement, bool) {
+	args := m.Called(ctx, id)
+	placement, _ := args.Get(0).(ads.Placement)
+	return placement, args.Bool(1)
+}
+
+func newMockPlacements() *MockPlacements {
+	m := &MockPlacements{}
+	m.On("One", mock.Anything, "test-placement").Return(ads.Placement{
+		ID: "test-placement",
+		Units: []ads.Unit{
+			{
+				ID:      "yandex-1",
+				Network: "yandex",
+				Price:   10,
+				Format:  "banner",
+			},
+		},
+	}, true)
+	m.On("One", mock.Anything, mock.Anything).Return(ads.Placement{}, false)
+
+	return m
+}
+
 func TestNew(t *testing.T) {
 	// Create real dependencies for constructor
 	logger := zaptest.NewLogger(t)
@@ -137,10 +184,12 @@ func TestStatic_Do_ViewAction(t *testing.T) {
 
 	// Create an instance of Static
 	static := &Static{
-		logger:    logger,
-		cache:     cache,
-		sessions:  session,
-		analytics: analytics,
+		logger:     logger,
+		cache:      cache,
+		sessions:   session,
+		analytics:  analytics,
+		geoip:      newMockGeoip(),
+		placements: newMockPlacements(),
 	}
 
 	// Prepare context and state
@@ -195,10 +244,12 @@ func TestStatic_Do_ClickAction_SessionNotFound(t *testing.T) {
 
 	// Create an instance of Static
 	static := &Static{
-		logger:    logger,
-		cache:     cache,
-		sessions:  session,
-		analytics: analytics,
+		logger:     logger,
+		cache:      cache,
+		sessions:   session,
+		analytics:  analytics,
+		geoip:      newMockGeoip(),
+		placements: newMockPlacements(),
 	}
 
 	// Prepare context and state
@@ -249,10 +300,12 @@ func TestStatic_Do_ClickAction_BannerNotFound(t *testing.T) {
 
 	// Create an instance of Static
 	static := &Static{
-		logger:    logger,
-		cache:     cache,
-		sessions:  session,
-		analytics: analytics,
+		logger:     logger,
+		cache:      cache,
+		sessions:   session,
+		analytics:  analytics,
+		geoip:      newMockGeoip(),
+		placements: newMockPlacements(),
 	}
 
 	// Prepare context and state
@@ -305,10 +358,12 @@ func TestStatic_Do_ClickAction_Success(t *testing.T) {
 
 	// Create an instance of Static
 	static := &Static{
-		logger:    logger,
-		cache:     cache,
-		sessions:  session,
-		analytics: analytics,
+		logger:     logger,
+		cache:      cache,
+		sessions:   session,
+		analytics:  analytics,
+		geoip:      newMockGeoip(),
+		placements: newMockPlacements(),
 	}
 
 	// Prepare context and state
@@ -334,13 +389,15 @@ func TestStatic_Do_ClickAction_Success(t *testing.T) {
 
 	// Set up mock expectations
 	reqForMock := req.Clone(ctx)
-	sess := sessions.Session{Value: "banner-id"}
-	banner := ads.Banner{Target: "https://example.com/target"}
+	sess := sessions.Session{Value: "banner-id", ClickID: "click-1"}
+	banner := ads.Banner{Target: "https://example.com/target?cid={click_id}"}
 	session.On("LoadWithExpire", mock.MatchedBy(func(r *http.Request) bool {
 		return r.URL == reqForMock.URL
 	})).Return(sess, true)
 	cache.On("One", ctx, "banner-id").Return(banner, true)
-	analytics.On("LogClick", ctx, ads.TrackerInfo{}).Return(nil)
+	analytics.On("LogClick", ctx, mock.MatchedBy(func(info ads.TrackerInfo) bool {
+		return info.ClickID == "click-1"
+	})).Return(nil)
 
 	// Call the function under test
 	result := static.Do(ctx, state)
@@ -349,10 +406,45 @@ func TestStatic_Do_ClickAction_Success(t *testing.T) {
 	assert.False(t, result)
 	// Check that it's a redirect response
 	assert.Equal(t, http.StatusSeeOther, rr.Code)
-	assert.Equal(t, "https://example.com/target", rr.Header().Get("Location"))
+	assert.Equal(t, "https://example.com/target?cid=click-1", rr.Header().Get("Location"))
 
 	// Verify mock expectations
 	session.AssertExpectations(t)
 	cache.AssertExpectations(t)
 	analytics.AssertExpectations(t)
 }
+
+func TestStatic_Do_UnknownPlacement(t *testing.T) {
+	static := &Static{
+		logger:     zaptest.NewLogger(t),
+		cache:      &MockCache{},
+		sessions:   &MockSession{},
+		analytics:  &MockAnalytics{},
+		geoip:      newMockGeoip(),
+		placements: newMockPlacements(),
+	}
+
+	ctx := context.Background()
+
+	rctx := &chi.Context{
+		URLParams: chi.RouteParams{
+			Keys:   []string{"action", "placement"},
+			Values: []string{"view", "unknown"},
+		},
+	}
+	req := &http.Request{}
+	req = req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+
+	state := &plugins.State{
+		Request:  req,
+		Response: rr,
+	}
+
+	result := static.Do(ctx, state)
+
+	assert.False(t, result)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Nil(t, state.Placement)
+}
@@ -2,10 +2,19 @@ package tracker
 
 import (
 	"context"
+	"net/http"
+	"net/url"
+	"time"
 
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 
+	"go.ads.coffee/platform/server/internal/analytics"
+	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/rewards"
+	"go.ads.coffee/platform/server/internal/tools/network"
+	"go.ads.coffee/platform/server/internal/tracking"
 )
 
 var Module = fx.Module(
@@ -20,11 +29,59 @@ var Module = fx.Module(
 	),
 )
 
+type Analytics interface {
+	LogWin(ctx context.Context, data ads.TrackerInfo) error
+	LogBilling(ctx context.Context, data ads.TrackerInfo) error
+	LogLoss(ctx context.Context, data ads.TrackerInfo) error
+	LogImpression(ctx context.Context, data ads.TrackerInfo) error
+	LogClick(ctx context.Context, data ads.TrackerInfo) error
+	LogVideo(ctx context.Context, action string, data ads.TrackerInfo) error
+}
+
+type Signer interface {
+	Verify(values url.Values, unsigned ...string) error
+}
+
+type Dedup interface {
+	First(ctx context.Context, action, requestID, bannerID string) (bool, error)
+}
+
+type Fanout interface {
+	Fire(ctx context.Context, action string, info ads.TrackerInfo)
+}
+
+type Rewards interface {
+	Grant(ctx context.Context, info ads.TrackerInfo)
+}
+
 type Tracker struct {
+	fanout    bool
+	logger    *zap.Logger
+	analytics Analytics
+	signer    Signer
+	dedup     Dedup
+	trackers  Fanout
+	rewards   Rewards
 }
 
-func New() *Tracker {
-	return &Tracker{}
+func New(
+	logger *zap.Logger,
+	analytics *analytics.Analytics,
+	signer *tracking.Signer,
+	dedup *tracking.Dedup,
+	trackers *tracking.Fanout,
+	rewards *rewards.Rewards,
+	cfg tracking.Config,
+) *Tracker {
+	return &Tracker{
+		fanout:    cfg.Fanout,
+		logger:    logger,
+		analytics: analytics,
+		signer:    signer,
+		dedup:     dedup,
+		trackers:  trackers,
+		rewards:   rewards,
+	}
 }
 
 func (s *Tracker) Name() string {
@@ -32,14 +89,158 @@ func (s *Tracker) Name() string {
 }
 
 func (s *Tracker) Copy(cfg map[string]any) plugins.Input {
-	return &Tracker{}
+	return &Tracker{
+		fanout:    s.fanout,
+		logger:    s.logger,
+		analytics: s.analytics,
+		signer:    s.signer,
+		dedup:     s.dedup,
+		trackers:  s.trackers,
+		rewards:   s.rewards,
+	}
 }
 
-func (stages *Tracker) Do(ctx context.Context, state *plugins.State) bool {
-	// нужно получить данные пользователя из запроса
+func (s *Tracker) Schema() plugins.Schema {
+	return plugins.Schema{}
+}
 
+func (s *Tracker) Do(ctx context.Context, state *plugins.State) bool {
 	state.User = &plugins.User{}
-	state.Device = &plugins.Device{}
+	state.Device = &plugins.Device{
+		UA: state.Request.UserAgent(),
+		IP: network.ClientIP(state.Request),
+	}
+
+	query := state.Request.URL.Query()
+
+	action, info, err := tracking.Parse(query)
+	if err != nil {
+		s.logger.Warn("invalid tracker request", zap.Error(err))
+
+		state.Response.WriteHeader(http.StatusBadRequest)
+
+		return false
+	}
+
+	info.Timestamp = time.Now().Unix()
+
+	switch action {
+	// показы и клики из наших подписанных ссылок
+	case ads.ActionImpression, ads.ActionCLick:
+		if !s.verify(state, action, query) {
+			return false
+		}
+
+		if !s.first(ctx, action, info) {
+			return true
+		}
+
+		if action == ads.ActionImpression {
+			err = s.analytics.LogImpression(ctx, info)
+		} else {
+			err = s.analytics.LogClick(ctx, info)
+		}
+
+		if s.fanout {
+			s.trackers.Fire(ctx, action, info)
+		}
+
+	// события видеоплеера из наших подписанных ссылок в VAST
+	case ads.ActionStart, ads.ActionFirstQuartile, ads.ActionMidpoint,
+		ads.ActionThirdQuartile, ads.ActionComplete, ads.ActionSkip:
+		if !s.verify(state, action, query) {
+			return false
+		}
+
+		if !s.first(ctx, action, info) {
+			return true
+		}
+
+		err = s.analytics.LogVideo(ctx, action, info)
+
+		// досмотр в наградном плейсменте дает награду, дедупликация
+		// выше гарантирует одну награду на показ
+		if action == ads.ActionComplete {
+			s.rewards.Grant(ctx, info)
+		}
+
+	// код ошибки плеер подставляет в макрос,
+	// он в подпись не входит
+	case ads.ActionError:
+		if !s.verify(state, action, query, tracking.ParamReason) {
+			return false
+		}
+
+		err = s.analytics.LogVideo(ctx, action, info)
+
+	// нотификации от rtb бирж. Цену и причину проигрыша биржа
+	// подставляет в макросы, они в подпись не входят
+	case ads.ActionWin, ads.ActionBilling:
+		if !s.verify(state, action, query, tracking.NoticeParams...) {
+			return false
+		}
+
+		if !s.first(ctx, action, info) {
+			return true
+		}
+
+		if action == ads.ActionWin {
+			err = s.analytics.LogWin(ctx, info)
+		} else {
+			err = s.analytics.LogBilling(ctx, info)
+		}
+
+	case ads.ActionLoose:
+		if !s.verify(state, action, query, tracking.NoticeParams...) {
+			return false
+		}
+
+		err = s.analytics.LogLoss(ctx, info)
+	default:
+		s.logger.Warn("unknown tracker action", zap.String("action", action))
+
+		state.Response.WriteHeader(http.StatusBadRequest)
+
+		return false
+	}
+
+	if err != nil {
+		s.logger.Error("error on log tracker action", zap.String("action", action), zap.Error(err))
+	}
 
 	return true
 }
+
+// verify проверяет подпись ссылки, на поддельную отвечаем 403
+func (s *Tracker) verify(state *plugins.State, action string, query url.Values, unsigned ...string) bool {
+	if err := s.signer.Verify(query, unsigned...); err != nil {
+		s.logger.Warn("invalid tracker signature", zap.String("action", action), zap.Error(err))
+
+		state.Response.WriteHeader(http.StatusForbidden)
+
+		return false
+	}
+
+	return true
+}
+
+// first проверяет, что событие пришло в первый раз.
+// Если redis недоступен, событие учитывается.
+func (s *Tracker) first(ctx context.Context, action string, info ads.TrackerInfo) bool {
+	first, err := s.dedup.First(ctx, action, info.RequestID, info.BannerID)
+	if err != nil {
+		s.logger.Warn("error on dedup tracker action", zap.String("action", action), zap.Error(err))
+
+		return true
+	}
+
+	if !first {
+		s.logger.Debug("duplicate tracker action",
+			zap.String("action", action),
+			zap.String("request", info.RequestID),
+			zap.String("banner", info.BannerID),
+		)
+	}
+
+	return first
+}
@@ -0,0 +1,529 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/tracking"
+)
+
+// MockAnalytics is a mock implementation of the Analytics interface
+type MockAnalytics struct {
+	mock.Mock
+}
+
+func (m *MockAnalytics) LogWin(ctx context.Context, data ads.TrackerInfo) error {
+	args := m.Called(ctx, data)
+	return args.Error(0)
+}
+
+func (m *MockAnalytics) LogBilling(ctx context.Context, data ads.TrackerInfo) error {
+	args := m.Called(ctx, data)
+	return args.Error(0)
+}
+
+func (m *MockAnalytics) LogLoss(ctx context.Context, data ads.TrackerInfo) error {
+	args := m.Called(ctx, data)
+	return args.Error(0)
+}
+
+func (m *MockAnalytics) LogImpression(ctx context.Context, data ads.TrackerInfo) error {
+	args := m.Called(ctx, data)
+	return args.Error(0)
+}
+
+func (m *MockAnalytics) LogClick(ctx context.Context, data ads.TrackerInfo) error {
+	args := m.Called(ctx, data)
+	return args.Error(0)
+}
+
+func (m *MockAnalytics) LogVideo(ctx context.Context, action string, data ads.TrackerInfo) error {
+	args := m.Called(ctx, action, data)
+	return args.Error(0)
+}
+
+// MockDedup is a mock implementation of the Dedup interface
+type MockDedup struct {
+	mock.Mock
+}
+
+func (m *MockDedup) First(ctx context.Context, action, requestID, bannerID string) (bool, error) {
+	args := m.Called(ctx, action, requestID, bannerID)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockFanout is a mock implementation of the Fanout interface
+type MockFanout struct {
+	mock.Mock
+}
+
+func (m *MockFanout) Fire(ctx context.Context, action string, info ads.TrackerInfo) {
+	m.Called(ctx, action, info)
+}
+
+// MockRewards is a mock implementation of the Rewards interface
+type MockRewards struct {
+	mock.Mock
+}
+
+func (m *MockRewards) Grant(ctx context.Context, info ads.TrackerInfo) {
+	m.Called(ctx, info)
+}
+
+func newSigner(t *testing.T, secret string) *tracking.Signer {
+	t.Helper()
+
+	signer, err := tracking.NewSigner(tracking.Config{Secret: secret})
+	require.NoError(t, err)
+
+	return signer
+}
+
+func signedQuery(t *testing.T, signer *tracking.Signer, action string, info ads.TrackerInfo) string {
+	link, err := signer.Sign(tracking.URL("http://ads.coffee/tracker", action, info))
+	require.NoError(t, err)
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+
+	return u.RawQuery
+}
+
+func TestTracker_Name(t *testing.T) {
+	assert.Equal(t, "inputs.tracker", (&Tracker{}).Name())
+}
+
+func TestTracker_Do_Notices(t *testing.T) {
+	signer := newSigner(t, "secret")
+	info := ads.TrackerInfo{RequestID: "request-1", BannerID: "banner-1"}
+
+	// price и reason биржа дописывает к подписанной ссылке
+	tests := []struct {
+		action string
+		method string
+		params string
+		price  float64
+		reason string
+	}{
+		{
+			action: ads.ActionWin,
+			method: "LogWin",
+			params: "&price=12.5",
+			price:  12.5,
+		},
+		{
+			action: ads.ActionBilling,
+			method: "LogBilling",
+			params: "&price=10",
+			price:  10,
+		},
+		{
+			action: ads.ActionLoose,
+			method: "LogLoss",
+			params: "&reason=102",
+			reason: "102",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			mockAnalytics := new(MockAnalytics)
+			mockAnalytics.On(tt.method, mock.Anything, mock.Anything).Return(nil)
+
+			mockDedup := new(MockDedup)
+			mockDedup.On("First", mock.Anything, tt.action, "request-1", "banner-1").Return(true, nil)
+
+			tracker := &Tracker{
+				logger:    zap.NewNop(),
+				analytics: mockAnalytics,
+				signer:    signer,
+				dedup:     mockDedup,
+			}
+
+			state := &plugins.State{
+				Request:  httptest.NewRequest(http.MethodGet, "/tracker?"+signedQuery(t, signer, tt.action, info)+tt.params, nil),
+				Response: httptest.NewRecorder(),
+			}
+
+			ok := tracker.Do(context.Background(), state)
+
+			assert.True(t, ok)
+			assert.NotNil(t, state.User)
+			assert.NotNil(t, state.Device)
+
+			mockAnalytics.AssertCalled(t, tt.method, mock.Anything, mock.MatchedBy(func(info ads.TrackerInfo) bool {
+				return info.BannerID == "banner-1" &&
+					info.RequestID == "request-1" &&
+					info.Price == tt.price &&
+					info.Reason == tt.reason &&
+					info.Timestamp > 0
+			}))
+		})
+	}
+}
+
+func TestTracker_Do_VideoError(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogVideo", mock.Anything, ads.ActionError, mock.Anything).Return(nil)
+
+	signer := newSigner(t, "secret")
+
+	tracker := &Tracker{
+		logger:    zap.NewNop(),
+		analytics: mockAnalytics,
+		signer:    signer,
+	}
+
+	// код ошибки плеер дописывает после подписи
+	query := signedQuery(t, signer, ads.ActionError, ads.TrackerInfo{RequestID: "request-1", BannerID: "banner-1"})
+
+	state := &plugins.State{
+		Request:  httptest.NewRequest(http.MethodGet, "/tracker?"+query+"&reason=402", nil),
+		Response: httptest.NewRecorder(),
+	}
+
+	assert.True(t, tracker.Do(context.Background(), state))
+
+	mockAnalytics.AssertCalled(t, "LogVideo", mock.Anything, ads.ActionError, mock.MatchedBy(func(info ads.TrackerInfo) bool {
+		return info.BannerID == "banner-1" && info.Reason == "402"
+	}))
+
+	// без подписи ошибку может записать кто угодно
+	recorder := httptest.NewRecorder()
+	state = &plugins.State{
+		Request:  httptest.NewRequest(http.MethodGet, "/tracker?action=error&bid=banner-1&rid=request-1&reason=402", nil),
+		Response: recorder,
+	}
+
+	assert.False(t, tracker.Do(context.Background(), state))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	mockAnalytics.AssertNumberOfCalls(t, "LogVideo", 1)
+}
+
+func TestTracker_Do_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "empty action", query: "bid=banner-1"},
+		{name: "unknown action", query: "action=unknown"},
+		{name: "unreplaced macro", query: "action=win&price=${AUCTION_PRICE}"},
+		{name: "negative price", query: "action=billing&price=-1e9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAnalytics := new(MockAnalytics)
+
+			tracker := &Tracker{
+				logger:    zap.NewNop(),
+				analytics: mockAnalytics,
+			}
+
+			w := httptest.NewRecorder()
+			state := &plugins.State{
+				Request:  httptest.NewRequest(http.MethodGet, "/tracker?"+tt.query, nil),
+				Response: w,
+			}
+
+			ok := tracker.Do(context.Background(), state)
+
+			assert.False(t, ok)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Empty(t, mockAnalytics.Calls)
+		})
+	}
+}
+
+func TestTracker_Do_Signed(t *testing.T) {
+	signer := newSigner(t, "secret")
+
+	info := ads.TrackerInfo{
+		RequestID:    "request-1",
+		ClickID:      "click-1",
+		BannerID:     "banner-1",
+		GroupID:      "group-1",
+		CampaignID:   "campaign-1",
+		AdvertiserID: "advertiser-1",
+		Price:        15,
+	}
+
+	tests := []struct {
+		action string
+		method string
+	}{
+		{action: ads.ActionImpression, method: "LogImpression"},
+		{action: ads.ActionCLick, method: "LogClick"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			mockAnalytics := new(MockAnalytics)
+			mockAnalytics.On(tt.method, mock.Anything, mock.Anything).Return(nil)
+
+			mockDedup := new(MockDedup)
+			mockDedup.On("First", mock.Anything, tt.action, "request-1", "banner-1").Return(true, nil)
+
+			tracker := (&Tracker{
+				logger:    zap.NewNop(),
+				analytics: mockAnalytics,
+				signer:    signer,
+				dedup:     mockDedup,
+			}).Copy(nil)
+
+			state := &plugins.State{
+				Request:  httptest.NewRequest(http.MethodGet, "/tracker?"+signedQuery(t, signer, tt.action, info), nil),
+				Response: httptest.NewRecorder(),
+			}
+
+			ok := tracker.Do(context.Background(), state)
+			assert.True(t, ok)
+
+			mockAnalytics.AssertCalled(t, tt.method, mock.Anything, mock.MatchedBy(func(data ads.TrackerInfo) bool {
+				data.Timestamp = 0
+				return data == info
+			}))
+		})
+	}
+}
+
+func TestTracker_Do_Video(t *testing.T) {
+	signer := newSigner(t, "secret")
+	info := ads.TrackerInfo{RequestID: "request-1", BannerID: "banner-1", PlacementID: "placement-1"}
+
+	actions := []string{
+		ads.ActionStart,
+		ads.ActionFirstQuartile,
+		ads.ActionMidpoint,
+		ads.ActionThirdQuartile,
+		ads.ActionComplete,
+		ads.ActionSkip,
+	}
+
+	for _, action := range actions {
+		t.Run(action, func(t *testing.T) {
+			mockAnalytics := new(MockAnalytics)
+			mockAnalytics.On("LogVideo", mock.Anything, action, mock.Anything).Return(nil)
+
+			mockDedup := new(MockDedup)
+			mockDedup.On("First", mock.Anything, action, "request-1", "banner-1").Return(true, nil)
+
+			mockRewards := new(MockRewards)
+			mockRewards.On("Grant", mock.Anything, mock.Anything).Return()
+
+			tracker := &Tracker{
+				logger:    zap.NewNop(),
+				analytics: mockAnalytics,
+				signer:    signer,
+				dedup:     mockDedup,
+				rewards:   mockRewards,
+			}
+
+			state := &plugins.State{
+				Request:  httptest.NewRequest(http.MethodGet, "/tracker?"+signedQuery(t, signer, action, info), nil),
+				Response: httptest.NewRecorder(),
+			}
+
+			assert.True(t, tracker.Do(context.Background(), state))
+			mockAnalytics.AssertNumberOfCalls(t, "LogVideo", 1)
+
+			// награду дает только досмотр
+			if action == ads.ActionComplete {
+				mockRewards.AssertCalled(t, "Grant", mock.Anything, mock.MatchedBy(func(got ads.TrackerInfo) bool {
+					return got.PlacementID == "placement-1" && got.RequestID == "request-1"
+				}))
+			} else {
+				mockRewards.AssertNotCalled(t, "Grant", mock.Anything, mock.Anything)
+			}
+		})
+	}
+
+	// без подписи события плеера не принимаем
+	mockAnalytics := new(MockAnalytics)
+
+	tracker := &Tracker{
+		logger:    zap.NewNop(),
+		analytics: mockAnalytics,
+		signer:    signer,
+	}
+
+	w := httptest.NewRecorder()
+	state := &plugins.State{
+		Request:  httptest.NewRequest(http.MethodGet, "/tracker?action=complete&bid=banner-1&rid=request-1", nil),
+		Response: w,
+	}
+
+	assert.False(t, tracker.Do(context.Background(), state))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, mockAnalytics.Calls)
+}
+
+func TestTracker_Do_Duplicate(t *testing.T) {
+	signer := newSigner(t, "secret")
+	info := ads.TrackerInfo{RequestID: "request-1", BannerID: "banner-1"}
+
+	mockAnalytics := new(MockAnalytics)
+
+	mockDedup := new(MockDedup)
+	mockDedup.On("First", mock.Anything, ads.ActionImpression, "request-1", "banner-1").Return(false, nil)
+
+	tracker := &Tracker{
+		logger:    zap.NewNop(),
+		analytics: mockAnalytics,
+		signer:    signer,
+		dedup:     mockDedup,
+	}
+
+	state := &plugins.State{
+		Request:  httptest.NewRequest(http.MethodGet, "/tracker?"+signedQuery(t, signer, ads.ActionImpression, info), nil),
+		Response: httptest.NewRecorder(),
+	}
+
+	ok := tracker.Do(context.Background(), state)
+
+	// пиксель отдаем, но показ не учитываем
+	assert.True(t, ok)
+	assert.Empty(t, mockAnalytics.Calls)
+}
+
+func TestTracker_Do_DuplicateNotice(t *testing.T) {
+	signer := newSigner(t, "secret")
+	info := ads.TrackerInfo{RequestID: "request-1", BannerID: "banner-1"}
+
+	mockAnalytics := new(MockAnalytics)
+
+	mockDedup := new(MockDedup)
+	mockDedup.On("First", mock.Anything, ads.ActionBilling, "request-1", "banner-1").Return(false, nil)
+
+	tracker := &Tracker{
+		logger:    zap.NewNop(),
+		analytics: mockAnalytics,
+		signer:    signer,
+		dedup:     mockDedup,
+	}
+
+	state := &plugins.State{
+		Request:  httptest.NewRequest(http.MethodGet, "/tracker?"+signedQuery(t, signer, ads.ActionBilling, info)+"&price=10", nil),
+		Response: httptest.NewRecorder(),
+	}
+
+	// повторный burl не списывает бюджет второй раз
+	assert.True(t, tracker.Do(context.Background(), state))
+	assert.Empty(t, mockAnalytics.Calls)
+}
+
+func TestTracker_Do_DedupError(t *testing.T) {
+	signer := newSigner(t, "secret")
+	info := ads.TrackerInfo{RequestID: "request-1", BannerID: "banner-1"}
+
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogImpression", mock.Anything, mock.Anything).Return(nil)
+
+	mockDedup := new(MockDedup)
+	mockDedup.On("First", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(false, errors.New("redis is down"))
+
+	tracker := &Tracker{
+		logger:    zap.NewNop(),
+		analytics: mockAnalytics,
+		signer:    signer,
+		dedup:     mockDedup,
+	}
+
+	state := &plugins.State{
+		Request:  httptest.NewRequest(http.MethodGet, "/tracker?"+signedQuery(t, signer, ads.ActionImpression, info), nil),
+		Response: httptest.NewRecorder(),
+	}
+
+	assert.True(t, tracker.Do(context.Background(), state))
+	mockAnalytics.AssertCalled(t, "LogImpression", mock.Anything, mock.Anything)
+}
+
+func TestTracker_Do_InvalidSignature(t *testing.T) {
+	signer := newSigner(t, "secret")
+	query := signedQuery(t, signer, ads.ActionImpression, ads.TrackerInfo{BannerID: "banner-1", Price: 1})
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "unsigned", query: "action=impression&bid=banner-1"},
+		{name: "forged price", query: query + "&price=1000"},
+		{name: "other secret", query: signedQuery(t, newSigner(t, "other"), ads.ActionCLick, ads.TrackerInfo{})},
+		{name: "unsigned notice", query: "action=billing&bid=banner-1&rid=request-1&price=10"},
+		{name: "forged notice", query: strings.Replace(signedQuery(t, signer, ads.ActionBilling, ads.TrackerInfo{BannerID: "banner-1"}), "banner-1", "banner-2", 1) + "&price=10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAnalytics := new(MockAnalytics)
+
+			tracker := &Tracker{
+				logger:    zap.NewNop(),
+				analytics: mockAnalytics,
+				signer:    signer,
+				dedup:     new(MockDedup),
+			}
+
+			w := httptest.NewRecorder()
+			state := &plugins.State{
+				Request:  httptest.NewRequest(http.MethodGet, "/tracker?"+tt.query, nil),
+				Response: w,
+			}
+
+			ok := tracker.Do(context.Background(), state)
+
+			assert.False(t, ok)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Empty(t, mockAnalytics.Calls)
+		})
+	}
+}
+
+func TestTracker_Do_Fanout(t *testing.T) {
+	signer := newSigner(t, "secret")
+	info := ads.TrackerInfo{RequestID: "request-1", BannerID: "banner-1"}
+
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogClick", mock.Anything, mock.Anything).Return(nil)
+
+	mockDedup := new(MockDedup)
+	mockDedup.On("First", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+
+	mockFanout := new(MockFanout)
+	mockFanout.On("Fire", mock.Anything, ads.ActionCLick, mock.Anything).Return()
+
+	tracker := (&Tracker{
+		fanout:    true,
+		logger:    zap.NewNop(),
+		analytics: mockAnalytics,
+		signer:    signer,
+		dedup:     mockDedup,
+		trackers:  mockFanout,
+	}).Copy(map[string]any{})
+
+	req := httptest.NewRequest(http.MethodGet, "/tracker?"+signedQuery(t, signer, ads.ActionCLick, info), nil)
+	req.Header.Set("User-Agent", "test-agent")
+
+	state := &plugins.State{
+		Request:  req,
+		Response: httptest.NewRecorder(),
+	}
+
+	assert.True(t, tracker.Do(context.Background(), state))
+	assert.Equal(t, "test-agent", state.Device.UA)
+	assert.Equal(t, "192.0.2.1", state.Device.IP)
+
+	mockFanout.AssertCalled(t, "Fire", mock.Anything, ads.ActionCLick, mock.Anything)
+}
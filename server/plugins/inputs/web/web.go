@@ -2,13 +2,29 @@ package web
 
 import (
 	"context"
+	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/fx"
 
+	"go.ads.coffee/platform/pkg/geoip"
 	"go.ads.coffee/platform/server/internal/analytics"
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/repos/placements"
+	"go.ads.coffee/platform/server/internal/tools/network"
+)
+
+const (
+	networkKey = "network"
+
+	// идентификатор пользователя у паблишера, уходит
+	// в колбэк наградной рекламы
+	userParam = "user_id"
+
+	// установленные приложения через запятую
+	appsParam = "apps"
 )
 
 var Module = fx.Module(
@@ -27,13 +43,26 @@ type Analytics interface {
 	LogRequest(ctx context.Context, state *plugins.State) error
 }
 
+type Geoip interface {
+	Lookup(ip string) (geoip.Location, bool)
+}
+
+type Placements interface {
+	One(ctx context.Context, id string) (ads.Placement, bool)
+}
+
 type Web struct {
-	analytics Analytics
+	network    string
+	analytics  Analytics
+	geoip      Geoip
+	placements Placements
 }
 
-func New(analytics *analytics.Analytics) *Web {
+func New(analytics *analytics.Analytics, geoip *geoip.Geoip, placements *placements.Cache) *Web {
 	return &Web{
-		analytics: analytics,
+		analytics:  analytics,
+		geoip:      geoip,
+		placements: placements,
 	}
 }
 
@@ -42,35 +71,72 @@ func (s *Web) Name() string {
 }
 
 func (s *Web) Copy(cfg map[string]any) plugins.Input {
+	network, _ := cfg[networkKey].(string)
+
 	return &Web{
-		analytics: s.analytics,
+		network:    network,
+		analytics:  s.analytics,
+		geoip:      s.geoip,
+		placements: s.placements,
+	}
+}
+
+func (s *Web) Schema() plugins.Schema {
+	return plugins.Schema{
+		{Name: networkKey, Type: plugins.TypeString},
 	}
 }
 
 func (s *Web) Do(ctx context.Context, state *plugins.State) bool {
 	// нужно получить данные пользователя из запроса
 
-	state.User = &plugins.User{}
-	state.Device = &plugins.Device{}
+	query := state.Request.URL.Query()
 
-	// проверить наличие placement
+	state.Network = s.network
+	state.User = &plugins.User{
+		ID:   query.Get(userParam),
+		Apps: installed(query.Get(appsParam)),
+	}
+	state.Device = &plugins.Device{
+		UA: state.Request.UserAgent(),
+		IP: network.ClientIP(state.Request),
+	}
+
+	location, _ := s.geoip.Lookup(state.Device.IP)
+	state.Geo = &plugins.Geo{
+		Country: location.Country,
+		Region:  location.Region,
+		City:    location.City,
+	}
 
-	placement := chi.URLParam(state.Request, "placement")
+	// неизвестный плейсмент - пустой ответ без рекламы
+	placement, ok := s.placements.One(ctx, chi.URLParam(state.Request, "placement"))
+	if !ok {
+		state.Response.WriteHeader(http.StatusNoContent)
 
-	state.Placement = &plugins.Placement{
-		ID: placement,
-		Units: []ads.Unit{
-			{
-				ID:      "yandex-1",
-				Network: "yandex",
-				Price:   10,
-				Format:  "banner",
-			},
-		},
+		return false
 	}
 
+	state.Placement = plugins.NewPlacement(placement)
+
 	// check error
 	_ = s.analytics.LogRequest(ctx, state)
 
 	return true
 }
+
+// installed - список приложений из параметра запроса
+func installed(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	aa := []string{}
+	for _, a := range strings.Split(value, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			aa = append(aa, a)
+		}
+	}
+
+	return aa
+}
@@ -3,13 +3,19 @@ package web
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.ads.coffee/platform/pkg/geoip"
 	"go.ads.coffee/platform/server/internal/analytics"
+	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/repos/placements"
+	"go.ads.coffee/platform/server/plugins/targetings/apps"
 )
 
 // MockAnalytics is a mock implementation of the Analytics interface
@@ -22,9 +28,30 @@ func (m *MockAnalytics) LogRequest(ctx context.Context, state *plugins.State) er
 	return args.Error(0)
 }
 
+// MockGeoip is a mock implementation of the Geoip interface
+type MockGeoip struct {
+	mock.Mock
+}
+
+func (m *MockGeoip) Lookup(ip string) (geoip.Location, bool) {
+	args := m.Called(ip)
+	return args.Get(0).(geoip.Location), args.Bool(1)
+}
+
+// MockPlacements is a mock implementation of the Placements interface
+type MockPlacements struct {
+	mock.Mock
+}
+
+func (m *MockPlacements) One(ctx context.Context, id string) (ads.Placement, bool) {
+	args := m.Called(ctx, id)
+	placement, _ := args.Get(0).(ads.Placement)
+	return placement, args.Bool(1)
+}
+
 func TestNew(t *testing.T) {
 	// Вызываем тестируемую функцию
-	web := New(&analytics.Analytics{})
+	web := New(&analytics.Analytics{}, &geoip.Geoip{}, &placements.Cache{})
 
 	// Проверяем результат
 	assert.NotNil(t, web)
@@ -46,8 +73,12 @@ func TestWeb_Copy(t *testing.T) {
 	mockAnalytics := new(MockAnalytics)
 
 	// Создаем экземпляр Web
+	mockGeoip := new(MockGeoip)
+	mockPlacements := new(MockPlacements)
 	web := &Web{
-		analytics: mockAnalytics,
+		analytics:  mockAnalytics,
+		geoip:      mockGeoip,
+		placements: mockPlacements,
 	}
 
 	// Подготавливаем конфигурацию
@@ -63,6 +94,8 @@ func TestWeb_Copy(t *testing.T) {
 	// Проверяем, что analytics скопирован корректно
 	copiedWeb := copied.(*Web)
 	assert.Equal(t, mockAnalytics, copiedWeb.analytics)
+	assert.Equal(t, mockGeoip, copiedWeb.geoip)
+	assert.Equal(t, mockPlacements, copiedWeb.placements)
 }
 
 func TestWeb_Do(t *testing.T) {
@@ -70,9 +103,29 @@ func TestWeb_Do(t *testing.T) {
 	mockAnalytics := new(MockAnalytics)
 	mockAnalytics.On("LogRequest", mock.Anything, mock.Anything).Return(nil)
 
+	mockGeoip := new(MockGeoip)
+	mockGeoip.On("Lookup", "1.2.3.4").Return(geoip.Location{Country: "RU", Region: "Moscow", City: "Moscow"}, true)
+
+	mockPlacements := new(MockPlacements)
+	mockPlacements.On("One", mock.Anything, "test-placement").Return(ads.Placement{
+		ID:      "test-placement",
+		Formats: []string{"banner"},
+		Floor:   1.5,
+		Units: []ads.Unit{
+			{
+				ID:      "yandex-1",
+				Network: "yandex",
+				Price:   10,
+				Format:  "banner",
+			},
+		},
+	}, true)
+
 	// Создаем экземпляр Web
 	web := &Web{
-		analytics: mockAnalytics,
+		analytics:  mockAnalytics,
+		geoip:      mockGeoip,
+		placements: mockPlacements,
 	}
 
 	// Подготавливаем контекст и состояние
@@ -85,7 +138,11 @@ func TestWeb_Do(t *testing.T) {
 			Values: []string{"test-placement"},
 		},
 	}
-	req := &http.Request{}
+	req := &http.Request{
+		URL:        &url.URL{Path: "/ads/test-placement", RawQuery: "user_id=user-1&apps=com.one.app,com.two.app"},
+		Header:     http.Header{"User-Agent": {"test-agent"}},
+		RemoteAddr: "1.2.3.4:1234",
+	}
 	req = req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
 
 	state := &plugins.State{
@@ -97,10 +154,17 @@ func TestWeb_Do(t *testing.T) {
 
 	// Проверяем результат
 	assert.True(t, result)
-	assert.NotNil(t, state.User)
+	assert.Equal(t, &plugins.User{ID: "user-1", Apps: []string{"com.one.app", "com.two.app"}}, state.User)
 	assert.NotNil(t, state.Device)
 	assert.NotNil(t, state.Placement)
 	assert.Equal(t, "test-placement", state.Placement.ID)
+	assert.Equal(t, []string{"banner"}, state.Placement.Formats)
+	assert.Equal(t, 1.5, state.Placement.Floor)
+
+	// данные устройства и гео по ip клиента
+	assert.Equal(t, "test-agent", state.Device.UA)
+	assert.Equal(t, "1.2.3.4", state.Device.IP)
+	assert.Equal(t, &plugins.Geo{Country: "RU", Region: "Moscow", City: "Moscow"}, state.Geo)
 
 	// Проверяем, что placement содержит единицу рекламы
 	assert.Len(t, state.Placement.Units, 1)
@@ -112,3 +176,102 @@ func TestWeb_Do(t *testing.T) {
 	// Проверяем, что analytics.LogRequest был вызван
 	mockAnalytics.AssertCalled(t, "LogRequest", ctx, state)
 }
+
+func TestWeb_Do_UnknownPlacement(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+
+	mockGeoip := new(MockGeoip)
+	mockGeoip.On("Lookup", mock.Anything).Return(geoip.Location{}, false)
+
+	mockPlacements := new(MockPlacements)
+	mockPlacements.On("One", mock.Anything, "unknown").Return(ads.Placement{}, false)
+
+	web := &Web{
+		analytics:  mockAnalytics,
+		geoip:      mockGeoip,
+		placements: mockPlacements,
+	}
+
+	ctx := context.Background()
+
+	rctx := &chi.Context{
+		URLParams: chi.RouteParams{
+			Keys:   []string{"placement"},
+			Values: []string{"unknown"},
+		},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/native/unknown", nil)
+	req = req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+
+	state := &plugins.State{
+		Request:  req,
+		Response: rr,
+	}
+
+	result := web.Do(ctx, state)
+
+	assert.False(t, result)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockAnalytics.AssertNotCalled(t, "LogRequest", mock.Anything, mock.Anything)
+}
+
+func TestWeb_Do_AppsTargeting(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogRequest", mock.Anything, mock.Anything).Return(nil)
+
+	mockGeoip := new(MockGeoip)
+	mockGeoip.On("Lookup", mock.Anything).Return(geoip.Location{}, false)
+
+	mockPlacements := new(MockPlacements)
+	mockPlacements.On("One", mock.Anything, "test-placement").Return(ads.Placement{ID: "test-placement"}, true)
+
+	web := &Web{
+		analytics:  mockAnalytics,
+		geoip:      mockGeoip,
+		placements: mockPlacements,
+	}
+
+	// баннер показываем только тем, у кого стоит приложение
+	banner := ads.Banner{
+		Targeting: ads.Targeting{
+			Bapp: ads.ExcludeInclude{IncludeOr: []string{"com.one.app"}},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		ok    bool
+	}{
+		{name: "installed", query: "apps=com.two.app,com.one.app", ok: true},
+		{name: "not installed", query: "apps=com.two.app", ok: false},
+		{name: "unknown", query: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			rctx := &chi.Context{
+				URLParams: chi.RouteParams{
+					Keys:   []string{"placement"},
+					Values: []string{"test-placement"},
+				},
+			}
+			req := httptest.NewRequest(http.MethodGet, "/ads/test-placement?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+
+			state := &plugins.State{
+				Request:  req,
+				Response: httptest.NewRecorder(),
+			}
+
+			assert.True(t, web.Do(ctx, state))
+
+			ok, _ := apps.New().Filter(ctx, state, banner)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
@@ -35,6 +35,11 @@ func (r *Empty) Copy(cfg map[string]any) plugins.Output {
 	return &Empty{}
 }
 
+// Schema - у плагина нет настроек
+func (r *Empty) Schema() plugins.Schema {
+	return plugins.Schema{}
+}
+
 func (rtb *Empty) Do(ctx context.Context, state *plugins.State) error {
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 
 	"go.ads.coffee/platform/server/plugins/outputs/empty"
 	"go.ads.coffee/platform/server/plugins/outputs/pixel"
+	"go.ads.coffee/platform/server/plugins/outputs/prebid"
 	"go.ads.coffee/platform/server/plugins/outputs/rtb"
 	"go.ads.coffee/platform/server/plugins/outputs/static"
 	"go.ads.coffee/platform/server/plugins/outputs/web"
@@ -15,6 +16,7 @@ var Module = fx.Module(
 
 	web.Module,
 	rtb.Module,
+	prebid.Module,
 	pixel.Module,
 	empty.Module,
 	static.Module,
@@ -2,6 +2,7 @@ package pixel
 
 import (
 	"context"
+	"net/http"
 
 	"go.uber.org/fx"
 
@@ -20,11 +21,28 @@ var Module = fx.Module(
 	),
 )
 
+const (
+	modeKey = "mode"
+
+	ModeGif   = "gif"
+	ModeEmpty = "empty"
+)
+
+// прозрачный gif 1x1
+var transparentGif = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
 type Pixel struct {
+	mode string
 }
 
 func New() *Pixel {
-	return &Pixel{}
+	return &Pixel{
+		mode: ModeGif,
+	}
 }
 
 func (r *Pixel) Name() string {
@@ -32,9 +50,40 @@ func (r *Pixel) Name() string {
 }
 
 func (r *Pixel) Copy(cfg map[string]any) plugins.Output {
-	return &Pixel{}
+	mode, _ := cfg[modeKey].(string)
+	if mode != ModeEmpty {
+		mode = ModeGif
+	}
+
+	return &Pixel{
+		mode: mode,
+	}
 }
 
-func (rtb *Pixel) Do(ctx context.Context, state *plugins.State) error {
-	return nil
+func (r *Pixel) Schema() plugins.Schema {
+	return plugins.Schema{
+		{Name: modeKey, Type: plugins.TypeString, Default: ModeGif, Values: []string{ModeGif, ModeEmpty}},
+	}
+}
+
+// Do отдает пиксель или пустой ответ. Ответ не должен
+// кешироваться, иначе повторные показы не дойдут до трекера.
+func (r *Pixel) Do(ctx context.Context, state *plugins.State) error {
+	header := state.Response.Header()
+	header.Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
+	header.Set("Pragma", "no-cache")
+	header.Set("Expires", "0")
+
+	if r.mode == ModeEmpty {
+		state.Response.WriteHeader(http.StatusNoContent)
+
+		return nil
+	}
+
+	header.Set("Content-Type", "image/gif")
+	state.Response.WriteHeader(http.StatusOK)
+
+	_, err := state.Response.Write(transparentGif)
+
+	return err
 }
@@ -0,0 +1,52 @@
+package pixel
+
+import (
+	"bytes"
+	"context"
+	"image/gif"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+func TestPixel_Name(t *testing.T) {
+	assert.Equal(t, "outputs.pixel", New().Name())
+}
+
+func TestPixel_Do_Gif(t *testing.T) {
+	w := httptest.NewRecorder()
+	state := &plugins.State{Response: w}
+
+	err := New().Copy(nil).Do(context.Background(), state)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/gif", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Cache-Control"), "no-store")
+
+	img, err := gif.Decode(bytes.NewReader(w.Body.Bytes()))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, img.Bounds().Dx())
+	assert.Equal(t, 1, img.Bounds().Dy())
+
+	_, _, _, a := img.At(0, 0).RGBA()
+	assert.Zero(t, a)
+}
+
+func TestPixel_Do_Empty(t *testing.T) {
+	w := httptest.NewRecorder()
+	state := &plugins.State{Response: w}
+
+	err := New().Copy(map[string]any{"mode": "empty"}).Do(context.Background(), state)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+	assert.Contains(t, w.Header().Get("Cache-Control"), "no-store")
+}
@@ -0,0 +1,100 @@
+package prebid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Cache сохраняет ставки в Prebid Cache, чтобы ad server
+// достал креатив по hb_cache_id
+type Cache struct {
+	client   *http.Client
+	endpoint string
+	ttl      time.Duration
+	timeout  time.Duration
+}
+
+type cachePut struct {
+	Type       string `json:"type"`
+	Value      any    `json:"value"`
+	TTLSeconds int64  `json:"ttlseconds,omitempty"`
+}
+
+type cacheRequest struct {
+	Puts []cachePut `json:"puts"`
+}
+
+type cacheResponse struct {
+	Responses []struct {
+		UUID string `json:"uuid"`
+	} `json:"responses"`
+}
+
+func NewCache(client *http.Client, endpoint string, ttl, timeout time.Duration) *Cache {
+	return &Cache{
+		client:   client,
+		endpoint: endpoint,
+		ttl:      ttl,
+		timeout:  timeout,
+	}
+}
+
+// Put сохраняет значения одним запросом и возвращает
+// их идентификаторы в том же порядке
+func (c *Cache) Put(ctx context.Context, values []any) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req := cacheRequest{Puts: make([]cachePut, 0, len(values))}
+	for _, v := range values {
+		req.Puts = append(req.Puts, cachePut{Type: "json", Value: v, TTLSeconds: int64(c.ttl.Seconds())})
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error on marshal cache request: %w", err)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/cache", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error on create cache request: %w", err)
+	}
+
+	r.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(r)
+	if err != nil {
+		return nil, fmt.Errorf("error on cache request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected cache status %d", resp.StatusCode)
+	}
+
+	res := cacheResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("error on decode cache response: %w", err)
+	}
+
+	if len(res.Responses) != len(values) {
+		return nil, fmt.Errorf("cache returned %d ids for %d values", len(res.Responses), len(values))
+	}
+
+	ids := make([]string, 0, len(res.Responses))
+	for _, r := range res.Responses {
+		ids = append(ids, r.UUID)
+	}
+
+	return ids, nil
+}
+
+// URL - ссылка, по которой креатив достается из кеша
+func (c *Cache) URL(id string) string {
+	return c.endpoint + "/cache?uuid=" + url.QueryEscape(id)
+}
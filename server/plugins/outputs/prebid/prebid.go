@@ -0,0 +1,318 @@
+package prebid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/server/internal/analytics"
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/openrtb"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/tracking"
+	"go.ads.coffee/platform/server/plugins/outputs/rtb"
+)
+
+const (
+	bidderKey       = "bidder"
+	cacheKey        = "cache"
+	cacheTTLKey     = "cache_ttl"
+	cacheTimeoutKey = "cache_timeout"
+	granularityKey  = "granularity"
+
+	defaultBidder       = "adscoffee"
+	defaultCacheTTL     = 5 * time.Minute
+	defaultCacheTimeout = 100 * time.Millisecond
+)
+
+var Module = fx.Module(
+	"outputs.prebid",
+
+	fx.Provide(
+		fx.Annotate(
+			New,
+			fx.As(new(plugins.Output)),
+			fx.ResultTags(`group:"outputs"`),
+		),
+	),
+)
+
+type Analytics interface {
+	LogResponse(ctx context.Context, w ads.Banner, state *plugins.State) error
+}
+
+// Prebid отвечает Prebid Server ставками OpenRTB. Разметку и трекеры
+// собирает outputs.rtb, здесь к ставке добавляются ключи таргетинга
+// с корзиной цены и, если задан кеш, идентификатор креатива в нем
+type Prebid struct {
+	bidder      string
+	granularity openrtb.Granularity
+	rtb         *rtb.Rtb
+	cache       *Cache
+	client      *http.Client
+	logger      *zap.Logger
+	analytics   Analytics
+}
+
+func New(logger *zap.Logger, analytics *analytics.Analytics, signer *tracking.Signer) *Prebid {
+	r := rtb.New(analytics, signer)
+
+	return &Prebid{
+		bidder:      defaultBidder,
+		granularity: granularity(r.Currency()),
+		rtb:         r,
+		client:      &http.Client{},
+		logger:      logger,
+		analytics:   analytics,
+	}
+}
+
+func (p *Prebid) Name() string {
+	return "outputs.prebid"
+}
+
+func (p *Prebid) Copy(cfg map[string]any) plugins.Output {
+	bidder, _ := cfg[bidderKey].(string)
+	if bidder == "" {
+		bidder = defaultBidder
+	}
+
+	var cache *Cache
+
+	if endpoint, _ := cfg[cacheKey].(string); endpoint != "" {
+		cache = NewCache(
+			p.client,
+			endpoint,
+			p.duration(cfg, cacheTTLKey, defaultCacheTTL),
+			p.duration(cfg, cacheTimeoutKey, defaultCacheTimeout),
+		)
+	}
+
+	r := p.rtb.Copy(cfg).(*rtb.Rtb)
+
+	g := granularity(r.Currency())
+	if name, _ := cfg[granularityKey].(string); name != "" {
+		if named, ok := openrtb.LookupGranularity(name); ok {
+			g = named
+		} else {
+			p.logger.Warn("unknown prebid price granularity, use default",
+				zap.String("granularity", name),
+			)
+		}
+	}
+
+	return &Prebid{
+		bidder:      bidder,
+		granularity: g,
+		rtb:         r,
+		cache:       cache,
+		client:      p.client,
+		logger:      p.logger,
+		analytics:   p.analytics,
+	}
+}
+
+// Schema - ключи outputs.rtb, имя бидера, гранулярность
+// цены по умолчанию и адрес Prebid Cache
+func (p *Prebid) Schema() plugins.Schema {
+	return append(p.rtb.Schema(),
+		plugins.Field{Name: bidderKey, Type: plugins.TypeString, Default: defaultBidder},
+		plugins.Field{Name: granularityKey, Type: plugins.TypeString},
+		plugins.Field{Name: cacheKey, Type: plugins.TypeString},
+		plugins.Field{Name: cacheTTLKey, Type: plugins.TypeDuration, Default: defaultCacheTTL.String()},
+		plugins.Field{Name: cacheTimeoutKey, Type: plugins.TypeDuration, Default: defaultCacheTimeout.String()},
+	)
+}
+
+//nolint:errcheck
+func (p *Prebid) Do(ctx context.Context, state *plugins.State) error {
+	if state.BidRequest == nil {
+		return fmt.Errorf("bid request not found")
+	}
+
+	imp, ok := p.rtb.Imp(state)
+	if !ok {
+		return fmt.Errorf("imp %s not found", state.Placement.ImpID)
+	}
+
+	granularity := p.priceGranularity(state.BidRequest)
+
+	bids := []openrtb.Bid{}
+	exts := []openrtb.PrebidBidExt{}
+	winners := []ads.Banner{}
+
+	for _, w := range state.Winners {
+		bid, err := p.rtb.Bid(state, imp, w)
+		if err != nil {
+			continue
+		}
+
+		ext := openrtb.PrebidBidExt{
+			Prebid: openrtb.PrebidBid{
+				Type: kind(bid),
+				Targeting: map[string]string{
+					openrtb.TargetingPriceBucket: granularity.Bucket(bid.Price),
+					openrtb.TargetingBidder:      p.bidder,
+					openrtb.TargetingFormat:      kind(bid),
+				},
+			},
+		}
+
+		if bid.W > 0 && bid.H > 0 {
+			ext.Prebid.Targeting[openrtb.TargetingSize] = fmt.Sprintf("%dx%d", bid.W, bid.H)
+		}
+
+		bids = append(bids, bid)
+		exts = append(exts, ext)
+		winners = append(winners, w)
+	}
+
+	if len(bids) == 0 {
+		state.Response.WriteHeader(http.StatusNoContent)
+
+		return nil
+	}
+
+	p.store(ctx, bids, exts)
+
+	for i := range bids {
+		data, err := json.Marshal(exts[i])
+		if err != nil {
+			return fmt.Errorf("error on render bid ext: %w", err)
+		}
+
+		bids[i].Ext = data
+	}
+
+	resp := openrtb.BidResponse{
+		ID:    state.BidRequest.ID,
+		BidID: state.RequestID,
+		Cur:   p.rtb.Currency(),
+		SeatBid: []openrtb.SeatBid{
+			{
+				Seat: p.bidder,
+				Bid:  bids,
+			},
+		},
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("error on render bid response: %w", err)
+	}
+
+	state.Response.Header().Set("Content-Type", "application/json")
+
+	if version := state.Request.Header.Get(openrtb.HeaderVersion); version != "" {
+		state.Response.Header().Set(openrtb.HeaderVersion, version)
+	}
+
+	_, err = state.Response.Write(data)
+
+	for _, w := range winners {
+		p.analytics.LogResponse(ctx, w, state)
+	}
+
+	return err
+}
+
+// NoBid - на ошибку отвечаем так же, как outputs.rtb
+func (p *Prebid) NoBid(state *plugins.State) {
+	p.rtb.NoBid(state)
+}
+
+// store кладет ставки в кеш. Без кеша ставка все равно
+// валидна: Prebid Server может закешировать ее сам
+func (p *Prebid) store(ctx context.Context, bids []openrtb.Bid, exts []openrtb.PrebidBidExt) {
+	if p.cache == nil {
+		return
+	}
+
+	values := make([]any, 0, len(bids))
+	for _, bid := range bids {
+		values = append(values, bid)
+	}
+
+	ids, err := p.cache.Put(ctx, values)
+	if err != nil {
+		p.logger.Warn("error on put bids to prebid cache", zap.Error(err))
+
+		return
+	}
+
+	for i, id := range ids {
+		exts[i].Prebid.Targeting[openrtb.TargetingCacheID] = id
+		exts[i].Prebid.Cache = &openrtb.PrebidBidCache{
+			Bids: &openrtb.PrebidCacheInfo{URL: p.cache.URL(id), CacheID: id},
+		}
+	}
+}
+
+// priceGranularity берется из ext.prebid.targeting запроса,
+// без нее - из конфига
+func (p *Prebid) priceGranularity(req *openrtb.BidRequest) openrtb.Granularity {
+	ext := openrtb.PrebidExt{}
+
+	if len(req.Ext) == 0 || json.Unmarshal(req.Ext, &ext) != nil ||
+		ext.Prebid == nil || ext.Prebid.Targeting == nil ||
+		len(ext.Prebid.Targeting.PriceGranularity) == 0 {
+		return p.granularity
+	}
+
+	g, err := openrtb.ParseGranularity(ext.Prebid.Targeting.PriceGranularity)
+	if err != nil {
+		p.logger.Warn("invalid prebid price granularity, use default", zap.Error(err))
+
+		return p.granularity
+	}
+
+	return g
+}
+
+// granularity по умолчанию зависит от валюты: medium
+// Prebid Server рассчитан на доллары
+func granularity(cur string) openrtb.Granularity {
+	if cur == "RUB" {
+		g, _ := openrtb.LookupGranularity("rub")
+
+		return g
+	}
+
+	return openrtb.DefaultGranularity()
+}
+
+func (p *Prebid) duration(cfg map[string]any, key string, def time.Duration) time.Duration {
+	v, ok := cfg[key].(string)
+	if !ok {
+		return def
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		p.logger.Warn("invalid duration, use default",
+			zap.String("key", key),
+			zap.String("value", v),
+			zap.Duration("default", def),
+		)
+
+		return def
+	}
+
+	return d
+}
+
+func kind(bid openrtb.Bid) string {
+	switch bid.MType {
+	case openrtb.MarkupNative:
+		return openrtb.PrebidNative
+	case openrtb.MarkupVideo:
+		return openrtb.PrebidVideo
+	}
+
+	return openrtb.PrebidBanner
+}
@@ -0,0 +1,284 @@
+package prebid
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/openrtb"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/tracking"
+)
+
+// MockAnalytics is a mock implementation of the Analytics interface
+type MockAnalytics struct {
+	mock.Mock
+}
+
+func (m *MockAnalytics) LogResponse(ctx context.Context, w ads.Banner, state *plugins.State) error {
+	args := m.Called(ctx, w, state)
+	return args.Error(0)
+}
+
+var signer, _ = tracking.NewSigner(tracking.Config{Secret: "secret"})
+
+func newPrebid(cfg map[string]any, analytics Analytics) *Prebid {
+	cfg["base"] = "http://ads.coffee/tracker"
+	if _, ok := cfg["cur"]; !ok {
+		cfg["cur"] = "RUB"
+	}
+
+	p := New(zap.NewNop(), nil, signer).Copy(cfg).(*Prebid)
+	p.analytics = analytics
+
+	return p
+}
+
+func newState(ext string, winners ...ads.Banner) (*plugins.State, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	width, height := int64(320), int64(50)
+
+	imp := openrtb.Imp{
+		ID:     "imp-1",
+		Banner: &openrtb.Banner{W: &width, H: &height},
+	}
+
+	req := &openrtb.BidRequest{
+		ID:  "req-1",
+		Imp: []openrtb.Imp{imp},
+	}
+
+	if ext != "" {
+		req.Ext = json.RawMessage(ext)
+	}
+
+	return &plugins.State{
+		RequestID:  "request-1",
+		ClickID:    "click-1",
+		Request:    httptest.NewRequest(http.MethodPost, "/prebid", nil),
+		Response:   w,
+		App:        &plugins.App{Bundle: "com.example.app"},
+		BidRequest: req,
+		Placement: &plugins.Placement{
+			ID:    "placement-1",
+			ImpID: imp.ID,
+		},
+		Winners: winners,
+	}, w
+}
+
+// banner - победитель аукциона со ставкой price. Цена списания
+// второго аукциона в ответ не попадает
+func banner(price int) ads.Banner {
+	return ads.Banner{
+		ID:            "banner-1",
+		Title:         "Title",
+		Price:         price,
+		ClearingPrice: 0.01,
+		Type:          ads.CreativeTypeBanner,
+		Target:        "https://www.example.com/landing",
+		Image:         ads.Image{Url: "https://cdn.example.com/image.png"},
+		CampaignID:    "campaign-1",
+	}
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) (openrtb.BidResponse, openrtb.PrebidBidExt) {
+	t.Helper()
+
+	resp := openrtb.BidResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.SeatBid, 1)
+	require.Len(t, resp.SeatBid[0].Bid, 1)
+
+	ext := openrtb.PrebidBidExt{}
+	require.NoError(t, json.Unmarshal(resp.SeatBid[0].Bid[0].Ext, &ext))
+
+	return resp, ext
+}
+
+func TestPrebid_Name(t *testing.T) {
+	p := &Prebid{}
+
+	assert.Equal(t, "outputs.prebid", p.Name())
+}
+
+func TestPrebid_Copy(t *testing.T) {
+	p := newPrebid(map[string]any{
+		"bidder":        "coffee",
+		"cache":         "http://cache.example.com",
+		"cache_ttl":     "1m",
+		"cache_timeout": "bad",
+	}, new(MockAnalytics))
+
+	assert.Equal(t, "coffee", p.bidder)
+	require.NotNil(t, p.cache)
+	assert.Equal(t, "http://cache.example.com", p.cache.endpoint)
+	assert.Equal(t, 60.0, p.cache.ttl.Seconds())
+	assert.Equal(t, defaultCacheTimeout, p.cache.timeout)
+
+	p = newPrebid(map[string]any{}, new(MockAnalytics))
+
+	assert.Equal(t, defaultBidder, p.bidder)
+	assert.Nil(t, p.cache)
+}
+
+func TestPrebid_Schema(t *testing.T) {
+	p := New(zap.NewNop(), nil, nil)
+
+	names := []string{}
+	for _, f := range p.Schema() {
+		names = append(names, f.Name)
+	}
+
+	assert.Contains(t, names, "base")
+	assert.Contains(t, names, bidderKey)
+	assert.Contains(t, names, cacheKey)
+}
+
+func TestPrebid_Do_Targeting(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogResponse", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	p := newPrebid(map[string]any{}, mockAnalytics)
+	state, w := newState("", banner(15))
+
+	require.NoError(t, p.Do(context.Background(), state))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	resp, ext := decode(t, w)
+
+	assert.Equal(t, "req-1", resp.ID)
+	assert.Equal(t, "RUB", resp.Cur)
+	assert.Equal(t, defaultBidder, resp.SeatBid[0].Seat)
+	assert.Equal(t, 15.0, resp.SeatBid[0].Bid[0].Price)
+
+	assert.Equal(t, openrtb.PrebidBanner, ext.Prebid.Type)
+	assert.Equal(t, "15.00", ext.Prebid.Targeting[openrtb.TargetingPriceBucket])
+	assert.Equal(t, defaultBidder, ext.Prebid.Targeting[openrtb.TargetingBidder])
+	assert.Equal(t, "320x50", ext.Prebid.Targeting[openrtb.TargetingSize])
+	assert.Equal(t, openrtb.PrebidBanner, ext.Prebid.Targeting[openrtb.TargetingFormat])
+	assert.Nil(t, ext.Prebid.Cache)
+
+	mockAnalytics.AssertNumberOfCalls(t, "LogResponse", 1)
+}
+
+func TestPrebid_Do_Granularity(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogResponse", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	p := newPrebid(map[string]any{}, mockAnalytics)
+	state, w := newState(`{"prebid": {"targeting": {"pricegranularity": "low"}}}`, banner(3))
+
+	require.NoError(t, p.Do(context.Background(), state))
+
+	_, ext := decode(t, w)
+
+	assert.Equal(t, "3.00", ext.Prebid.Targeting[openrtb.TargetingPriceBucket])
+}
+
+func TestPrebid_Do_GranularityConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    map[string]any
+		price  int
+		bucket string
+	}{
+		// без гранулярности в запросе рубли не упираются в 20
+		{name: "rub", cfg: map[string]any{}, price: 237, bucket: "235.00"},
+		{name: "usd", cfg: map[string]any{"cur": "USD"}, price: 35, bucket: "20.00"},
+		{name: "config", cfg: map[string]any{"granularity": "low"}, price: 3, bucket: "3.00"},
+		{name: "unknown", cfg: map[string]any{"granularity": "ultra"}, price: 237, bucket: "235.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAnalytics := new(MockAnalytics)
+			mockAnalytics.On("LogResponse", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			p := newPrebid(tt.cfg, mockAnalytics)
+			state, w := newState("", banner(tt.price))
+
+			require.NoError(t, p.Do(context.Background(), state))
+
+			_, ext := decode(t, w)
+
+			assert.Equal(t, tt.bucket, ext.Prebid.Targeting[openrtb.TargetingPriceBucket])
+		})
+	}
+}
+
+func TestPrebid_Do_Cache(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogResponse", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	puts := cacheRequest{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/cache", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&puts))
+
+		_, _ = w.Write([]byte(`{"responses": [{"uuid": "uuid-1"}]}`))
+	}))
+	defer srv.Close()
+
+	p := newPrebid(map[string]any{"cache": srv.URL}, mockAnalytics)
+	state, w := newState("", banner(15))
+
+	require.NoError(t, p.Do(context.Background(), state))
+
+	_, ext := decode(t, w)
+
+	require.Len(t, puts.Puts, 1)
+	assert.Equal(t, "json", puts.Puts[0].Type)
+	assert.Equal(t, int64(defaultCacheTTL.Seconds()), puts.Puts[0].TTLSeconds)
+
+	assert.Equal(t, "uuid-1", ext.Prebid.Targeting[openrtb.TargetingCacheID])
+	require.NotNil(t, ext.Prebid.Cache)
+	require.NotNil(t, ext.Prebid.Cache.Bids)
+	assert.Equal(t, "uuid-1", ext.Prebid.Cache.Bids.CacheID)
+	assert.Equal(t, srv.URL+"/cache?uuid=uuid-1", ext.Prebid.Cache.Bids.URL)
+}
+
+func TestPrebid_Do_CacheError(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogResponse", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	p := newPrebid(map[string]any{"cache": srv.URL}, mockAnalytics)
+	state, w := newState("", banner(15))
+
+	require.NoError(t, p.Do(context.Background(), state))
+
+	// ставка уходит и без кеша
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	_, ext := decode(t, w)
+
+	assert.Empty(t, ext.Prebid.Targeting[openrtb.TargetingCacheID])
+	assert.Nil(t, ext.Prebid.Cache)
+}
+
+func TestPrebid_Do_NoWinners(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+
+	p := newPrebid(map[string]any{}, mockAnalytics)
+	state, w := newState("")
+
+	require.NoError(t, p.Do(context.Background(), state))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockAnalytics.AssertNotCalled(t, "LogResponse", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -2,12 +2,34 @@ package rtb
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/google/uuid"
 	"go.uber.org/fx"
 
+	"go.ads.coffee/platform/server/internal/analytics"
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/openrtb"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/tracking"
 )
 
+const (
+	baseUrlKey = "base"
+	seatKey    = "seat"
+	curKey     = "cur"
+
+	defaultCur = "RUB"
+)
+
+var errUnsupportedType = errors.New("unsupported creative type")
+
 var Module = fx.Module(
 	"outputs.rtb",
 
@@ -20,11 +42,27 @@ var Module = fx.Module(
 	),
 )
 
+type Analytics interface {
+	LogResponse(ctx context.Context, w ads.Banner, state *plugins.State) error
+}
+
+type Signer interface {
+	Sign(link string) (string, error)
+}
+
 type Rtb struct {
+	base      string
+	seat      string
+	cur       string
+	analytics Analytics
+	signer    Signer
 }
 
-func New() *Rtb {
-	return &Rtb{}
+func New(analytics *analytics.Analytics, signer *tracking.Signer) *Rtb {
+	return &Rtb{
+		analytics: analytics,
+		signer:    signer,
+	}
 }
 
 func (r *Rtb) Name() string {
@@ -32,9 +70,296 @@ func (r *Rtb) Name() string {
 }
 
 func (r *Rtb) Copy(cfg map[string]any) plugins.Output {
-	return &Rtb{}
+	base, _ := cfg[baseUrlKey].(string)
+	seat, _ := cfg[seatKey].(string)
+
+	cur, _ := cfg[curKey].(string)
+	if cur == "" {
+		cur = defaultCur
+	}
+
+	return &Rtb{
+		base:      base,
+		seat:      seat,
+		cur:       cur,
+		analytics: r.analytics,
+		signer:    r.signer,
+	}
+}
+
+func (r *Rtb) Schema() plugins.Schema {
+	return plugins.Schema{
+		{Name: baseUrlKey, Type: plugins.TypeString, Required: true},
+		{Name: seatKey, Type: plugins.TypeString},
+		{Name: curKey, Type: plugins.TypeString, Default: defaultCur},
+	}
+}
+
+// Currency - валюта ставок
+func (r *Rtb) Currency() string {
+	return r.cur
+}
+
+//nolint:errcheck
+func (r *Rtb) Do(ctx context.Context, state *plugins.State) error {
+	if state.BidRequest == nil {
+		return fmt.Errorf("bid request not found")
+	}
+
+	imp, ok := r.Imp(state)
+	if !ok {
+		return fmt.Errorf("imp %s not found", state.Placement.ImpID)
+	}
+
+	bids := []openrtb.Bid{}
+	winners := []ads.Banner{}
+
+	for _, w := range state.Winners {
+		bid, err := r.Bid(state, imp, w)
+		if err != nil {
+			continue
+		}
+
+		bids = append(bids, bid)
+		winners = append(winners, w)
+	}
+
+	if len(bids) == 0 {
+		state.Response.WriteHeader(http.StatusNoContent)
+
+		return nil
+	}
+
+	resp := openrtb.BidResponse{
+		ID:    state.BidRequest.ID,
+		BidID: state.RequestID,
+		Cur:   r.cur,
+		SeatBid: []openrtb.SeatBid{
+			{
+				Seat: r.seat,
+				Bid:  bids,
+			},
+		},
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("error on render bid response: %w", err)
+	}
+
+	state.Response.Header().Set("Content-Type", "application/json")
+
+	if version := state.Request.Header.Get(openrtb.HeaderVersion); version != "" {
+		state.Response.Header().Set(openrtb.HeaderVersion, version)
+	}
+
+	_, err = state.Response.Write(data)
+
+	for _, w := range winners {
+		r.analytics.LogResponse(ctx, w, state)
+	}
+
+	return err
+}
+
+// NoBid - ответ на ошибку пайплайна: 204 с причиной
+// в заголовке, как у inputs.rtb
+func (r *Rtb) NoBid(state *plugins.State) {
+	state.Response.Header().Set(openrtb.HeaderNbr, strconv.Itoa(openrtb.NbrTechnicalError))
+	state.Response.WriteHeader(http.StatusNoContent)
+}
+
+// Imp - imp запроса, для которого выбран плейсмент
+func (r *Rtb) Imp(state *plugins.State) (openrtb.Imp, bool) {
+	if state.Placement == nil {
+		return openrtb.Imp{}, false
+	}
+
+	for _, imp := range state.BidRequest.Imp {
+		if imp.ID == state.Placement.ImpID {
+			return imp, true
+		}
+	}
+
+	return openrtb.Imp{}, false
+}
+
+// Bid собирает ставку с разметкой креатива и ссылками на трекер
+func (r *Rtb) Bid(state *plugins.State, imp openrtb.Imp, w ads.Banner) (openrtb.Bid, error) {
+	w.Target = tracking.Target(w.Target, state.ClickID)
+
+	// на бирже ставим свою ставку. Цена второго аукциона внутри
+	// пайплайна нужна только для списания, цену биржи пришлет
+	// ${AUCTION_PRICE} в уведомлениях
+	bid := openrtb.Bid{
+		ID:      uuid.NewString(),
+		ImpID:   imp.ID,
+		Price:   w.ECPM(),
+		AdID:    w.ID,
+		CrID:    w.ID,
+		CID:     w.CampaignID,
+		ADomain: adomain(w),
+		Bundle:  w.Bundle,
+		IURL:    w.Image.Full(""),
+	}
+
+	switch {
+	case w.Type == ads.CreativeTypeNative && imp.Native != nil:
+		adm, err := native(imp.Native, w)
+		if err != nil {
+			return bid, err
+		}
+
+		bid.AdM = adm
+		bid.MType = openrtb.MarkupNative
+	case w.Type == ads.CreativeTypeBanner && imp.Banner != nil:
+		bid.W, bid.H = size(imp.Banner)
+		bid.AdM = banner(w, bid.W, bid.H)
+		bid.MType = openrtb.MarkupBanner
+	default:
+		return bid, fmt.Errorf("%w: %s", errUnsupportedType, w.Type)
+	}
+
+	// цену и причину проигрыша подставит биржа через макрос,
+	// эти параметры в подпись не входят
+	info := state.TrackerInfo(w)
+	info.Price = 0
+
+	nurl, err := r.sign(ads.ActionWin, info)
+	if err != nil {
+		return bid, err
+	}
+
+	burl, err := r.sign(ads.ActionBilling, info)
+	if err != nil {
+		return bid, err
+	}
+
+	lurl, err := r.sign(ads.ActionLoose, info)
+	if err != nil {
+		return bid, err
+	}
+
+	bid.NURL = tracking.Macro(nurl, tracking.ParamPrice, openrtb.MacroAuctionPrice)
+	bid.BURL = tracking.Macro(burl, tracking.ParamPrice, openrtb.MacroAuctionPrice)
+	bid.LURL = tracking.Macro(
+		tracking.Macro(lurl, tracking.ParamPrice, openrtb.MacroAuctionPrice),
+		tracking.ParamReason, openrtb.MacroAuctionLoss,
+	)
+
+	return bid, nil
+}
+
+func (r *Rtb) sign(action string, info ads.TrackerInfo) (string, error) {
+	link, err := r.signer.Sign(tracking.URL(r.base, action, info))
+	if err != nil {
+		return "", fmt.Errorf("error on sign %s notice: %w", action, err)
+	}
+
+	return link, nil
+}
+
+// banner собирает html разметку для баннера
+func banner(w ads.Banner, width, height int64) string {
+	b := strings.Builder{}
+
+	fmt.Fprintf(&b, `<a href="%s" target="_blank">`, html.EscapeString(w.Target))
+	fmt.Fprintf(&b, `<img src="%s" alt="%s"`, html.EscapeString(w.Image.Full("")), html.EscapeString(w.Title))
+
+	if width > 0 && height > 0 {
+		fmt.Fprintf(&b, ` width="%d" height="%d"`, width, height)
+	}
+
+	b.WriteString(` border="0"/></a>`)
+
+	if w.Imptracker != "" {
+		fmt.Fprintf(&b, `<img src="%s" width="1" height="1" style="display:none"/>`, html.EscapeString(w.Imptracker))
+	}
+
+	return b.String()
 }
 
-func (rtb *Rtb) Do(ctx context.Context, state *plugins.State) error {
-	return nil
+// native собирает нативный ответ по ассетам из запроса
+func native(imp *openrtb.Native, w ads.Banner) (string, error) {
+	req, err := openrtb.ParseNative(imp.Request)
+	if err != nil {
+		return "", err
+	}
+
+	resp := openrtb.NativeResponse{
+		Ver:    req.Ver,
+		Assets: []openrtb.NativeAsset{},
+		Link: openrtb.NativeLink{
+			URL: w.Target,
+		},
+	}
+
+	if w.Clicktracker != "" {
+		resp.Link.ClickTrackers = []string{w.Clicktracker}
+	}
+
+	if w.Imptracker != "" {
+		resp.ImpTrackers = []string{w.Imptracker}
+	}
+
+	for _, a := range req.Assets {
+		asset := openrtb.NativeAsset{ID: a.ID}
+
+		switch {
+		case a.Title != nil:
+			asset.Title = &openrtb.NativeTitle{Text: w.Title}
+		case a.Img != nil && a.Img.Type == openrtb.NativeImageIcon:
+			asset.Img = &openrtb.NativeImage{URL: w.Icon.Full("")}
+		case a.Img != nil:
+			asset.Img = &openrtb.NativeImage{URL: w.Image.Full("")}
+		case a.Data != nil && a.Data.Type == openrtb.NativeDataDesc:
+			asset.Data = &openrtb.NativeData{Value: w.Description}
+		case a.Data != nil && a.Data.Type == openrtb.NativeDataSponsored:
+			asset.Data = &openrtb.NativeData{Value: w.Label}
+		default:
+			if a.Required == 1 {
+				return "", fmt.Errorf("required asset %d is not supported", a.ID)
+			}
+
+			continue
+		}
+
+		if asset.Empty() {
+			if a.Required == 1 {
+				return "", fmt.Errorf("required asset %d is empty", a.ID)
+			}
+
+			continue
+		}
+
+		resp.Assets = append(resp.Assets, asset)
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func size(b *openrtb.Banner) (int64, int64) {
+	if b.W != nil && b.H != nil {
+		return *b.W, *b.H
+	}
+
+	if len(b.Format) > 0 {
+		return b.Format[0].W, b.Format[0].H
+	}
+
+	return 0, 0
+}
+
+func adomain(w ads.Banner) []string {
+	domain := w.Domain()
+	if domain == "" {
+		return nil
+	}
+
+	return []string{domain}
 }
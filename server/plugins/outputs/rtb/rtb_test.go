@@ -0,0 +1,253 @@
+package rtb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/openrtb"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/tracking"
+)
+
+var signer, _ = tracking.NewSigner(tracking.Config{Secret: "secret"})
+
+// MockAnalytics is a mock implementation of the Analytics interface
+type MockAnalytics struct {
+	mock.Mock
+}
+
+func (m *MockAnalytics) LogResponse(ctx context.Context, w ads.Banner, state *plugins.State) error {
+	args := m.Called(ctx, w, state)
+	return args.Error(0)
+}
+
+func newState(imp openrtb.Imp, winners ...ads.Banner) (*plugins.State, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+
+	return &plugins.State{
+		RequestID: "request-1",
+		ClickID:   "click-1",
+		Request:   httptest.NewRequest(http.MethodPost, "/dsp", nil),
+		Response:  w,
+		App:       &plugins.App{Bundle: "com.example.app"},
+		BidRequest: &openrtb.BidRequest{
+			ID:  "req-1",
+			Imp: []openrtb.Imp{imp},
+		},
+		Placement: &plugins.Placement{
+			ID:    "placement-1",
+			ImpID: imp.ID,
+		},
+		Winners: winners,
+	}, w
+}
+
+func TestRtb_Copy(t *testing.T) {
+	rtb := &Rtb{analytics: new(MockAnalytics), signer: signer}
+
+	copied := rtb.Copy(map[string]any{
+		"base": "http://ads.coffee/tracker",
+		"seat": "seat-1",
+	}).(*Rtb)
+
+	assert.Equal(t, "http://ads.coffee/tracker", copied.base)
+	assert.Equal(t, "seat-1", copied.seat)
+	assert.Equal(t, defaultCur, copied.cur)
+	assert.Equal(t, rtb.analytics, copied.analytics)
+	assert.Equal(t, rtb.signer, copied.signer)
+}
+
+func TestRtb_Do_Banner(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogResponse", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	rtb := &Rtb{
+		base:      "http://ads.coffee/tracker",
+		seat:      "seat-1",
+		cur:       "RUB",
+		analytics: mockAnalytics,
+		signer:    signer,
+	}
+
+	width, height := int64(320), int64(50)
+	banner := ads.Banner{
+		ID:         "banner-1",
+		Title:      "Title",
+		Price:      15,
+		Type:       ads.CreativeTypeBanner,
+		Target:     "https://www.example.com/landing",
+		Image:      ads.Image{Url: "https://cdn.example.com/image.png"},
+		CampaignID: "campaign-1",
+		// цена внутреннего аукциона не уходит на биржу
+		ClearingPrice: 0.01,
+	}
+
+	state, w := newState(openrtb.Imp{
+		ID:     "imp-1",
+		Banner: &openrtb.Banner{W: &width, H: &height},
+	}, banner)
+
+	err := rtb.Do(context.Background(), state)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	resp := openrtb.BidResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	assert.Equal(t, "req-1", resp.ID)
+	assert.Equal(t, "RUB", resp.Cur)
+	require.Len(t, resp.SeatBid, 1)
+	assert.Equal(t, "seat-1", resp.SeatBid[0].Seat)
+	require.Len(t, resp.SeatBid[0].Bid, 1)
+
+	bid := resp.SeatBid[0].Bid[0]
+	assert.Equal(t, "imp-1", bid.ImpID)
+	assert.Equal(t, 15.0, bid.Price)
+	assert.Equal(t, "banner-1", bid.CrID)
+	assert.Equal(t, "campaign-1", bid.CID)
+	assert.Equal(t, []string{"example.com"}, bid.ADomain)
+	assert.Equal(t, int8(openrtb.MarkupBanner), bid.MType)
+	assert.Equal(t, int64(320), bid.W)
+	assert.Contains(t, bid.AdM, `href="https://www.example.com/landing"`)
+	assert.Contains(t, bid.AdM, `src="https://cdn.example.com/image.png"`)
+
+	assert.True(t, strings.HasPrefix(bid.NURL, "http://ads.coffee/tracker?"))
+	assert.Contains(t, bid.NURL, "action=win")
+	assert.Contains(t, bid.NURL, "price=${AUCTION_PRICE}")
+	assert.Contains(t, bid.BURL, "action=billing")
+	assert.Contains(t, bid.BURL, "price=${AUCTION_PRICE}")
+	assert.Contains(t, bid.LURL, "action=loose")
+	assert.Contains(t, bid.LURL, "reason=${AUCTION_LOSS}")
+	assert.Contains(t, bid.NURL, "bid=banner-1")
+	assert.Contains(t, bid.NURL, "bundle=com.example.app")
+
+	// уведомления подписаны, цену биржа подставит после подписи
+	for _, link := range []string{bid.NURL, bid.BURL, bid.LURL} {
+		u, err := url.Parse(strings.NewReplacer(
+			openrtb.MacroAuctionPrice, "12.5",
+			openrtb.MacroAuctionLoss, "102",
+		).Replace(link))
+		require.NoError(t, err)
+
+		assert.NoError(t, signer.Verify(u.Query(), tracking.NoticeParams...))
+	}
+
+	mockAnalytics.AssertCalled(t, "LogResponse", mock.Anything, banner, state)
+}
+
+func TestRtb_Do_Native(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+	mockAnalytics.On("LogResponse", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	rtb := &Rtb{analytics: mockAnalytics, signer: signer}
+
+	banner := ads.Banner{
+		ID:           "banner-1",
+		Title:        "Title",
+		Description:  "Description",
+		Type:         ads.CreativeTypeNative,
+		Target:       "https://example.com",
+		Image:        ads.Image{Url: "https://cdn.example.com/image.png"},
+		Imptracker:   "https://tracker.example.com/imp",
+		Clicktracker: "https://tracker.example.com/click",
+	}
+
+	state, w := newState(openrtb.Imp{
+		ID: "imp-1",
+		Native: &openrtb.Native{
+			Request: `{"native":{"ver":"1.2","assets":[` +
+				`{"id":1,"required":1,"title":{"len":90}},` +
+				`{"id":2,"required":1,"img":{"type":3}},` +
+				`{"id":3,"data":{"type":2}},` +
+				`{"id":4,"data":{"type":12}}]}}`,
+		},
+	}, banner)
+
+	err := rtb.Do(context.Background(), state)
+	require.NoError(t, err)
+
+	resp := openrtb.BidResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	bid := resp.SeatBid[0].Bid[0]
+	assert.Equal(t, int8(openrtb.MarkupNative), bid.MType)
+
+	native := openrtb.NativeResponse{}
+	require.NoError(t, json.Unmarshal([]byte(bid.AdM), &native))
+
+	assert.Equal(t, "1.2", native.Ver)
+	assert.Equal(t, "https://example.com", native.Link.URL)
+	assert.Equal(t, []string{"https://tracker.example.com/click"}, native.Link.ClickTrackers)
+	assert.Equal(t, []string{"https://tracker.example.com/imp"}, native.ImpTrackers)
+
+	require.Len(t, native.Assets, 3)
+	assert.Equal(t, "Title", native.Assets[0].Title.Text)
+	assert.Equal(t, "https://cdn.example.com/image.png", native.Assets[1].Img.URL)
+	assert.Equal(t, "Description", native.Assets[2].Data.Value)
+}
+
+func TestRtb_Do_NoBid(t *testing.T) {
+	mockAnalytics := new(MockAnalytics)
+
+	rtb := &Rtb{analytics: mockAnalytics, signer: signer}
+
+	// баннер не подходит под формат imp
+	state, w := newState(openrtb.Imp{
+		ID:     "imp-1",
+		Native: &openrtb.Native{Request: `{"assets":[]}`},
+	}, ads.Banner{ID: "banner-1", Type: ads.CreativeTypeBanner})
+
+	err := rtb.Do(context.Background(), state)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+	mockAnalytics.AssertNotCalled(t, "LogResponse", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRtb_Do_WithoutBidRequest(t *testing.T) {
+	rtb := &Rtb{analytics: new(MockAnalytics)}
+
+	err := rtb.Do(context.Background(), &plugins.State{})
+
+	assert.Error(t, err)
+}
+
+func TestRtb_NoBid(t *testing.T) {
+	rtb := &Rtb{analytics: new(MockAnalytics)}
+
+	w := httptest.NewRecorder()
+	rtb.NoBid(&plugins.State{Response: w})
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "1", w.Header().Get(openrtb.HeaderNbr))
+	assert.Empty(t, w.Body.String())
+}
+
+func TestRtb_Bid_SignError(t *testing.T) {
+	rtb := &Rtb{
+		base:      "http://ads.coffee/tracker",
+		analytics: new(MockAnalytics),
+		signer:    &tracking.Signer{},
+	}
+
+	width, height := int64(320), int64(50)
+	imp := openrtb.Imp{ID: "imp-1", Banner: &openrtb.Banner{W: &width, H: &height}}
+	state, _ := newState(imp)
+
+	_, err := rtb.Bid(state, imp, ads.Banner{ID: "banner-1", Type: ads.CreativeTypeBanner})
+
+	assert.ErrorIs(t, err, tracking.ErrNoSecret)
+}
@@ -30,31 +30,36 @@ func NewBanner() *Banner {
 }
 
 func (b *Banner) Banner(ctx context.Context, base string, banner ads.Banner, w http.ResponseWriter) error {
-	image, err := filesystem.NewFileFromURL(ctx, banner.Image.Full(base))
+	data, format, err := b.Image(ctx, base, banner)
 	if err != nil {
 		return err
 	}
 
-	buffer, err := image.Reader.Open()
-	if err != nil {
+	w.Header().Set("Content-Length", fmt.Sprint(data.Len()))
+	w.Header().Set("Content-Type", "image/"+format)
+
+	if _, err = io.Copy(w, data); err != nil {
 		return err
 	}
 
-	defer buffer.Close()
+	return nil
+}
 
-	data, format, err := b.Render(buffer, banner.Description, banner.Title)
+// Image рисует баннер и возвращает картинку с ее форматом
+func (b *Banner) Image(ctx context.Context, base string, banner ads.Banner) (*bytes.Buffer, string, error) {
+	image, err := filesystem.NewFileFromURL(ctx, banner.Image.Full(base))
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 
-	w.Header().Set("Content-Length", fmt.Sprint(data.Len()))
-	w.Header().Set("Content-Type", "image/"+format)
-
-	if _, err = io.Copy(w, data); err != nil {
-		return err
+	buffer, err := image.Reader.Open()
+	if err != nil {
+		return nil, "", err
 	}
 
-	return nil
+	defer buffer.Close()
+
+	return b.Render(buffer, banner.Description, banner.Title)
 }
 
 func (b *Banner) Render(file io.Reader, description, info string) (*bytes.Buffer, string, error) {
@@ -1,22 +1,30 @@
 package static
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
+	"html"
 	"net/http"
+	"net/url"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
 
 	"go.ads.coffee/platform/server/internal/analytics"
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 	"go.ads.coffee/platform/server/internal/sessions"
 	"go.ads.coffee/platform/server/plugins/outputs/static/formats"
-	"go.uber.org/fx"
 )
 
 const (
-	baseUrlKey = "base"
-	actionImg  = "img"
-	actionKey  = "action"
+	baseUrlKey  = "base"
+	actionImg   = "img"
+	actionFrame = "frame"
+	actionClick = "click"
+	actionKey   = "action"
 )
 
 var Module = fx.Module(
@@ -38,25 +46,29 @@ type Analytics interface {
 
 type Banner interface {
 	Banner(ctx context.Context, base string, banner ads.Banner, w http.ResponseWriter) error
+	Image(ctx context.Context, base string, banner ads.Banner) (*bytes.Buffer, string, error)
 }
 
 type Session interface {
-	Start(r *http.Request, value string) error
+	Start(r *http.Request, session sessions.Session) (string, error)
 }
 
 type Static struct {
 	base      string
+	logger    *zap.Logger
 	sessions  Session
 	analytics Analytics
 	format    Banner
 }
 
 func New(
+	logger *zap.Logger,
 	format *formats.Banner,
 	sessions *sessions.Sessions,
 	analytics *analytics.Analytics,
 ) *Static {
 	return &Static{
+		logger:    logger,
 		format:    format,
 		sessions:  sessions,
 		analytics: analytics,
@@ -68,25 +80,28 @@ func (w *Static) Name() string {
 }
 
 func (w *Static) Copy(cfg map[string]any) plugins.Output {
-	base := ""
-
-	if cfg != nil {
-		base = cfg[baseUrlKey].(string)
-	}
+	base, _ := cfg[baseUrlKey].(string)
 
 	return &Static{
 		base:      base,
+		logger:    w.logger,
 		format:    w.format,
 		sessions:  w.sessions,
 		analytics: w.analytics,
 	}
 }
 
+func (w *Static) Schema() plugins.Schema {
+	return plugins.Schema{
+		{Name: baseUrlKey, Type: plugins.TypeString, Required: true},
+	}
+}
+
 func (w *Static) Do(ctx context.Context, state *plugins.State) error {
 	action := state.Value(actionKey).(string)
 
-	// сюда мы попадаем только для экшена img
-	if action != actionImg {
+	// сюда мы попадаем только для экшенов img и frame
+	if action != actionImg && action != actionFrame {
 		state.Response.WriteHeader(http.StatusNotFound)
 
 		return nil
@@ -100,12 +115,47 @@ func (w *Static) Do(ctx context.Context, state *plugins.State) error {
 
 	banner := state.Winners[0]
 
-	if err := w.sessions.Start(state.Request, banner.ID); err != nil {
-		return fmt.Errorf("error on start session: %w", err)
+	token, err := w.sessions.Start(state.Request, sessions.Session{
+		Value:   banner.ID,
+		ClickID: state.ClickID,
+	})
+	if err != nil {
+		// без сессии потеряем только клик, показ все равно отдаем
+		w.logger.Warn("error on start session", zap.Error(err))
 	}
 
 	// check error
-	_ = w.analytics.LogImpression(ctx, ads.TrackerInfo{})
+	_ = w.analytics.LogImpression(ctx, state.TrackerInfo(banner))
+
+	if action == actionFrame {
+		return w.frame(ctx, banner, token, state.Response)
+	}
 
 	return w.format.Banner(ctx, w.base, banner, state.Response)
 }
+
+// frame отдает html для iframe: картинку и ссылку клика с токеном.
+// Картинка из img не может передать токен в ссылку паблишера
+func (w *Static) frame(ctx context.Context, banner ads.Banner, token string, rw http.ResponseWriter) error {
+	data, format, err := w.format.Image(ctx, w.base, banner)
+	if err != nil {
+		return err
+	}
+
+	// ссылка относительная: /banner/{placement}/frame -> /banner/{placement}/click
+	link := actionClick
+	if token != "" {
+		link += "?" + url.Values{sessions.ParamToken: {token}}.Encode()
+	}
+
+	src := "data:image/" + format + ";base64," + base64.StdEncoding.EncodeToString(data.Bytes())
+
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	_, err = fmt.Fprintf(rw,
+		`<a href="%s" target="_blank"><img src="%s" alt="%s" border="0"/></a>`,
+		html.EscapeString(link), src, html.EscapeString(banner.Title),
+	)
+
+	return err
+}
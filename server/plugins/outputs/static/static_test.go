@@ -2,6 +2,7 @@
 package static
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/http"
@@ -12,6 +13,8 @@ import (
 	"github.com/stretchr/testify/mock"
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/sessions"
+	"go.uber.org/zap"
 )
 
 // Mock implementations for testing
@@ -19,9 +22,9 @@ type MockSession struct {
 	mock.Mock
 }
 
-func (m *MockSession) Start(r *http.Request, value string) error {
-	args := m.Called(r, value)
-	return args.Error(0)
+func (m *MockSession) Start(r *http.Request, session sessions.Session) (string, error) {
+	args := m.Called(r, session)
+	return args.String(0), args.Error(1)
 }
 
 type MockAnalytics struct {
@@ -42,6 +45,11 @@ func (m *MockBanner) Banner(ctx context.Context, base string, banner ads.Banner,
 	return args.Error(0)
 }
 
+func (m *MockBanner) Image(ctx context.Context, base string, banner ads.Banner) (*bytes.Buffer, string, error) {
+	args := m.Called(ctx, base, banner)
+	return args.Get(0).(*bytes.Buffer), args.String(1), args.Error(2)
+}
+
 func TestStatic_Name(t *testing.T) {
 	static := &Static{}
 	name := static.Name()
@@ -113,6 +121,7 @@ func TestStatic_Do_SessionStartError(t *testing.T) {
 	mockBanner := new(MockBanner)
 
 	static := &Static{
+		logger:    zap.NewNop(),
 		sessions:  mockSession,
 		analytics: mockAnalytics,
 		format:    mockBanner,
@@ -139,14 +148,17 @@ func TestStatic_Do_SessionStartError(t *testing.T) {
 	}
 
 	// Set up mock expectations
-	mockSession.On("Start", req, "test-banner-id").Return(fmt.Errorf("session error"))
+	mockSession.On("Start", req, sessions.Session{Value: "test-banner-id"}).Return("", fmt.Errorf("session error"))
+	mockAnalytics.On("LogImpression", mock.Anything, mock.Anything).Return(nil)
+	mockBanner.On("Banner", mock.Anything, "http://example.com", banner, rr).Return(nil)
 
+	// ошибка сессии не мешает показу
 	err := static.Do(context.Background(), state)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "error on start session")
+	assert.NoError(t, err)
 
 	// Assert that the mock expectations were met
 	mockSession.AssertExpectations(t)
+	mockBanner.AssertExpectations(t)
 }
 
 func TestStatic_Do_Success(t *testing.T) {
@@ -182,8 +194,10 @@ func TestStatic_Do_Success(t *testing.T) {
 	}
 
 	// Set up mock expectations
-	mockSession.On("Start", req, "test-banner-id").Return(nil)
-	mockAnalytics.On("LogImpression", mock.Anything, ads.TrackerInfo{}).Return(nil)
+	mockSession.On("Start", req, sessions.Session{Value: "test-banner-id"}).Return("", nil)
+	mockAnalytics.On("LogImpression", mock.Anything, mock.MatchedBy(func(info ads.TrackerInfo) bool {
+		return info.BannerID == "test-banner-id"
+	})).Return(nil)
 	mockBanner.On("Banner", mock.Anything, "http://example.com", banner, rr).Return(nil)
 
 	err := static.Do(context.Background(), state)
@@ -194,3 +208,44 @@ func TestStatic_Do_Success(t *testing.T) {
 	mockAnalytics.AssertExpectations(t)
 	mockBanner.AssertExpectations(t)
 }
+
+func TestStatic_Do_ClickToken(t *testing.T) {
+	mockSession := new(MockSession)
+	mockAnalytics := new(MockAnalytics)
+	mockBanner := new(MockBanner)
+
+	static := &Static{
+		base:      "http://example.com",
+		sessions:  mockSession,
+		analytics: mockAnalytics,
+		format:    mockBanner,
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "action", "frame"))
+
+	rr := httptest.NewRecorder()
+
+	banner := ads.Banner{ID: "test-banner-id", Title: "Title"}
+
+	state := &plugins.State{
+		ClickID:  "click-1",
+		Request:  req,
+		Response: rr,
+		Winners:  []ads.Banner{banner},
+	}
+
+	mockSession.On("Start", req, sessions.Session{Value: "test-banner-id", ClickID: "click-1"}).Return("signed+token", nil)
+	mockAnalytics.On("LogImpression", mock.Anything, mock.Anything).Return(nil)
+	mockBanner.On("Image", mock.Anything, "http://example.com", banner).Return(bytes.NewBufferString("png"), "png", nil)
+
+	err := static.Do(context.Background(), state)
+	assert.NoError(t, err)
+
+	// токен едет в ссылке клика, картинка встроена в html
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t,
+		`<a href="click?token=signed%2Btoken" target="_blank"><img src="data:image/png;base64,cG5n" alt="Title" border="0"/></a>`,
+		rr.Body.String(),
+	)
+}
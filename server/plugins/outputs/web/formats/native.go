@@ -2,18 +2,30 @@ package formats
 
 import (
 	"context"
+	"fmt"
 
+	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/tracking"
 )
 
 const TypeNative = "native"
 
+type Signer interface {
+	Sign(link string) (string, error)
+}
+
 type Native struct {
-	base string
+	base   string
+	fanout bool
+	signer Signer
 }
 
-func NewNative() *Native {
-	return &Native{}
+func NewNative(signer *tracking.Signer, cfg tracking.Config) *Native {
+	return &Native{
+		fanout: cfg.Fanout,
+		signer: signer,
+	}
 }
 
 func (b *Native) Name() string {
@@ -27,30 +39,64 @@ type NativeResponse struct {
 	Target      string   `json:"target"`
 	Impressions []string `json:"impressions"`
 	Clicks      []string `json:"click"`
+	// креатив сети медиации, полученный с сервера
+	Markup string `json:"markup,omitempty"`
 }
 
 func (b *Native) Copy(cfg map[string]any) plugins.Format {
 	base, _ := cfg["base"].(string)
 
 	return &Native{
-		base: base,
+		base:   base,
+		fanout: b.fanout,
+		signer: b.signer,
 	}
 }
 
 func (b *Native) Render(ctx context.Context, state *plugins.State) (any, error) {
 	items := []NativeResponse{}
 
-	for _, b := range state.Winners {
+	for _, w := range state.Winners {
+		info := state.TrackerInfo(w)
+
+		impressions, err := b.trackers(ads.ActionImpression, info, w.Imptracker)
+		if err != nil {
+			return nil, err
+		}
+
+		clicks, err := b.trackers(ads.ActionCLick, info, w.Clicktracker)
+		if err != nil {
+			return nil, err
+		}
+
 		items = append(items, NativeResponse{
-			Title:       b.Title,
-			Description: b.Description,
-			Target:      b.Target,
-			Image:       b.Image.Full(""),
+			Title:       w.Title,
+			Description: w.Description,
+			Target:      tracking.Target(w.Target, info.ClickID),
+			Image:       w.Image.Full(""),
 
-			Impressions: []string{}, // TODO: make trackers
-			Clicks:      []string{},
+			Impressions: impressions,
+			Clicks:      clicks,
+			Markup:      w.Markup,
 		})
 	}
 
 	return items, nil
 }
+
+// trackers возвращает подписанную ссылку на наш трекер
+// и трекер рекламодателя, если он задан и его не вызывает сервер
+func (b *Native) trackers(action string, info ads.TrackerInfo, advertiser string) ([]string, error) {
+	link, err := b.signer.Sign(tracking.URL(b.base, action, info))
+	if err != nil {
+		return nil, fmt.Errorf("error on sign %s tracker: %w", action, err)
+	}
+
+	links := []string{link}
+
+	if advertiser != "" && !b.fanout {
+		links = append(links, advertiser)
+	}
+
+	return links, nil
+}
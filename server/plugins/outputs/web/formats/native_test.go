@@ -2,13 +2,33 @@ package formats
 
 import (
 	"context"
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/tracking"
 )
 
+const testBase = "http://ads.coffee/tracker"
+
+var testSigner, _ = tracking.NewSigner(tracking.Config{Secret: "secret"})
+
+func newTestNative() *Native {
+	signer := testSigner
+
+	return NewNative(signer, tracking.Config{}).Copy(map[string]any{"base": testBase}).(*Native)
+}
+
+func action(t *testing.T, link string) string {
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+
+	return u.Query().Get(tracking.ParamAction)
+}
+
 func TestNative_Name(t *testing.T) {
 	// Arrange
 	native := &Native{}
@@ -58,7 +78,7 @@ func TestNative_Copy_WithEmptyConfig(t *testing.T) {
 
 func TestNative_Render_WithEmptyWinners(t *testing.T) {
 	// Arrange
-	native := &Native{}
+	native := newTestNative()
 	ctx := context.Background()
 	state := &plugins.State{
 		Winners: []ads.Banner{},
@@ -79,7 +99,7 @@ func TestNative_Render_WithEmptyWinners(t *testing.T) {
 
 func TestNative_Render_WithOneWinner(t *testing.T) {
 	// Arrange
-	native := &Native{}
+	native := newTestNative()
 	ctx := context.Background()
 
 	// Create a test image
@@ -115,14 +135,14 @@ func TestNative_Render_WithOneWinner(t *testing.T) {
 	assert.Equal(t, "https://example.com/click", item.Target)
 	assert.Equal(t, "/test/image.jpg", item.Image) // base URL is prepended
 
-	// Check that trackers are empty arrays
-	assert.Empty(t, item.Impressions)
-	assert.Empty(t, item.Clicks)
+	// только наши трекеры, у рекламодателя их нет
+	assert.Len(t, item.Impressions, 1)
+	assert.Len(t, item.Clicks, 1)
 }
 
 func TestNative_Render_WithMultipleWinners(t *testing.T) {
 	// Arrange
-	native := &Native{}
+	native := newTestNative()
 	ctx := context.Background()
 
 	// Create test images
@@ -165,9 +185,8 @@ func TestNative_Render_WithMultipleWinners(t *testing.T) {
 	assert.Equal(t, "https://example.com/click1", item1.Target)
 	assert.Equal(t, "/test/image1.jpg", item1.Image)
 
-	// Check that trackers are empty arrays
-	assert.Empty(t, item1.Impressions)
-	assert.Empty(t, item1.Clicks)
+	assert.Len(t, item1.Impressions, 1)
+	assert.Len(t, item1.Clicks, 1)
 
 	// Check the second item properties
 	item2 := items[1]
@@ -176,14 +195,13 @@ func TestNative_Render_WithMultipleWinners(t *testing.T) {
 	assert.Equal(t, "https://example.com/click2", item2.Target)
 	assert.Equal(t, "/test/image2.jpg", item2.Image)
 
-	// Check that trackers are empty arrays
-	assert.Empty(t, item2.Impressions)
-	assert.Empty(t, item2.Clicks)
+	assert.Len(t, item2.Impressions, 1)
+	assert.Len(t, item2.Clicks, 1)
 }
 
 func TestNative_Render_WithEmptyFields(t *testing.T) {
 	// Arrange
-	native := &Native{}
+	native := newTestNative()
 	ctx := context.Background()
 
 	// Create an empty image
@@ -219,7 +237,102 @@ func TestNative_Render_WithEmptyFields(t *testing.T) {
 	assert.Equal(t, "", item.Target)
 	assert.Equal(t, "", item.Image) // Just the protocol prefix
 
-	// Check that trackers are empty arrays
-	assert.Empty(t, item.Impressions)
-	assert.Empty(t, item.Clicks)
+	// только наши трекеры, у рекламодателя их нет
+	assert.Len(t, item.Impressions, 1)
+	assert.Len(t, item.Clicks, 1)
+}
+
+func TestNative_Render_Trackers(t *testing.T) {
+	native := newTestNative()
+
+	state := &plugins.State{
+		RequestID: "request-1",
+		ClickID:   "click-1",
+		Winners: []ads.Banner{
+			{
+				ID:           "banner-1",
+				GroupID:      "group-1",
+				CampaignID:   "campaign-1",
+				AdvertiserID: "advertiser-1",
+				Price:        15,
+				Imptracker:   "https://advertiser.com/imp",
+				Clicktracker: "https://advertiser.com/click",
+			},
+		},
+	}
+
+	result, err := native.Render(context.Background(), state)
+	require.NoError(t, err)
+
+	items := result.([]NativeResponse)
+	require.Len(t, items, 1)
+
+	require.Len(t, items[0].Impressions, 2)
+	assert.Equal(t, "https://advertiser.com/imp", items[0].Impressions[1])
+
+	require.Len(t, items[0].Clicks, 2)
+	assert.Equal(t, "https://advertiser.com/click", items[0].Clicks[1])
+
+	signer := testSigner
+
+	u, err := url.Parse(items[0].Impressions[0])
+	require.NoError(t, err)
+	require.NoError(t, signer.Verify(u.Query()))
+
+	action, info, err := tracking.Parse(u.Query())
+	require.NoError(t, err)
+
+	assert.Equal(t, ads.ActionImpression, action)
+	assert.Equal(t, "request-1", info.RequestID)
+	assert.Equal(t, "click-1", info.ClickID)
+	assert.Equal(t, "banner-1", info.BannerID)
+	assert.Equal(t, "group-1", info.GroupID)
+	assert.Equal(t, "campaign-1", info.CampaignID)
+	assert.Equal(t, "advertiser-1", info.AdvertiserID)
+	assert.Equal(t, 15.0, info.Price)
+
+	u, err = url.Parse(items[0].Clicks[0])
+	require.NoError(t, err)
+
+	action, _, err = tracking.Parse(u.Query())
+	require.NoError(t, err)
+	assert.Equal(t, ads.ActionCLick, action)
+}
+
+func TestNative_Render_Fanout(t *testing.T) {
+	signer := testSigner
+	native := NewNative(signer, tracking.Config{Fanout: true}).Copy(map[string]any{"base": testBase})
+
+	state := &plugins.State{
+		Winners: []ads.Banner{
+			{
+				ID:           "banner-1",
+				Imptracker:   "https://advertiser.com/imp",
+				Clicktracker: "https://advertiser.com/click",
+			},
+		},
+	}
+
+	result, err := native.Render(context.Background(), state)
+	require.NoError(t, err)
+
+	items := result.([]NativeResponse)
+	require.Len(t, items, 1)
+
+	// трекеры рекламодателя вызовет сервер, клиенту только наши
+	require.Len(t, items[0].Impressions, 1)
+	assert.Equal(t, ads.ActionImpression, action(t, items[0].Impressions[0]))
+	require.Len(t, items[0].Clicks, 1)
+	assert.Equal(t, ads.ActionCLick, action(t, items[0].Clicks[0]))
+}
+
+func TestNative_Render_WithoutSecret(t *testing.T) {
+	native := NewNative(&tracking.Signer{}, tracking.Config{})
+
+	state := &plugins.State{
+		Winners: []ads.Banner{{ID: "banner-1"}},
+	}
+
+	_, err := native.Render(context.Background(), state)
+	assert.ErrorIs(t, err, tracking.ErrNoSecret)
 }
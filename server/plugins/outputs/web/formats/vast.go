@@ -0,0 +1,276 @@
+package formats
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/domain/vast"
+	"go.ads.coffee/platform/server/internal/tracking"
+)
+
+const (
+	TypeVast = "vast"
+
+	// версия VAST из конфига, плеер может попросить 3.0 параметром
+	VersionKey   = "vast_version"
+	versionParam = "vast"
+
+	adSystem = "adscoffee"
+)
+
+// event - событие плеера и экшен трекера, который оно вызывает
+type event struct {
+	name   string
+	action string
+}
+
+var (
+	events = []event{
+		{vast.EventStart, ads.ActionStart},
+		{vast.EventFirstQuartile, ads.ActionFirstQuartile},
+		{vast.EventMidpoint, ads.ActionMidpoint},
+		{vast.EventThirdQuartile, ads.ActionThirdQuartile},
+		{vast.EventComplete, ads.ActionComplete},
+	}
+
+	// пропуск отслеживаем, только если креатив можно пропустить
+	skip = event{vast.EventSkip, ads.ActionSkip}
+)
+
+// Vast отдает видеокреативы в VAST. Показы, клики и события
+// плеера идут в наш трекер, блоки медиации заворачиваются
+// в Wrapper со ссылкой на VAST сети
+type Vast struct {
+	base    string
+	version string
+	fanout  bool
+	signer  Signer
+}
+
+func NewVast(signer *tracking.Signer, cfg tracking.Config) *Vast {
+	return &Vast{
+		version: vast.Version4,
+		fanout:  cfg.Fanout,
+		signer:  signer,
+	}
+}
+
+func (b *Vast) Name() string {
+	return TypeVast
+}
+
+func (b *Vast) Copy(cfg map[string]any) plugins.Format {
+	base, _ := cfg["base"].(string)
+
+	version, _ := cfg[VersionKey].(string)
+	if version == "" {
+		version = vast.Version4
+	}
+
+	return &Vast{
+		base:    base,
+		version: version,
+		fanout:  b.fanout,
+		signer:  b.signer,
+	}
+}
+
+func (b *Vast) Render(ctx context.Context, state *plugins.State) (any, error) {
+	doc := vast.New(b.choose(state))
+
+	for _, w := range state.Winners {
+		var (
+			ad  vast.Ad
+			ok  bool
+			err error
+		)
+
+		if w.Type == ads.CreativeTypeMediator || w.Markup != "" {
+			ad, ok, err = b.wrapper(state, w)
+		} else {
+			ad, ok, err = b.inline(doc, state, w)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			doc.Ads = append(doc.Ads, ad)
+		}
+	}
+
+	return doc, nil
+}
+
+// choose - версия из параметра запроса, иначе из конфига
+func (b *Vast) choose(state *plugins.State) string {
+	if state.Request != nil {
+		switch state.Request.URL.Query().Get(versionParam) {
+		case "3", vast.Version3:
+			return vast.Version3
+		case "4", vast.Version4:
+			return vast.Version4
+		}
+	}
+
+	return b.version
+}
+
+// inline - линейный креатив из видео баннера
+func (b *Vast) inline(doc vast.VAST, state *plugins.State, w ads.Banner) (vast.Ad, bool, error) {
+	if len(w.Video.MediaFiles) == 0 {
+		return vast.Ad{}, false, nil
+	}
+
+	info := state.TrackerInfo(w)
+
+	common, err := b.common(info, w, w.Video.SkipOffset > 0)
+	if err != nil {
+		return vast.Ad{}, false, err
+	}
+
+	linear := common.linear
+	linear.Duration = vast.Duration(w.Video.Duration)
+	linear.VideoClicks.ClickThrough = &vast.CDATA{Value: tracking.Target(w.Target, info.ClickID)}
+
+	if w.Video.SkipOffset > 0 {
+		linear.SkipOffset = vast.Duration(w.Video.SkipOffset)
+	}
+
+	for _, f := range w.Video.MediaFiles {
+		delivery := vast.DeliveryProgressive
+		if f.Streaming() {
+			delivery = vast.DeliveryStreaming
+		}
+
+		linear.MediaFiles = append(linear.MediaFiles, vast.MediaFile{
+			Delivery: delivery,
+			Type:     f.Type,
+			Width:    f.Width,
+			Height:   f.Height,
+			URL:      f.URL,
+		})
+	}
+
+	creative := vast.Creative{
+		ID:     w.ID,
+		AdID:   w.ID,
+		Linear: &linear,
+	}
+
+	inline := &vast.InLine{
+		AdSystem:    vast.AdSystem{Name: adSystem},
+		AdTitle:     w.Title,
+		Description: w.Description,
+		Errors:      common.errors,
+		Impressions: common.impressions,
+	}
+
+	if doc.V4() {
+		inline.AdServingID = state.RequestID
+		creative.UniversalAdIDs = []vast.UniversalAdID{{IDRegistry: "unknown", ID: w.ID}}
+	}
+
+	inline.Creatives = []vast.Creative{creative}
+
+	return vast.Ad{ID: w.ID, InLine: inline}, true, nil
+}
+
+// wrapper - блок медиации, креатив плеер возьмет по ссылке из
+// разметки сети. Разметку без ссылки в Wrapper не завернуть
+func (b *Vast) wrapper(state *plugins.State, w ads.Banner) (vast.Ad, bool, error) {
+	if !strings.HasPrefix(w.Markup, "http://") && !strings.HasPrefix(w.Markup, "https://") {
+		return vast.Ad{}, false, nil
+	}
+
+	common, err := b.common(state.TrackerInfo(w), w, true)
+	if err != nil {
+		return vast.Ad{}, false, err
+	}
+
+	return vast.Ad{
+		ID: w.ID,
+		Wrapper: &vast.Wrapper{
+			FollowAdditionalWrappers: true,
+			AdSystem:                 vast.AdSystem{Name: adSystem},
+			Errors:                   common.errors,
+			Impressions:              common.impressions,
+			AdTagURI:                 vast.CDATA{Value: w.Markup},
+			Creatives:                []vast.Creative{{Linear: &common.linear}},
+		},
+	}, true, nil
+}
+
+type trackers struct {
+	errors      []vast.CDATA
+	impressions []vast.Impression
+	linear      vast.Linear
+}
+
+// common собирает трекеры, общие для InLine и Wrapper
+func (b *Vast) common(info ads.TrackerInfo, w ads.Banner, skippable bool) (trackers, error) {
+	t := trackers{
+		linear: vast.Linear{VideoClicks: &vast.VideoClicks{}},
+	}
+
+	// код ошибки подставит плеер после подписи, он в нее не входит
+	errorLink, err := b.sign(ads.ActionError, info)
+	if err != nil {
+		return t, err
+	}
+
+	t.errors = []vast.CDATA{{
+		Value: tracking.Macro(errorLink, tracking.ParamReason, vast.MacroErrorCode),
+	}}
+
+	impression, err := b.sign(ads.ActionImpression, info)
+	if err != nil {
+		return t, err
+	}
+
+	t.impressions = []vast.Impression{{URL: impression}}
+	// при fanout трекеры рекламодателя вызывает сервер
+	if w.Imptracker != "" && !b.fanout {
+		t.impressions = append(t.impressions, vast.Impression{URL: w.Imptracker})
+	}
+
+	click, err := b.sign(ads.ActionCLick, info)
+	if err != nil {
+		return t, err
+	}
+
+	t.linear.VideoClicks.ClickTracking = []vast.CDATA{{Value: click}}
+	if w.Clicktracker != "" && !b.fanout {
+		t.linear.VideoClicks.ClickTracking = append(t.linear.VideoClicks.ClickTracking, vast.CDATA{Value: w.Clicktracker})
+	}
+
+	list := events
+	if skippable {
+		list = append(slices.Clip(list), skip)
+	}
+
+	for _, e := range list {
+		link, err := b.sign(e.action, info)
+		if err != nil {
+			return t, err
+		}
+
+		t.linear.TrackingEvents = append(t.linear.TrackingEvents, vast.Tracking{Event: e.name, URL: link})
+	}
+
+	return t, nil
+}
+
+func (b *Vast) sign(action string, info ads.TrackerInfo) (string, error) {
+	link, err := b.signer.Sign(tracking.URL(b.base, action, info))
+	if err != nil {
+		return "", fmt.Errorf("error on sign %s tracker: %w", action, err)
+	}
+
+	return link, nil
+}
@@ -0,0 +1,251 @@
+package formats
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/domain/vast"
+	"go.ads.coffee/platform/server/internal/tracking"
+)
+
+func newTestVast(cfg map[string]any) *Vast {
+	signer := testSigner
+
+	cfg["base"] = testBase
+
+	return NewVast(signer, tracking.Config{}).Copy(cfg).(*Vast)
+}
+
+func newVastState(target string, winners ...ads.Banner) *plugins.State {
+	return &plugins.State{
+		RequestID: "request-1",
+		ClickID:   "click-1",
+		Request:   httptest.NewRequest(http.MethodGet, target, nil),
+		Winners:   winners,
+	}
+}
+
+func videoBanner() ads.Banner {
+	return ads.Banner{
+		ID:           "banner-1",
+		Title:        "Title",
+		Description:  "Description",
+		Type:         ads.CreativeTypeVideo,
+		Target:       "https://example.com/landing?click={click_id}",
+		Imptracker:   "https://advertiser.com/imp",
+		Clicktracker: "https://advertiser.com/click",
+		Video: ads.Video{
+			Duration:   15 * time.Second,
+			SkipOffset: 5 * time.Second,
+			MediaFiles: []ads.MediaFile{
+				{URL: "https://cdn.example.com/video.mp4", Type: "video/mp4", Width: 640, Height: 360},
+				{URL: "https://cdn.example.com/video.m3u8", Type: "application/x-mpegURL", Width: 640, Height: 360},
+			},
+		},
+	}
+}
+
+func TestVast_Name(t *testing.T) {
+	assert.Equal(t, "vast", (&Vast{}).Name())
+}
+
+func TestVast_Copy(t *testing.T) {
+	v := newTestVast(map[string]any{})
+
+	assert.Equal(t, testBase, v.base)
+	assert.Equal(t, vast.Version4, v.version)
+
+	v = newTestVast(map[string]any{VersionKey: vast.Version3})
+
+	assert.Equal(t, vast.Version3, v.version)
+}
+
+func TestVast_Render_InLine(t *testing.T) {
+	v := newTestVast(map[string]any{})
+
+	result, err := v.Render(context.Background(), newVastState("/video/placement-1", videoBanner()))
+	require.NoError(t, err)
+
+	doc := result.(vast.VAST)
+
+	assert.Equal(t, vast.Version4, doc.Version)
+	require.Len(t, doc.Ads, 1)
+
+	inline := doc.Ads[0].InLine
+	require.NotNil(t, inline)
+
+	assert.Equal(t, "banner-1", doc.Ads[0].ID)
+	assert.Equal(t, "request-1", inline.AdServingID)
+	assert.Equal(t, "Title", inline.AdTitle)
+
+	// наш подписанный показ и трекер рекламодателя
+	require.Len(t, inline.Impressions, 2)
+	assert.Equal(t, ads.ActionImpression, action(t, inline.Impressions[0].URL))
+	assert.Contains(t, inline.Impressions[0].URL, tracking.ParamSignature+"=")
+	assert.Equal(t, "https://advertiser.com/imp", inline.Impressions[1].URL)
+
+	require.Len(t, inline.Errors, 1)
+	assert.Contains(t, inline.Errors[0].Value, "reason="+vast.MacroErrorCode)
+	assert.Contains(t, inline.Errors[0].Value, tracking.ParamSignature+"=")
+
+	require.Len(t, inline.Creatives, 1)
+	creative := inline.Creatives[0]
+	require.Len(t, creative.UniversalAdIDs, 1)
+
+	linear := creative.Linear
+	require.NotNil(t, linear)
+
+	assert.Equal(t, "00:00:15.000", linear.Duration)
+	assert.Equal(t, "00:00:05.000", linear.SkipOffset)
+
+	events := map[string]string{}
+	for _, e := range linear.TrackingEvents {
+		events[e.Event] = action(t, e.URL)
+	}
+
+	assert.Equal(t, map[string]string{
+		vast.EventStart:         ads.ActionStart,
+		vast.EventFirstQuartile: ads.ActionFirstQuartile,
+		vast.EventMidpoint:      ads.ActionMidpoint,
+		vast.EventThirdQuartile: ads.ActionThirdQuartile,
+		vast.EventComplete:      ads.ActionComplete,
+		vast.EventSkip:          ads.ActionSkip,
+	}, events)
+
+	require.NotNil(t, linear.VideoClicks.ClickThrough)
+	assert.Equal(t, "https://example.com/landing?click=click-1", linear.VideoClicks.ClickThrough.Value)
+	require.Len(t, linear.VideoClicks.ClickTracking, 2)
+	assert.Equal(t, ads.ActionCLick, action(t, linear.VideoClicks.ClickTracking[0].Value))
+
+	require.Len(t, linear.MediaFiles, 2)
+	assert.Equal(t, vast.DeliveryProgressive, linear.MediaFiles[0].Delivery)
+	assert.Equal(t, "video/mp4", linear.MediaFiles[0].Type)
+	assert.Equal(t, 640, linear.MediaFiles[0].Width)
+	assert.Equal(t, vast.DeliveryStreaming, linear.MediaFiles[1].Delivery)
+}
+
+func TestVast_Render_Fanout(t *testing.T) {
+	signer := testSigner
+	v := NewVast(signer, tracking.Config{Fanout: true}).Copy(map[string]any{"base": testBase})
+
+	result, err := v.Render(context.Background(), newVastState("/video/placement-1", videoBanner()))
+	require.NoError(t, err)
+
+	inline := result.(vast.VAST).Ads[0].InLine
+	require.NotNil(t, inline)
+
+	// трекеры рекламодателя вызовет сервер
+	require.Len(t, inline.Impressions, 1)
+	assert.Equal(t, ads.ActionImpression, action(t, inline.Impressions[0].URL))
+
+	linear := inline.Creatives[0].Linear
+	require.NotNil(t, linear)
+	require.Len(t, linear.VideoClicks.ClickTracking, 1)
+	assert.Equal(t, ads.ActionCLick, action(t, linear.VideoClicks.ClickTracking[0].Value))
+}
+
+func TestVast_Render_Version3(t *testing.T) {
+	banner := videoBanner()
+	banner.Video.SkipOffset = 0
+
+	// версию можно задать в конфиге или попросить в запросе
+	for _, tt := range []struct {
+		name    string
+		version string
+		target  string
+	}{
+		{name: "config", version: vast.Version3, target: "/video/placement-1"},
+		{name: "param", version: vast.Version4, target: "/video/placement-1?vast=3"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVast(map[string]any{VersionKey: tt.version})
+
+			result, err := v.Render(context.Background(), newVastState(tt.target, banner))
+			require.NoError(t, err)
+
+			doc := result.(vast.VAST)
+
+			assert.Equal(t, vast.Version3, doc.Version)
+			assert.Empty(t, doc.XMLNS)
+			require.Len(t, doc.Ads, 1)
+
+			inline := doc.Ads[0].InLine
+			assert.Empty(t, inline.AdServingID)
+			assert.Empty(t, inline.Creatives[0].UniversalAdIDs)
+
+			// креатив нельзя пропустить
+			linear := inline.Creatives[0].Linear
+			assert.Empty(t, linear.SkipOffset)
+
+			for _, e := range linear.TrackingEvents {
+				assert.NotEqual(t, vast.EventSkip, e.Event)
+			}
+		})
+	}
+}
+
+func TestVast_Render_Wrapper(t *testing.T) {
+	v := newTestVast(map[string]any{})
+
+	unit := ads.Banner{
+		ID:      "unit-1",
+		Type:    ads.CreativeTypeVideo,
+		Network: "network-1",
+		Markup:  "https://network.example.com/vast?id=1",
+	}
+
+	result, err := v.Render(context.Background(), newVastState("/video/placement-1", unit))
+	require.NoError(t, err)
+
+	doc := result.(vast.VAST)
+	require.Len(t, doc.Ads, 1)
+
+	wrapper := doc.Ads[0].Wrapper
+	require.NotNil(t, wrapper)
+	assert.Nil(t, doc.Ads[0].InLine)
+
+	assert.Equal(t, "https://network.example.com/vast?id=1", wrapper.AdTagURI.Value)
+	assert.True(t, wrapper.FollowAdditionalWrappers)
+	require.Len(t, wrapper.Impressions, 1)
+	assert.Equal(t, ads.ActionImpression, action(t, wrapper.Impressions[0].URL))
+
+	require.Len(t, wrapper.Creatives, 1)
+	linear := wrapper.Creatives[0].Linear
+	assert.Empty(t, linear.MediaFiles)
+	assert.Len(t, linear.TrackingEvents, 6)
+	assert.Nil(t, linear.VideoClicks.ClickThrough)
+}
+
+func TestVast_Render_Skipped(t *testing.T) {
+	v := newTestVast(map[string]any{})
+
+	winners := []ads.Banner{
+		// баннер без видео
+		{ID: "banner-2", Type: ads.CreativeTypeBanner},
+		// клиентская медиация без ссылки на VAST
+		{ID: "unit-1", Type: ads.CreativeTypeMediator},
+		// разметка сети, а не ссылка
+		{ID: "unit-2", Type: ads.CreativeTypeVideo, Markup: "<VAST/>"},
+	}
+
+	result, err := v.Render(context.Background(), newVastState("/video/placement-1", winners...))
+	require.NoError(t, err)
+
+	assert.Empty(t, result.(vast.VAST).Ads)
+}
+
+func TestVast_Render_SignError(t *testing.T) {
+	v := NewVast(&tracking.Signer{}, tracking.Config{}).Copy(map[string]any{"base": testBase})
+
+	_, err := v.Render(context.Background(), newVastState("/video/placement-1", videoBanner()))
+
+	assert.ErrorIs(t, err, tracking.ErrNoSecret)
+}
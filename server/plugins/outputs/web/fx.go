@@ -27,5 +27,15 @@ var Module = fx.Module(
 			fx.As(new(plugins.Format)),
 			fx.ResultTags(`group:"outputs.web.formats"`),
 		),
+
+		formats.NewVast,
+
+		fx.Annotate(
+			func(v *formats.Vast) plugins.Format {
+				return v
+			},
+			fx.As(new(plugins.Format)),
+			fx.ResultTags(`group:"outputs.web.formats"`),
+		),
 	),
 )
@@ -4,16 +4,33 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"go.ads.coffee/platform/server/internal/analytics"
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/domain/vast"
+	"go.ads.coffee/platform/server/plugins/outputs/web/formats"
+)
+
+const (
+	formatKey = "format"
+	baseKey   = "base"
+
+	defaultFormat = "native"
 )
 
 type Analytics interface {
 	LogResponse(ctx context.Context, w ads.Banner, state *plugins.State) error
 }
 
+// Document - результат формата, который сам себя кодирует,
+// например VAST в xml. Остальные форматы отдаются в json
+type Document interface {
+	ContentType() string
+	Encode() ([]byte, error)
+}
+
 type Web struct {
 	format    string
 	formats   map[string]plugins.Format
@@ -38,9 +55,9 @@ func (w *Web) Name() string {
 }
 
 func (w *Web) Copy(cfg map[string]any) plugins.Output {
-	format := "native" // default format
-	if cfg != nil {
-		format = cfg["format"].(string)
+	format, _ := cfg[formatKey].(string)
+	if format == "" {
+		format = defaultFormat
 	}
 
 	dest := make(map[string]plugins.Format, len(w.formats))
@@ -55,6 +72,23 @@ func (w *Web) Copy(cfg map[string]any) plugins.Output {
 	}
 }
 
+// Schema - формат выбирается из зарегистрированных,
+// остальные ключи передаются в формат
+func (w *Web) Schema() plugins.Schema {
+	names := make([]string, 0, len(w.formats))
+	for name := range w.formats {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return plugins.Schema{
+		{Name: formatKey, Type: plugins.TypeString, Default: defaultFormat, Values: names},
+		{Name: baseKey, Type: plugins.TypeString},
+		{Name: formats.VersionKey, Type: plugins.TypeString, Default: vast.Version4, Values: []string{vast.Version4, vast.Version3}},
+	}
+}
+
 //nolint:errcheck
 func (w *Web) Do(ctx context.Context, state *plugins.State) error {
 	format, ok := w.formats[w.format]
@@ -67,11 +101,15 @@ func (w *Web) Do(ctx context.Context, state *plugins.State) error {
 		return fmt.Errorf("error on render format: %w", err)
 	}
 
-	data, err := json.Marshal(result)
+	data, err := encode(result)
 	if err != nil {
 		return fmt.Errorf("error on render format: %w", err)
 	}
 
+	if doc, ok := result.(Document); ok {
+		state.Response.Header().Set("Content-Type", doc.ContentType())
+	}
+
 	_, err = state.Response.Write(data)
 
 	if len(state.Winners) > 0 {
@@ -80,3 +118,11 @@ func (w *Web) Do(ctx context.Context, state *plugins.State) error {
 
 	return err
 }
+
+func encode(result any) ([]byte, error) {
+	if doc, ok := result.(Document); ok {
+		return doc.Encode()
+	}
+
+	return json.Marshal(result)
+}
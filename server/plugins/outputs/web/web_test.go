@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/domain/vast"
 )
 
 // MockFormat is a mock implementation of the plugins.Format interface
@@ -205,6 +206,37 @@ func TestWeb_Do_Success(t *testing.T) {
 	mockResponseWriter.AssertExpectations(t)
 }
 
+func TestWeb_Do_Document(t *testing.T) {
+	// Arrange
+	mockFormat := new(MockFormat)
+	mockFormat.On("Name").Return("vast")
+	mockFormat.On("Render", mock.Anything, mock.Anything).Return(vast.New(vast.Version4), nil)
+
+	mockAnalytics := new(MockAnalytics)
+
+	web := newWebWithMockAnalytics([]plugins.Format{mockFormat}, mockAnalytics)
+	web.format = "vast"
+
+	header := http.Header{}
+
+	mockResponseWriter := new(MockResponseWriter)
+	mockResponseWriter.On("Header").Return(header)
+	mockResponseWriter.On("Write", mock.Anything).Return(0, nil)
+
+	state := &plugins.State{
+		Response: mockResponseWriter,
+		Winners:  []ads.Banner{},
+	}
+
+	// Act
+	err := web.Do(context.Background(), state)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "application/xml; charset=utf-8", header.Get("Content-Type"))
+	assert.Contains(t, mockResponseWriter.Buffer.String(), `<VAST version="4.2" xmlns="http://www.iab.com/VAST"></VAST>`)
+}
+
 func TestWeb_Do_WithWinners_AnalyticsCalled(t *testing.T) {
 	// Arrange
 	mockFormat := new(MockFormat)
@@ -334,3 +366,27 @@ func TestWeb_Copy(t *testing.T) {
 
 	mockFormat.AssertExpectations(t)
 }
+
+func TestWeb_Copy_DefaultFormat(t *testing.T) {
+	web := newWebWithMockAnalytics([]plugins.Format{}, new(MockAnalytics))
+
+	// без format в конфиге не паникуем
+	copied := web.Copy(map[string]any{"base": "http://ads.coffee"})
+
+	assert.Equal(t, defaultFormat, copied.(*Web).format)
+}
+
+func TestWeb_Schema(t *testing.T) {
+	native := new(MockFormat)
+	native.On("Name").Return("native")
+
+	banner := new(MockFormat)
+	banner.On("Name").Return("banner")
+
+	web := newWebWithMockAnalytics([]plugins.Format{native, banner}, new(MockAnalytics))
+
+	schema := web.Schema()
+
+	assert.Empty(t, schema.Validate(map[string]any{"format": "banner", "base": "http://ads.coffee"}))
+	assert.Len(t, schema.Validate(map[string]any{"format": "video"}), 1)
+}
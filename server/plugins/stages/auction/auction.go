@@ -0,0 +1,206 @@
+package auction
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"go.uber.org/fx"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+var Module = fx.Module(
+	"stages.auction",
+
+	fx.Provide(
+		fx.Annotate(
+			New,
+			fx.As(new(plugins.Stage)),
+			fx.ResultTags(`group:"stages"`),
+		),
+	),
+)
+
+const (
+	typeKey      = "type"
+	incrementKey = "increment"
+	floorKey     = "floor"
+	mediationKey = "mediation"
+
+	FirstPrice  = "first"
+	SecondPrice = "second"
+
+	defaultIncrement = 0.01
+)
+
+// Auction выбирает победителя по eCPM среди кандидатов, которые
+// прошли минимальную цену, и записывает ему цену списания
+type Auction struct {
+	kind      string
+	increment float64
+	floor     float64
+	mediation bool
+	shuffle   func(n int, swap func(i, j int))
+}
+
+func New() *Auction {
+	return &Auction{
+		kind:      SecondPrice,
+		increment: defaultIncrement,
+		shuffle:   rand.Shuffle,
+	}
+}
+
+func (a *Auction) Name() string {
+	return "stages.auction"
+}
+
+func (a *Auction) Copy(cfg map[string]any) plugins.Stage {
+	kind, _ := cfg[typeKey].(string)
+	if kind == "" {
+		kind = SecondPrice
+	}
+
+	increment, ok := number(cfg[incrementKey])
+	if !ok {
+		increment = defaultIncrement
+	}
+
+	floor, _ := number(cfg[floorKey])
+	mediation, _ := cfg[mediationKey].(bool)
+
+	return &Auction{
+		kind:      kind,
+		increment: increment,
+		floor:     floor,
+		mediation: mediation,
+		shuffle:   a.shuffle,
+	}
+}
+
+func (a *Auction) Schema() plugins.Schema {
+	return plugins.Schema{
+		{Name: typeKey, Type: plugins.TypeString, Default: SecondPrice, Values: []string{FirstPrice, SecondPrice}},
+		{Name: incrementKey, Type: plugins.TypeFloat, Default: defaultIncrement},
+		{Name: floorKey, Type: plugins.TypeFloat},
+		{Name: mediationKey, Type: plugins.TypeBool, Default: false},
+	}
+}
+
+func (a *Auction) Do(ctx context.Context, state *plugins.State) error {
+	floor := a.minimum(state)
+	bids := []ads.Banner{}
+
+	for _, banner := range a.bidders(state) {
+		if banner.ECPM() < floor {
+			state.Drop(a.Name(), banner, plugins.ReasonFloor,
+				fmt.Sprintf("ecpm %s below floor %s", format(banner.ECPM()), format(floor)))
+
+			continue
+		}
+
+		bids = append(bids, banner)
+	}
+
+	if len(bids) == 0 {
+		state.Winners = nil
+
+		return nil
+	}
+
+	// при равных ставках победитель случайный
+	a.shuffle(len(bids), func(i, j int) { bids[i], bids[j] = bids[j], bids[i] })
+	slices.SortStableFunc(bids, func(x, y ads.Banner) int {
+		return cmp.Compare(y.ECPM(), x.ECPM())
+	})
+
+	winner := bids[0]
+	winner.ClearingPrice = a.clearing(bids, floor)
+
+	state.Winners = []ads.Banner{winner}
+
+	for _, banner := range bids[1:] {
+		state.Drop(a.Name(), banner, plugins.ReasonAuction, "lost to "+winner.ID)
+	}
+
+	return nil
+}
+
+// minimum - самая высокая из минимальных цен пайплайна,
+// плейсмента и запроса
+func (a *Auction) minimum(state *plugins.State) float64 {
+	floor := a.floor
+
+	if state.Placement != nil {
+		floor = max(floor, state.Placement.Floor, state.Placement.BidFloor)
+	}
+
+	return floor
+}
+
+// bidders - кандидаты и, если включено, блоки медиации плейсмента
+func (a *Auction) bidders(state *plugins.State) []ads.Banner {
+	bidders := slices.Clone(state.Candidates)
+
+	if !a.mediation || state.Placement == nil {
+		return bidders
+	}
+
+	for _, u := range state.Placement.Units {
+		bidders = append(bidders, ads.Banner{
+			ID:      u.ID,
+			Title:   u.Title,
+			Price:   u.Price,
+			Type:    ads.CreativeTypeMediator,
+			Network: u.Network,
+		})
+	}
+
+	return bidders
+}
+
+// clearing считает цену списания. В первой цене победитель платит
+// свою ставку, во второй - следующую ставку или минимальную цену
+// плюс шаг, но не больше своей ставки.
+func (a *Auction) clearing(bids []ads.Banner, floor float64) float64 {
+	winner := bids[0].ECPM()
+
+	if a.kind == FirstPrice {
+		return winner
+	}
+
+	price := floor
+	if len(bids) > 1 {
+		price = max(price, bids[1].ECPM())
+	}
+
+	return round(min(price+a.increment, winner))
+}
+
+func number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+
+	return 0, false
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func format(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
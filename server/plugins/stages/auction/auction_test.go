@@ -0,0 +1,165 @@
+package auction
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+func stage(cfg map[string]any) *Auction {
+	a := New().Copy(New().Schema().Apply(cfg)).(*Auction)
+	// без перемешивания при равных ставках побеждает первый
+	a.shuffle = func(n int, swap func(i, j int)) {}
+
+	return a
+}
+
+func TestAuction_Name(t *testing.T) {
+	assert.Equal(t, "stages.auction", New().Name())
+}
+
+func TestAuction_Copy(t *testing.T) {
+	a := New().Copy(map[string]any{"type": "first", "increment": 1, "floor": 2.5, "mediation": true}).(*Auction)
+
+	assert.Equal(t, FirstPrice, a.kind)
+	assert.Equal(t, 1.0, a.increment)
+	assert.Equal(t, 2.5, a.floor)
+	assert.True(t, a.mediation)
+
+	a = New().Copy(map[string]any{}).(*Auction)
+
+	assert.Equal(t, SecondPrice, a.kind)
+	assert.Equal(t, defaultIncrement, a.increment)
+	assert.Zero(t, a.floor)
+	assert.False(t, a.mediation)
+}
+
+func TestAuction_Schema(t *testing.T) {
+	s := New().Schema()
+
+	assert.Empty(t, s.Validate(map[string]any{"type": "second", "increment": 0.5, "floor": 10}))
+	assert.NotEmpty(t, s.Validate(map[string]any{"type": "vickrey"}))
+}
+
+func TestAuction_Do(t *testing.T) {
+	candidates := []ads.Banner{
+		{ID: "low", Price: 50},
+		{ID: "high", Price: 120},
+		{ID: "mid", Price: 100},
+	}
+
+	tests := []struct {
+		name      string
+		cfg       map[string]any
+		placement *plugins.Placement
+		winner    string
+		clearing  float64
+	}{
+		{
+			name:     "first price",
+			cfg:      map[string]any{"type": "first"},
+			winner:   "high",
+			clearing: 120,
+		},
+		{
+			name:     "second price",
+			cfg:      map[string]any{"type": "second", "increment": 0.5},
+			winner:   "high",
+			clearing: 100.5,
+		},
+		{
+			name:      "second price below placement floor",
+			cfg:       map[string]any{"increment": 1},
+			placement: &plugins.Placement{Floor: 110},
+			winner:    "high",
+			clearing:  111,
+		},
+		{
+			name:      "clearing not above bid",
+			cfg:       map[string]any{"increment": 10},
+			placement: &plugins.Placement{BidFloor: 115},
+			winner:    "high",
+			clearing:  120,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &plugins.State{
+				Candidates: candidates,
+				Placement:  tt.placement,
+			}
+
+			err := stage(tt.cfg).Do(context.Background(), state)
+			require.NoError(t, err)
+
+			require.Len(t, state.Winners, 1)
+			assert.Equal(t, tt.winner, state.Winners[0].ID)
+			assert.Equal(t, tt.clearing, state.Winners[0].ClearingPrice)
+			assert.Equal(t, tt.clearing, state.Winners[0].Charge())
+
+			// кандидаты не меняются
+			assert.Zero(t, candidates[1].ClearingPrice)
+		})
+	}
+}
+
+func TestAuction_Do_Floors(t *testing.T) {
+	state := &plugins.State{
+		Candidates: []ads.Banner{{ID: "1", Price: 50}, {ID: "2", Price: 80}, {ID: "3", Price: 90}},
+		Placement:  &plugins.Placement{Floor: 60, BidFloor: 85},
+		Decisions:  &plugins.DecisionLog{},
+	}
+
+	err := stage(map[string]any{"floor": 70}).Do(context.Background(), state)
+	require.NoError(t, err)
+
+	require.Len(t, state.Winners, 1)
+	assert.Equal(t, "3", state.Winners[0].ID)
+	assert.Equal(t, 85.01, state.Winners[0].ClearingPrice)
+
+	assert.Equal(t, []plugins.Decision{
+		{BannerID: "1", Stage: "stages.auction", Reason: plugins.ReasonFloor, Details: "ecpm 50.00 below floor 85.00"},
+		{BannerID: "2", Stage: "stages.auction", Reason: plugins.ReasonFloor, Details: "ecpm 80.00 below floor 85.00"},
+	}, state.Decisions.Items())
+}
+
+func TestAuction_Do_NoBids(t *testing.T) {
+	state := &plugins.State{
+		Candidates: []ads.Banner{{ID: "1", Price: 50}},
+		Winners:    []ads.Banner{{ID: "old"}},
+		Placement:  &plugins.Placement{BidFloor: 100},
+	}
+
+	err := stage(nil).Do(context.Background(), state)
+	require.NoError(t, err)
+
+	assert.Empty(t, state.Winners)
+}
+
+func TestAuction_Do_Mediation(t *testing.T) {
+	state := &plugins.State{
+		Candidates: []ads.Banner{{ID: "banner", Price: 100}},
+		Placement: &plugins.Placement{
+			Units: []ads.Unit{{ID: "R-A-1-1", Title: "yandex", Price: 150, Network: "yandex"}},
+		},
+		Decisions: &plugins.DecisionLog{},
+	}
+
+	err := stage(map[string]any{"mediation": true}).Do(context.Background(), state)
+	require.NoError(t, err)
+
+	require.Len(t, state.Winners, 1)
+	assert.Equal(t, "R-A-1-1", state.Winners[0].ID)
+	assert.Equal(t, ads.CreativeTypeMediator, state.Winners[0].Type)
+	assert.Equal(t, 100.01, state.Winners[0].ClearingPrice)
+
+	assert.Equal(t, []plugins.Decision{
+		{BannerID: "banner", Stage: "stages.auction", Reason: plugins.ReasonAuction, Details: "lost to R-A-1-1"},
+	}, state.Decisions.Items())
+}
@@ -2,6 +2,7 @@ package banners
 
 import (
 	"context"
+	"fmt"
 
 	"go.uber.org/fx"
 
@@ -24,6 +25,7 @@ var Module = fx.Module(
 
 type BannersCache interface {
 	All(ctx context.Context) []ads.Banner
+	Candidates(ctx context.Context, q banners.Query) []ads.Banner
 }
 
 type Banners struct {
@@ -46,8 +48,58 @@ func (b *Banners) Copy(cfg map[string]any) plugins.Stage {
 	}
 }
 
+// Schema - у плагина нет настроек
+func (b *Banners) Schema() plugins.Schema {
+	return plugins.Schema{}
+}
+
+// Do берет из индекса кеша только баннеры, которые могут подойти
+// под гео, приложение, сеть и форматы запроса. Остальные таргетинги
+// проверяет stages.targeting.
 func (b *Banners) Do(ctx context.Context, state *plugins.State) error {
-	state.Candidates = b.cache.All(ctx)
+	state.Candidates = b.cache.Candidates(ctx, query(state))
+
+	// в отладке нужны и баннеры, которые отсек индекс
+	if state.Decisions != nil {
+		b.explain(ctx, state)
+	}
 
 	return nil
 }
+
+// explain пишет одну запись со счетчиком: по баннеру на каждый
+// промах индекса раздули бы журнал на весь кеш
+func (b *Banners) explain(ctx context.Context, state *plugins.State) {
+	missed := len(b.cache.All(ctx)) - len(state.Candidates)
+	if missed <= 0 {
+		return
+	}
+
+	state.Decisions.Add(plugins.Decision{
+		Stage:   b.Name(),
+		Reason:  plugins.ReasonTargeting,
+		Details: fmt.Sprintf("index: %d banners", missed),
+	})
+}
+
+func query(state *plugins.State) banners.Query {
+	q := banners.Query{
+		Network: state.Network,
+	}
+
+	if state.Geo != nil {
+		q.Country = state.Geo.Country
+		q.Region = state.Geo.Region
+		q.City = state.Geo.City
+	}
+
+	if state.App != nil {
+		q.Bundle = state.App.Bundle
+	}
+
+	if state.Placement != nil {
+		q.Formats = state.Placement.Formats
+	}
+
+	return q
+}
@@ -9,16 +9,24 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/repos/banners"
 )
 
 type mockBannersCache struct {
 	banners []ads.Banner
+	query   banners.Query
 }
 
 func (m *mockBannersCache) All(ctx context.Context) []ads.Banner {
 	return m.banners
 }
 
+func (m *mockBannersCache) Candidates(ctx context.Context, q banners.Query) []ads.Banner {
+	m.query = q
+
+	return banners.NewIndex(m.banners).Candidates(q)
+}
+
 func TestNew(t *testing.T) {
 	mockCache := &mockBannersCache{}
 
@@ -116,3 +124,42 @@ func TestBanners_DoWithEmptyCache(t *testing.T) {
 	assert.Empty(t, state.Candidates)
 	assert.Len(t, state.Candidates, 0)
 }
+
+func TestBanners_Do_Query(t *testing.T) {
+	mockCache := &mockBannersCache{
+		banners: []ads.Banner{
+			{ID: "1"},
+			{ID: "2", Targeting: ads.Targeting{Country: ads.ExcludeInclude{IncludeOr: []string{"DE"}}}},
+			{ID: "3", Type: ads.CreativeTypeVideo},
+		},
+	}
+
+	stage := &Banners{cache: mockCache}
+
+	state := &plugins.State{
+		Network:   "exchange",
+		Geo:       &plugins.Geo{Country: "RU", Region: "MOW", City: "Moscow"},
+		App:       &plugins.App{Bundle: "com.example.app"},
+		Placement: &plugins.Placement{Formats: []string{"banner", "native"}},
+		Decisions: &plugins.DecisionLog{},
+	}
+
+	err := stage.Do(context.Background(), state)
+	assert.NoError(t, err)
+
+	assert.Equal(t, banners.Query{
+		Country: "RU",
+		Region:  "MOW",
+		City:    "Moscow",
+		Bundle:  "com.example.app",
+		Network: "exchange",
+		Formats: []string{"banner", "native"},
+	}, mockCache.query)
+
+	assert.Equal(t, []ads.Banner{{ID: "1"}}, state.Candidates)
+
+	// баннеры, которые отсек индекс, в журнале одной записью
+	assert.Equal(t, []plugins.Decision{
+		{Stage: "stages.banners", Reason: plugins.ReasonTargeting, Details: "index: 2 banners"},
+	}, state.Decisions.Items())
+}
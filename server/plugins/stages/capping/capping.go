@@ -0,0 +1,135 @@
+package capping
+
+import (
+	"context"
+	"net/http"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/server/internal/capping"
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/sessions"
+)
+
+var Module = fx.Module(
+	"stages.capping",
+
+	fx.Provide(
+		fx.Annotate(
+			New,
+			fx.As(new(plugins.Stage)),
+			fx.ResultTags(`group:"stages"`),
+		),
+	),
+)
+
+type Counter interface {
+	Capped(ctx context.Context, uid string, banners []ads.Banner) (map[string]string, error)
+}
+
+type Sessions interface {
+	Identifier(r *http.Request) string
+}
+
+type Capping struct {
+	logger   *zap.Logger
+	counter  Counter
+	sessions Sessions
+}
+
+func New(logger *zap.Logger, counter *capping.Capping, sessions *sessions.Sessions) *Capping {
+	return &Capping{
+		logger:   logger,
+		counter:  counter,
+		sessions: sessions,
+	}
+}
+
+func (c *Capping) Name() string {
+	return "stages.capping"
+}
+
+func (c *Capping) Copy(cfg map[string]any) plugins.Stage {
+	return &Capping{
+		logger:   c.logger,
+		counter:  c.counter,
+		sessions: c.sessions,
+	}
+}
+
+// Schema - у плагина нет настроек
+func (c *Capping) Schema() plugins.Schema {
+	return plugins.Schema{}
+}
+
+// Do убирает баннеры, которые пользователь уже видел максимальное
+// число раз. Найденный идентификатор сохраняется в User.ID, чтобы
+// попасть в события показа и ссылки трекера.
+func (c *Capping) Do(ctx context.Context, state *plugins.State) error {
+	uid := c.uid(state)
+	if uid == "" {
+		return nil
+	}
+
+	if state.User == nil {
+		state.User = &plugins.User{}
+	}
+
+	state.User.ID = uid
+
+	if len(state.Candidates) == 0 {
+		return nil
+	}
+
+	capped, err := c.counter.Capped(ctx, uid, state.Candidates)
+	if err != nil {
+		c.logger.Warn("error on check capping", zap.Error(err))
+
+		return nil
+	}
+
+	if len(capped) == 0 {
+		return nil
+	}
+
+	candidates := make([]ads.Banner, 0, len(state.Candidates))
+
+	for _, banner := range state.Candidates {
+		if reason, ok := capped[banner.ID]; ok {
+			c.logger.Debug("banner filtered by capping",
+				zap.String("banner", banner.ID),
+				zap.String("reason", reason),
+			)
+
+			state.Drop(c.Name(), banner, plugins.ReasonCapping, reason)
+
+			continue
+		}
+
+		candidates = append(candidates, banner)
+	}
+
+	state.Candidates = candidates
+
+	return nil
+}
+
+// uid - id пользователя, рекламный id устройства
+// или отпечаток из сессий
+func (c *Capping) uid(state *plugins.State) string {
+	if state.User != nil && state.User.ID != "" {
+		return state.User.ID
+	}
+
+	if state.Device != nil && state.Device.IFA != "" {
+		return state.Device.IFA
+	}
+
+	if state.Request != nil {
+		return c.sessions.Identifier(state.Request)
+	}
+
+	return ""
+}
@@ -0,0 +1,130 @@
+package capping
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+type MockCounter struct {
+	mock.Mock
+}
+
+func (m *MockCounter) Capped(ctx context.Context, uid string, banners []ads.Banner) (map[string]string, error) {
+	args := m.Called(ctx, uid, banners)
+	capped, _ := args.Get(0).(map[string]string)
+
+	return capped, args.Error(1)
+}
+
+type mockSessions struct{}
+
+func (mockSessions) Identifier(r *http.Request) string {
+	return "fingerprint"
+}
+
+func TestCapping_Do(t *testing.T) {
+	counter := &MockCounter{}
+	stage := (&Capping{logger: zap.NewNop(), counter: counter, sessions: mockSessions{}}).Copy(nil)
+
+	candidates := []ads.Banner{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	counter.On("Capped", mock.Anything, "user-1", candidates).
+		Return(map[string]string{"1": "capping.banner", "3": "capping.campaign"}, nil)
+
+	state := &plugins.State{
+		User:       &plugins.User{ID: "user-1"},
+		Candidates: candidates,
+	}
+
+	err := stage.Do(context.Background(), state)
+	require.NoError(t, err)
+
+	require.Len(t, state.Candidates, 1)
+	assert.Equal(t, "2", state.Candidates[0].ID)
+
+	counter.AssertExpectations(t)
+}
+
+func TestCapping_Do_Error(t *testing.T) {
+	counter := &MockCounter{}
+	stage := &Capping{logger: zap.NewNop(), counter: counter, sessions: mockSessions{}}
+
+	candidates := []ads.Banner{{ID: "1"}}
+	counter.On("Capped", mock.Anything, "ifa-1", candidates).
+		Return(nil, errors.New("redis is down"))
+
+	state := &plugins.State{
+		Device:     &plugins.Device{IFA: "ifa-1"},
+		Candidates: candidates,
+	}
+
+	err := stage.Do(context.Background(), state)
+	require.NoError(t, err)
+	assert.Len(t, state.Candidates, 1)
+}
+
+func TestCapping_UID(t *testing.T) {
+	stage := &Capping{sessions: mockSessions{}}
+
+	tests := []struct {
+		name  string
+		state *plugins.State
+		uid   string
+	}{
+		{
+			name: "user",
+			state: &plugins.State{
+				User:   &plugins.User{ID: "user-1"},
+				Device: &plugins.Device{IFA: "ifa-1"},
+			},
+			uid: "user-1",
+		},
+		{
+			name: "device",
+			state: &plugins.State{
+				User:    &plugins.User{},
+				Device:  &plugins.Device{IFA: "ifa-1"},
+				Request: httptest.NewRequest(http.MethodGet, "/", nil),
+			},
+			uid: "ifa-1",
+		},
+		{
+			name:  "session",
+			state: &plugins.State{Request: httptest.NewRequest(http.MethodGet, "/", nil)},
+			uid:   "fingerprint",
+		},
+		{
+			name:  "unknown",
+			state: &plugins.State{},
+			uid:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.uid, stage.uid(tt.state))
+		})
+	}
+}
+
+func TestCapping_Do_SetsUser(t *testing.T) {
+	stage := &Capping{logger: zap.NewNop(), counter: &MockCounter{}, sessions: mockSessions{}}
+
+	state := &plugins.State{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+
+	err := stage.Do(context.Background(), state)
+	require.NoError(t, err)
+
+	require.NotNil(t, state.User)
+	assert.Equal(t, "fingerprint", state.User.ID)
+}
@@ -3,10 +3,13 @@ package stages
 import (
 	"go.uber.org/fx"
 
+	"go.ads.coffee/platform/server/plugins/stages/auction"
 	"go.ads.coffee/platform/server/plugins/stages/banners"
+	"go.ads.coffee/platform/server/plugins/stages/capping"
 	"go.ads.coffee/platform/server/plugins/stages/limits"
 	"go.ads.coffee/platform/server/plugins/stages/mediation"
 	"go.ads.coffee/platform/server/plugins/stages/rotation"
+	"go.ads.coffee/platform/server/plugins/stages/schedule"
 	"go.ads.coffee/platform/server/plugins/stages/targeting"
 )
 
@@ -14,8 +17,11 @@ var Module = fx.Module(
 	"stages.stages",
 
 	limits.Module,
+	capping.Module,
+	schedule.Module,
 	targeting.Module,
 	rotation.Module,
 	banners.Module,
 	mediation.Module,
+	auction.Module,
 )
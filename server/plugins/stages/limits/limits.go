@@ -4,7 +4,10 @@ import (
 	"context"
 
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 
+	"go.ads.coffee/platform/server/internal/budgets"
+	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 )
 
@@ -20,10 +23,20 @@ var Module = fx.Module(
 	),
 )
 
-type Limits struct{}
+type Budgets interface {
+	Exhausted(ctx context.Context, banners []ads.Banner) (map[string]string, error)
+}
+
+type Limits struct {
+	logger  *zap.Logger
+	budgets Budgets
+}
 
-func New() *Limits {
-	return &Limits{}
+func New(logger *zap.Logger, budgets *budgets.Budgets) *Limits {
+	return &Limits{
+		logger:  logger,
+		budgets: budgets,
+	}
 }
 
 func (l *Limits) Name() string {
@@ -31,12 +44,53 @@ func (l *Limits) Name() string {
 }
 
 func (l *Limits) Copy(cfg map[string]any) plugins.Stage {
-	return &Limits{}
+	return &Limits{
+		logger:  l.logger,
+		budgets: l.budgets,
+	}
 }
 
+// Schema - у плагина нет настроек
+func (l *Limits) Schema() plugins.Schema {
+	return plugins.Schema{}
+}
+
+// Do убирает кандидатов, у которых исчерпан бюджет на любом
+// уровне иерархии. Если redis недоступен, кандидаты остаются.
 func (l *Limits) Do(ctx context.Context, state *plugins.State) error {
-	// срабатывают ограничения по показам, капингам и так далее
-	state.Candidates = state.Candidates[:]
+	if len(state.Candidates) == 0 {
+		return nil
+	}
+
+	exhausted, err := l.budgets.Exhausted(ctx, state.Candidates)
+	if err != nil {
+		l.logger.Warn("error on check budgets", zap.Error(err))
+
+		return nil
+	}
+
+	if len(exhausted) == 0 {
+		return nil
+	}
+
+	candidates := make([]ads.Banner, 0, len(state.Candidates))
+
+	for _, banner := range state.Candidates {
+		if reason, ok := exhausted[banner.ID]; ok {
+			l.logger.Debug("banner filtered by limits",
+				zap.String("banner", banner.ID),
+				zap.String("reason", reason),
+			)
+
+			state.Drop(l.Name(), banner, plugins.ReasonBudget, reason)
+
+			continue
+		}
+
+		candidates = append(candidates, banner)
+	}
+
+	state.Candidates = candidates
 
 	return nil
 }
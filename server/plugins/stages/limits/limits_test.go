@@ -0,0 +1,73 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+type MockBudgets struct {
+	mock.Mock
+}
+
+func (m *MockBudgets) Exhausted(ctx context.Context, banners []ads.Banner) (map[string]string, error) {
+	args := m.Called(ctx, banners)
+	exhausted, _ := args.Get(0).(map[string]string)
+
+	return exhausted, args.Error(1)
+}
+
+func TestLimits_Do(t *testing.T) {
+	budgets := &MockBudgets{}
+	stage := &Limits{logger: zap.NewNop(), budgets: budgets}
+
+	candidates := []ads.Banner{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	budgets.On("Exhausted", mock.Anything, candidates).
+		Return(map[string]string{"2": "limits.campaign.money.daily"}, nil)
+
+	state := &plugins.State{Candidates: candidates}
+
+	err := stage.Copy(nil).Do(context.Background(), state)
+	require.NoError(t, err)
+
+	require.Len(t, state.Candidates, 2)
+	assert.Equal(t, "1", state.Candidates[0].ID)
+	assert.Equal(t, "3", state.Candidates[1].ID)
+
+	budgets.AssertExpectations(t)
+}
+
+func TestLimits_Do_Error(t *testing.T) {
+	budgets := &MockBudgets{}
+	stage := &Limits{logger: zap.NewNop(), budgets: budgets}
+
+	candidates := []ads.Banner{{ID: "1"}, {ID: "2"}}
+	budgets.On("Exhausted", mock.Anything, candidates).
+		Return(nil, errors.New("redis is down"))
+
+	state := &plugins.State{Candidates: candidates}
+
+	err := stage.Do(context.Background(), state)
+	require.NoError(t, err)
+	assert.Len(t, state.Candidates, 2)
+}
+
+func TestLimits_Do_Empty(t *testing.T) {
+	budgets := &MockBudgets{}
+	stage := &Limits{logger: zap.NewNop(), budgets: budgets}
+
+	state := &plugins.State{}
+
+	err := stage.Do(context.Background(), state)
+	require.NoError(t, err)
+
+	budgets.AssertNotCalled(t, "Exhausted", mock.Anything, mock.Anything)
+}
@@ -1,12 +1,20 @@
 package mediation
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/mroth/weightedrand/v2"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 
+	"go.ads.coffee/platform/pkg/circuitbreaker"
+	"go.ads.coffee/platform/server/internal/adapters"
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 )
@@ -23,10 +31,60 @@ var Module = fx.Module(
 	),
 )
 
-type Mediation struct{}
+const (
+	modeKey     = "mode"
+	timeoutKey  = "timeout"
+	networksKey = "networks"
+	adapterKey  = "adapter"
 
-func New() *Mediation {
-	return &Mediation{}
+	// клиент сам вызывает сети, блоки участвуют в ротации по цене
+	ModeClient = "client"
+	// сети по убыванию цены блока, пока одна не ответит ставкой.
+	// В серверных режимах блоки сетей без адаптера не участвуют
+	ModeWaterfall = "waterfall"
+	// все сети сразу, лучшая ставка соревнуется с прямыми баннерами
+	ModeParallel = "parallel"
+
+	// circuit сети называется mediation.<сеть>
+	CircuitPrefix = "mediation."
+
+	defaultTimeout = 100 * time.Millisecond
+
+	reasonNoAdapter = "no adapter"
+)
+
+type Adapters interface {
+	Get(name string, cfg map[string]any) (plugins.Adapter, bool)
+}
+
+type Circuits interface {
+	Get(name string) circuitbreaker.Circuit
+}
+
+// network - сеть, ставку которой запрашиваем с сервера
+type network struct {
+	adapter plugins.Adapter
+	circuit circuitbreaker.Circuit
+}
+
+type Mediation struct {
+	logger   *zap.Logger
+	adapters Adapters
+	circuits Circuits
+
+	mode     string
+	timeout  time.Duration
+	networks map[string]network
+}
+
+func New(logger *zap.Logger, adapters *adapters.Adapters, pool *circuitbreaker.Pool) *Mediation {
+	return &Mediation{
+		logger:   logger,
+		adapters: adapters,
+		circuits: pool,
+		mode:     ModeClient,
+		timeout:  defaultTimeout,
+	}
 }
 
 func (t *Mediation) Name() string {
@@ -34,20 +92,89 @@ func (t *Mediation) Name() string {
 }
 
 func (t *Mediation) Copy(cfg map[string]any) plugins.Stage {
-	return &Mediation{}
+	mode, _ := cfg[modeKey].(string)
+	if mode == "" {
+		mode = ModeClient
+	}
+
+	timeout := defaultTimeout
+	if v, ok := cfg[timeoutKey].(string); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			t.logger.Warn("invalid mediation timeout, use default",
+				zap.String("timeout", v),
+				zap.Duration("default", defaultTimeout),
+			)
+		} else {
+			timeout = d
+		}
+	}
+
+	networks := map[string]network{}
+
+	nn, _ := cfg[networksKey].(map[string]any)
+	for name, v := range nn {
+		ncfg, _ := v.(map[string]any)
+		adapter, _ := ncfg[adapterKey].(string)
+
+		a, ok := t.adapters.Get(adapter, ncfg)
+		if !ok {
+			t.logger.Warn("unknown mediation adapter, network is called by client",
+				zap.String("network", name),
+				zap.String("adapter", adapter),
+			)
+
+			continue
+		}
+
+		networks[name] = network{
+			adapter: a,
+			circuit: t.circuits.Get(CircuitPrefix + name),
+		}
+	}
+
+	return &Mediation{
+		logger:   t.logger,
+		adapters: t.adapters,
+		circuits: t.circuits,
+		mode:     mode,
+		timeout:  timeout,
+		networks: networks,
+	}
+}
+
+// Schema - в networks ключ это Unit.Network, значение - имя адаптера
+// в adapter и его настройки
+func (t *Mediation) Schema() plugins.Schema {
+	return plugins.Schema{
+		{Name: modeKey, Type: plugins.TypeString, Default: ModeClient, Values: []string{ModeClient, ModeWaterfall, ModeParallel}},
+		{Name: timeoutKey, Type: plugins.TypeDuration, Default: defaultTimeout.String()},
+		{Name: networksKey, Type: plugins.TypeMap},
+	}
 }
 
 func (t *Mediation) Do(ctx context.Context, state *plugins.State) error {
+	switch t.mode {
+	case ModeWaterfall:
+		t.waterfall(ctx, state)
+
+		return nil
+	case ModeParallel:
+		t.parallel(ctx, state)
+
+		return nil
+	}
+
+	return t.client(state)
+}
+
+// client добавляет блоки к победителям и выбирает
+// одного случайно с весом по цене
+func (t *Mediation) client(state *plugins.State) error {
 	winners := state.Winners
 
 	for _, u := range state.Placement.Units {
-		winners = append(winners, ads.Banner{
-			ID:      u.ID,
-			Title:   u.Title,
-			Price:   u.Price,
-			Type:    ads.CreativeTypeMediator,
-			Network: u.Network,
-		})
+		winners = append(winners, unit(u))
 	}
 
 	if len(winners) == 1 {
@@ -63,9 +190,206 @@ func (t *Mediation) Do(ctx context.Context, state *plugins.State) error {
 
 	state.Winners = []ads.Banner{winner}
 
+	for _, banner := range winners {
+		if banner.ID != winner.ID {
+			state.Drop(t.Name(), banner, plugins.ReasonMediation, "lost to "+winner.ID)
+		}
+	}
+
 	return nil
 }
 
+// waterfall опрашивает сети по убыванию цены блока. Цена блока -
+// минимальная ставка, которую ждем от сети. Прямой баннер
+// показывается, как только он дороже следующего блока.
+func (t *Mediation) waterfall(ctx context.Context, state *plugins.State) {
+	if state.Placement == nil {
+		return
+	}
+
+	units := slices.SortedStableFunc(slices.Values(state.Placement.Units), func(x, y ads.Unit) int {
+		return cmp.Compare(y.Price, x.Price)
+	})
+
+	direct, hasDirect := t.direct(state)
+	floor := t.floor(state)
+
+	for i, u := range units {
+		price := float64(u.Price)
+
+		if hasDirect && direct.Charge() >= price {
+			t.win(state, direct, banners(units[i:]))
+
+			return
+		}
+
+		if price < floor {
+			state.Drop(t.Name(), unit(u), plugins.ReasonFloor,
+				fmt.Sprintf("unit price %d below floor %.2f", u.Price, floor))
+
+			continue
+		}
+
+		n, ok := t.networks[u.Network]
+		if !ok {
+			// без адаптера креатива нет, а клиент в серверном
+			// режиме сеть не вызывает: ответ был бы пустым
+			state.Drop(t.Name(), unit(u), plugins.ReasonMediation, reasonNoAdapter)
+
+			continue
+		}
+
+		bid, ok := t.bid(ctx, n, state, u, max(floor, price))
+		if ok {
+			t.win(state, bidder(u, bid), banners(units[i+1:]))
+
+			return
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	// ни одна сеть не ответила, остается прямой баннер
+}
+
+// parallel запрашивает ставки у всех сетей сразу. Лучшая ставка
+// выигрывает, если она выше прямого баннера и минимальной цены.
+// Сети без адаптера не участвуют, как и в waterfall.
+func (t *Mediation) parallel(ctx context.Context, state *plugins.State) {
+	if state.Placement == nil {
+		return
+	}
+
+	direct, hasDirect := t.direct(state)
+
+	floor := t.floor(state)
+	if hasDirect {
+		floor = max(floor, direct.Charge())
+	}
+
+	units := state.Placement.Units
+	bids := make([]*ads.Banner, len(units))
+
+	var wg sync.WaitGroup
+
+	for i, u := range units {
+		n, ok := t.networks[u.Network]
+		if !ok {
+			state.Drop(t.Name(), unit(u), plugins.ReasonMediation, reasonNoAdapter)
+
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if bid, ok := t.bid(ctx, n, state, u, floor); ok {
+				b := bidder(u, bid)
+				bids[i] = &b
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	var (
+		winner ads.Banner
+		found  bool
+	)
+
+	// при равной цене остается прямой баннер
+	if hasDirect {
+		winner, found = direct, true
+	}
+
+	for _, b := range bids {
+		if b == nil || b.Charge() < floor {
+			continue
+		}
+
+		if !found || b.Charge() > winner.Charge() {
+			winner, found = *b, true
+		}
+	}
+
+	if !found {
+		return
+	}
+
+	losers := []ads.Banner{}
+
+	for _, b := range bids {
+		if b != nil && b.ID != winner.ID {
+			losers = append(losers, *b)
+		}
+	}
+
+	if hasDirect && direct.ID != winner.ID {
+		losers = append(losers, direct)
+	}
+
+	t.win(state, winner, losers)
+}
+
+// bid запрашивает ставку сети через ее circuit
+// и с отдельным ограничением по времени
+func (t *Mediation) bid(ctx context.Context, n network, state *plugins.State, u ads.Unit, floor float64) (plugins.Bid, bool) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	var (
+		bid plugins.Bid
+		ok  bool
+	)
+
+	err := n.circuit.Run(ctx, func(ctx context.Context) error {
+		var err error
+
+		bid, ok, err = n.adapter.Bid(ctx, state, u, floor)
+
+		return err
+	})
+
+	switch {
+	case err != nil:
+		t.logger.Debug("error on mediation bid", zap.String("network", u.Network), zap.Error(err))
+		state.Drop(t.Name(), unit(u), plugins.ReasonMediation, "error: "+err.Error())
+
+		return plugins.Bid{}, false
+	case !ok:
+		state.Drop(t.Name(), unit(u), plugins.ReasonMediation, "no bid")
+
+		return plugins.Bid{}, false
+	}
+
+	return bid, true
+}
+
+func (t *Mediation) direct(state *plugins.State) (ads.Banner, bool) {
+	if len(state.Winners) == 0 || state.Winners[0].ID == "" {
+		return ads.Banner{}, false
+	}
+
+	return state.Winners[0], true
+}
+
+func (t *Mediation) floor(state *plugins.State) float64 {
+	return max(state.Placement.Floor, state.Placement.BidFloor)
+}
+
+// win делает баннер победителем, остальные проиграли ему
+func (t *Mediation) win(state *plugins.State, winner ads.Banner, losers []ads.Banner) {
+	state.Winners = []ads.Banner{winner}
+
+	for _, banner := range losers {
+		state.Drop(t.Name(), banner, plugins.ReasonMediation, "lost to "+winner.ID)
+	}
+}
+
 func (t *Mediation) rotate(candidates []ads.Banner) (ads.Banner, bool, error) {
 	choices := []weightedrand.Choice[ads.Banner, int]{}
 	for _, candidate := range candidates {
@@ -83,3 +407,41 @@ func (t *Mediation) rotate(candidates []ads.Banner) (ads.Banner, bool, error) {
 
 	return chooser.Pick(), true, nil
 }
+
+// unit - блок, который клиент вызывает сам
+func unit(u ads.Unit) ads.Banner {
+	return ads.Banner{
+		ID:      u.ID,
+		Title:   u.Title,
+		Price:   u.Price,
+		Type:    ads.CreativeTypeMediator,
+		Network: u.Network,
+	}
+}
+
+func banners(units []ads.Unit) []ads.Banner {
+	result := make([]ads.Banner, 0, len(units))
+	for _, u := range units {
+		result = append(result, unit(u))
+	}
+
+	return result
+}
+
+// bidder - блок со ставкой, полученной с сервера: креатив
+// уже есть, списываем цену ставки
+func bidder(u ads.Unit, bid plugins.Bid) ads.Banner {
+	b := unit(u)
+
+	b.Type = u.Format
+	if b.Type == "" {
+		b.Type = ads.CreativeTypeBanner
+	}
+
+	b.Price = int(math.Round(bid.Price))
+	b.ClearingPrice = bid.Price
+	b.Markup = bid.Markup
+	b.Imptracker = bid.Tracker
+
+	return b
+}
@@ -2,27 +2,38 @@ package mediation
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/pkg/circuitbreaker"
+	"go.ads.coffee/platform/server/internal/adapters"
 	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/openrtb"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	adapter "go.ads.coffee/platform/server/plugins/adapters/openrtb"
 )
 
 func TestNew(t *testing.T) {
-	mediation := New()
+	mediation := New(zap.NewNop(), nil, nil)
 	assert.NotNil(t, mediation)
 }
 
 func TestMediation_Name(t *testing.T) {
-	mediation := New()
+	mediation := New(zap.NewNop(), nil, nil)
 	name := mediation.Name()
 	assert.Equal(t, "stages.mediation", name)
 }
 
 func TestMediation_Copy(t *testing.T) {
-	mediation := New()
+	mediation := New(zap.NewNop(), nil, nil)
 	cfgMap := map[string]any{"key": "value"}
 	copied := mediation.Copy(cfgMap)
 	assert.NotNil(t, copied)
@@ -30,7 +41,7 @@ func TestMediation_Copy(t *testing.T) {
 }
 
 func TestMediation_Do(t *testing.T) {
-	mediation := New()
+	mediation := New(zap.NewNop(), nil, nil)
 
 	t.Run("single unit", func(t *testing.T) {
 		ctx := context.Background()
@@ -296,3 +307,328 @@ func TestMediation_rotate(t *testing.T) {
 		assert.InDelta(t, results["1"], results["3"], 100)
 	})
 }
+
+// standin - сеть медиации на httptest: отвечает ставкой
+// с заданной ценой и запоминает минимальную цену из запроса
+type standin struct {
+	price float64
+	delay time.Duration
+
+	mu     sync.Mutex
+	floors []float64
+}
+
+func (n *standin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := openrtb.BidRequest{}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	n.mu.Lock()
+	n.floors = append(n.floors, req.Imp[0].BidFloor)
+	n.mu.Unlock()
+
+	if n.delay > 0 {
+		select {
+		case <-time.After(n.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if n.price == 0 || n.price < req.Imp[0].BidFloor {
+		w.WriteHeader(http.StatusNoContent)
+
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(openrtb.BidResponse{
+		ID: req.ID,
+		SeatBid: []openrtb.SeatBid{{Bid: []openrtb.Bid{{
+			ID:    "bid",
+			ImpID: req.Imp[0].ID,
+			Price: n.price,
+			AdM:   "<div>" + req.Imp[0].TagID + "</div>",
+			BURL:  "http://network/billing?price=${AUCTION_PRICE}",
+		}}}},
+	})
+}
+
+func (n *standin) requested() []float64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.floors
+}
+
+type circuits map[string]int
+
+func (c circuits) Get(name string) circuitbreaker.Circuit {
+	c[name]++
+
+	var pool *circuitbreaker.Pool
+
+	return pool.Get(name)
+}
+
+func stage(t *testing.T, cfg map[string]any, networks map[string]*standin) *Mediation {
+	nn := map[string]any{}
+
+	for name, n := range networks {
+		srv := httptest.NewServer(n)
+		t.Cleanup(srv.Close)
+
+		nn[name] = map[string]any{"adapter": "adapters.openrtb", "endpoint": srv.URL}
+	}
+
+	cfg["networks"] = nn
+
+	m := New(zap.NewNop(), adapters.New([]plugins.Adapter{adapter.New()}), nil)
+
+	return m.Copy(m.Schema().Apply(cfg)).(*Mediation)
+}
+
+func TestMediation_Copy_Config(t *testing.T) {
+	cc := circuits{}
+
+	m := &Mediation{
+		logger:   zap.NewNop(),
+		adapters: adapters.New([]plugins.Adapter{adapter.New()}),
+		circuits: cc,
+	}
+
+	copied := m.Copy(map[string]any{
+		"mode":    "parallel",
+		"timeout": "50ms",
+		"networks": map[string]any{
+			"yandex":  map[string]any{"adapter": "adapters.openrtb", "endpoint": "http://yandex"},
+			"unknown": map[string]any{"adapter": "adapters.unknown"},
+		},
+	}).(*Mediation)
+
+	assert.Equal(t, ModeParallel, copied.mode)
+	assert.Equal(t, 50*time.Millisecond, copied.timeout)
+	assert.Len(t, copied.networks, 1)
+	assert.Contains(t, copied.networks, "yandex")
+
+	// у каждой сети свой circuit
+	assert.Equal(t, circuits{"mediation.yandex": 1}, cc)
+
+	copied = m.Copy(map[string]any{"timeout": "soon"}).(*Mediation)
+	assert.Equal(t, ModeClient, copied.mode)
+	assert.Equal(t, defaultTimeout, copied.timeout)
+}
+
+func TestMediation_Waterfall(t *testing.T) {
+	first := &standin{}
+	second := &standin{price: 250}
+	third := &standin{price: 500}
+
+	m := stage(t, map[string]any{"mode": "waterfall"}, map[string]*standin{
+		"first": first, "second": second, "third": third,
+	})
+
+	state := &plugins.State{
+		Placement: &plugins.Placement{
+			Units: []ads.Unit{
+				{ID: "unit-3", Price: 100, Network: "third"},
+				{ID: "unit-1", Price: 300, Network: "first"},
+				{ID: "unit-2", Price: 200, Network: "second", Format: ads.CreativeTypeNative},
+			},
+		},
+		Winners:   []ads.Banner{{ID: "direct", Price: 50}},
+		Decisions: &plugins.DecisionLog{},
+	}
+
+	require.NoError(t, m.Do(context.Background(), state))
+
+	require.Len(t, state.Winners, 1)
+
+	winner := state.Winners[0]
+	assert.Equal(t, "unit-2", winner.ID)
+	assert.Equal(t, ads.CreativeTypeNative, winner.Type)
+	assert.Equal(t, 250.0, winner.Charge())
+	assert.Equal(t, "<div>unit-2</div>", winner.Markup)
+	assert.Equal(t, "http://network/billing?price=250", winner.Imptracker)
+
+	// сети опрашиваются по убыванию цены блока, цена блока - минимальная ставка
+	assert.Equal(t, []float64{300}, first.requested())
+	assert.Equal(t, []float64{200}, second.requested())
+	assert.Empty(t, third.requested())
+
+	assert.Equal(t, []plugins.Decision{
+		{BannerID: "unit-1", Stage: "stages.mediation", Reason: plugins.ReasonMediation, Details: "no bid"},
+		{BannerID: "unit-3", Stage: "stages.mediation", Reason: plugins.ReasonMediation, Details: "lost to unit-2"},
+	}, state.Decisions.Items())
+}
+
+func TestMediation_Waterfall_Direct(t *testing.T) {
+	first := &standin{}
+	second := &standin{price: 250}
+
+	m := stage(t, map[string]any{"mode": "waterfall"}, map[string]*standin{"first": first, "second": second})
+
+	state := &plugins.State{
+		Placement: &plugins.Placement{
+			Units: []ads.Unit{
+				{ID: "unit-1", Price: 300, Network: "first"},
+				{ID: "unit-2", Price: 200, Network: "second"},
+			},
+		},
+		Winners: []ads.Banner{{ID: "direct", Price: 220}},
+	}
+
+	require.NoError(t, m.Do(context.Background(), state))
+
+	// прямой баннер дороже второго блока, вторую сеть не вызываем
+	require.Len(t, state.Winners, 1)
+	assert.Equal(t, "direct", state.Winners[0].ID)
+	assert.Len(t, first.requested(), 1)
+	assert.Empty(t, second.requested())
+}
+
+func TestMediation_Waterfall_Timeout(t *testing.T) {
+	slow := &standin{price: 500, delay: time.Second}
+	fast := &standin{price: 150}
+
+	m := stage(t, map[string]any{"mode": "waterfall", "timeout": "20ms"}, map[string]*standin{"slow": slow, "fast": fast})
+
+	state := &plugins.State{
+		Placement: &plugins.Placement{
+			Units: []ads.Unit{
+				{ID: "unit-1", Price: 300, Network: "slow"},
+				{ID: "unit-2", Price: 100, Network: "fast"},
+				// сеть без адаптера клиент вызывает сам
+				{ID: "unit-3", Price: 50, Network: "client"},
+			},
+		},
+		Decisions: &plugins.DecisionLog{},
+	}
+
+	start := time.Now()
+	require.NoError(t, m.Do(context.Background(), state))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	require.Len(t, state.Winners, 1)
+	assert.Equal(t, "unit-2", state.Winners[0].ID)
+
+	items := state.Decisions.Items()
+	require.Len(t, items, 2)
+	assert.Equal(t, "unit-1", items[0].BannerID)
+	assert.Contains(t, items[0].Details, "error: ")
+	assert.Equal(t, "lost to unit-2", items[1].Details)
+}
+
+func TestMediation_Waterfall_ClientUnit(t *testing.T) {
+	m := stage(t, map[string]any{"mode": "waterfall"}, map[string]*standin{"empty": {}})
+
+	state := &plugins.State{
+		Placement: &plugins.Placement{
+			Floor: 60,
+			Units: []ads.Unit{
+				{ID: "unit-1", Price: 300, Network: "empty"},
+				{ID: "unit-2", Price: 100, Network: "client"},
+				{ID: "unit-3", Price: 50, Network: "client"},
+			},
+		},
+		Winners:   []ads.Banner{{ID: "direct", Price: 40}},
+		Decisions: &plugins.DecisionLog{},
+	}
+
+	require.NoError(t, m.Do(context.Background(), state))
+
+	// блок без адаптера не выигрывает, остается прямой баннер
+	require.Len(t, state.Winners, 1)
+	assert.Equal(t, "direct", state.Winners[0].ID)
+
+	assert.Equal(t, []plugins.Decision{
+		{BannerID: "unit-1", Stage: "stages.mediation", Reason: plugins.ReasonMediation, Details: "no bid"},
+		{BannerID: "unit-2", Stage: "stages.mediation", Reason: plugins.ReasonMediation, Details: "no adapter"},
+		{BannerID: "unit-3", Stage: "stages.mediation", Reason: plugins.ReasonFloor, Details: "unit price 50 below floor 60.00"},
+	}, state.Decisions.Items())
+}
+
+func TestMediation_Waterfall_Video(t *testing.T) {
+	// как в пайплайне video: waterfall без сетей с адаптером
+	m := stage(t, map[string]any{"mode": "waterfall", "timeout": "100ms"}, nil)
+
+	direct := ads.Banner{
+		ID:    "direct",
+		Type:  ads.CreativeTypeVideo,
+		Price: 10,
+		Video: ads.Video{MediaFiles: []ads.MediaFile{{URL: "https://cdn/video.mp4", Type: "video/mp4"}}},
+	}
+
+	state := &plugins.State{
+		Placement: &plugins.Placement{
+			Units: []ads.Unit{
+				{ID: "unit-1", Price: 100, Network: "yandex", Format: ads.CreativeTypeVideo},
+			},
+		},
+		Winners: []ads.Banner{direct},
+	}
+
+	require.NoError(t, m.Do(context.Background(), state))
+
+	require.Len(t, state.Winners, 1)
+	assert.Equal(t, direct, state.Winners[0])
+}
+
+func TestMediation_Parallel(t *testing.T) {
+	low := &standin{price: 120}
+	high := &standin{price: 180}
+	none := &standin{}
+
+	m := stage(t, map[string]any{"mode": "parallel"}, map[string]*standin{"low": low, "high": high, "none": none})
+
+	state := &plugins.State{
+		Placement: &plugins.Placement{
+			BidFloor: 100,
+			Units: []ads.Unit{
+				{ID: "unit-low", Price: 10, Network: "low"},
+				{ID: "unit-high", Price: 10, Network: "high"},
+				{ID: "unit-none", Price: 10, Network: "none"},
+			},
+		},
+		Winners:   []ads.Banner{{ID: "direct", Price: 150}},
+		Decisions: &plugins.DecisionLog{},
+	}
+
+	require.NoError(t, m.Do(context.Background(), state))
+
+	require.Len(t, state.Winners, 1)
+	assert.Equal(t, "unit-high", state.Winners[0].ID)
+	assert.Equal(t, 180.0, state.Winners[0].Charge())
+
+	// прямой баннер задает минимальную ставку для сетей
+	assert.Equal(t, []float64{150}, low.requested())
+	assert.Equal(t, []float64{150}, high.requested())
+
+	reasons := map[string]string{}
+	for _, d := range state.Decisions.Items() {
+		reasons[d.BannerID] = d.Details
+	}
+
+	assert.Equal(t, map[string]string{
+		"unit-low":  "no bid",
+		"unit-none": "no bid",
+		"direct":    "lost to unit-high",
+	}, reasons)
+}
+
+func TestMediation_Parallel_Direct(t *testing.T) {
+	m := stage(t, map[string]any{"mode": "parallel"}, map[string]*standin{"net": {price: 120}})
+
+	state := &plugins.State{
+		Placement: &plugins.Placement{
+			Units: []ads.Unit{
+				{ID: "unit-1", Price: 10, Network: "net"},
+				{ID: "unit-2", Price: 100, Network: "client"},
+			},
+		},
+		Winners: []ads.Banner{{ID: "direct", Price: 150}},
+	}
+
+	require.NoError(t, m.Do(context.Background(), state))
+
+	require.Len(t, state.Winners, 1)
+	assert.Equal(t, "direct", state.Winners[0].ID)
+}
@@ -0,0 +1,121 @@
+package rotation
+
+import (
+	"math"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/stats"
+)
+
+// prior - априорные CTR и CVR для новых баннеров. weight - сколько
+// показов (для CVR - кликов) весит априорная оценка: чем больше,
+// тем дольше баннер показывается до того, как статистика возьмет верх
+type prior struct {
+	ctr    float64
+	cvr    float64
+	weight float64
+}
+
+// ecpm - ожидаемый доход с тысячи показов по сглаженным CTR и CVR
+func (r *Rotattion) ecpm(banner ads.Banner) float64 {
+	if banner.CPM() {
+		return banner.ECPM()
+	}
+
+	c, _ := r.stats.Get(banner.ID)
+
+	return banner.Expected(r.prior.smoothCTR(c), r.prior.smoothCVR(c))
+}
+
+// sample - eCPM со случайными CTR и CVR из апостериорного
+// бета-распределения. Баннеры с малой статистикой получают
+// большой разброс и чаще выигрывают, так новые баннеры
+// набирают показы без отдельного режима обучения
+func (r *Rotattion) sample(banner ads.Banner) float64 {
+	if banner.CPM() {
+		return banner.ECPM()
+	}
+
+	c, _ := r.stats.Get(banner.ID)
+
+	w := r.prior.weight
+	ctr := r.beta(
+		c.Clicks+r.prior.ctr*w,
+		max(c.Impressions-c.Clicks, 0)+(1-r.prior.ctr)*w,
+	)
+	cvr := r.beta(
+		c.Conversions+r.prior.cvr*w,
+		max(c.Clicks-c.Conversions, 0)+(1-r.prior.cvr)*w,
+	)
+
+	return banner.Expected(ctr, cvr)
+}
+
+func (p prior) smoothCTR(c stats.Counters) float64 {
+	return (c.Clicks + p.ctr*p.weight) / (c.Impressions + p.weight)
+}
+
+func (p prior) smoothCVR(c stats.Counters) float64 {
+	return (c.Conversions + p.cvr*p.weight) / (c.Clicks + p.weight)
+}
+
+// beta - случайное значение из Beta(a, b) через два гамма-распределения
+func (r *Rotattion) beta(a, b float64) float64 {
+	x := r.gamma(a)
+	y := r.gamma(b)
+
+	if x+y == 0 {
+		return 0
+	}
+
+	return x / (x + y)
+}
+
+// gamma - метод Марсальи-Цанга, для a < 1 с поправкой через a+1
+func (r *Rotattion) gamma(a float64) float64 {
+	if a <= 0 {
+		return 0
+	}
+
+	if a < 1 {
+		return r.gamma(a+1) * math.Pow(r.random(), 1/a)
+	}
+
+	d := a - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+
+	for {
+		x := r.normal()
+		v := 1 + c*x
+
+		if v <= 0 {
+			continue
+		}
+
+		v = v * v * v
+		u := r.random()
+
+		if u < 1-0.0331*x*x*x*x {
+			return d * v
+		}
+
+		if math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
+
+func number(v any, def float64) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	}
+
+	return def
+}
@@ -1,14 +1,20 @@
 package rotation
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync/atomic"
 
 	"github.com/mroth/weightedrand/v2"
 	"go.uber.org/fx"
 
 	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/stats"
 )
 
 var Module = fx.Module(
@@ -23,10 +29,62 @@ var Module = fx.Module(
 	),
 )
 
-type Rotattion struct{}
+const (
+	strategyKey    = "strategy"
+	epsilonKey     = "epsilon"
+	priorCTRKey    = "prior_ctr"
+	priorCVRKey    = "prior_cvr"
+	priorWeightKey = "prior_weight"
+)
+
+// Стратегии ротации
+const (
+	// случайный выбор с весом по цене
+	StrategyWeighted = "weighted"
+	// баннеры по очереди
+	StrategyRoundRobin = "roundrobin"
+	// баннер с лучшим ожидаемым eCPM
+	StrategyECPM = "ecpm"
+	// сэмплирование Томпсона по CTR и CVR
+	StrategyThompson = "thompson"
+	// лучший eCPM, с вероятностью epsilon - случайный баннер
+	StrategyEpsilon = "epsilon"
+)
 
-func New() *Rotattion {
-	return &Rotattion{}
+const (
+	defaultEpsilon     = 0.1
+	defaultPriorCTR    = 0.01
+	defaultPriorCVR    = 0.05
+	defaultPriorWeight = 100
+)
+
+type Stats interface {
+	Get(id string) (stats.Counters, bool)
+}
+
+type Rotattion struct {
+	stats    Stats
+	strategy string
+	epsilon  float64
+	prior    prior
+
+	// счетчик запросов для round-robin, свой у каждого пайплайна
+	turn *atomic.Uint64
+
+	random func() float64
+	normal func() float64
+}
+
+func New(stats *stats.Stats) *Rotattion {
+	return &Rotattion{
+		stats:    stats,
+		strategy: StrategyWeighted,
+		epsilon:  defaultEpsilon,
+		prior:    prior{ctr: defaultPriorCTR, cvr: defaultPriorCVR, weight: defaultPriorWeight},
+		turn:     &atomic.Uint64{},
+		random:   rand.Float64,
+		normal:   rand.NormFloat64,
+	}
 }
 
 func (r *Rotattion) Name() string {
@@ -34,10 +92,50 @@ func (r *Rotattion) Name() string {
 }
 
 func (r *Rotattion) Copy(cfg map[string]any) plugins.Stage {
-	return &Rotattion{}
+	strategy, _ := cfg[strategyKey].(string)
+	if strategy == "" {
+		strategy = StrategyWeighted
+	}
+
+	return &Rotattion{
+		stats:    r.stats,
+		strategy: strategy,
+		epsilon:  number(cfg[epsilonKey], defaultEpsilon),
+		prior: prior{
+			ctr:    number(cfg[priorCTRKey], defaultPriorCTR),
+			cvr:    number(cfg[priorCVRKey], defaultPriorCVR),
+			weight: number(cfg[priorWeightKey], defaultPriorWeight),
+		},
+		turn:   &atomic.Uint64{},
+		random: r.random,
+		normal: r.normal,
+	}
+}
+
+func (r *Rotattion) Schema() plugins.Schema {
+	return plugins.Schema{
+		{
+			Name:    strategyKey,
+			Type:    plugins.TypeString,
+			Default: StrategyWeighted,
+			Values:  []string{StrategyWeighted, StrategyRoundRobin, StrategyECPM, StrategyThompson, StrategyEpsilon},
+		},
+		{Name: epsilonKey, Type: plugins.TypeFloat, Default: defaultEpsilon},
+		{Name: priorCTRKey, Type: plugins.TypeFloat, Default: defaultPriorCTR},
+		{Name: priorCVRKey, Type: plugins.TypeFloat, Default: defaultPriorCVR},
+		{Name: priorWeightKey, Type: plugins.TypeFloat, Default: defaultPriorWeight},
+	}
 }
 
 func (r *Rotattion) Do(ctx context.Context, state *plugins.State) error {
+	// у CPC и CPA баннеров цена не за показы, дальше
+	// медиация и списание берут eCPM из прогноза
+	for i, banner := range state.Candidates {
+		if !banner.CPM() {
+			state.Candidates[i].Estimate = r.ecpm(banner)
+		}
+	}
+
 	winners, ok, err := r.rotate(state.Candidates)
 	if err != nil {
 		return err
@@ -49,17 +147,44 @@ func (r *Rotattion) Do(ctx context.Context, state *plugins.State) error {
 
 	state.Winners = []ads.Banner{winners}
 
+	for _, banner := range state.Candidates {
+		if banner.ID != winners.ID {
+			state.Drop(r.Name(), banner, plugins.ReasonRotation, "lost to "+winners.ID)
+		}
+	}
+
 	return nil
 }
 
 func (r *Rotattion) rotate(candidates []ads.Banner) (ads.Banner, bool, error) {
-	choices := []weightedrand.Choice[ads.Banner, int]{}
-	for _, candidate := range candidates {
-		choices = append(choices, weightedrand.NewChoice(candidate, candidate.Price))
+	if len(candidates) == 0 {
+		return ads.Banner{}, false, nil
 	}
 
-	if len(choices) == 0 {
-		return ads.Banner{}, false, nil
+	switch r.strategy {
+	case StrategyRoundRobin:
+		return r.roundRobin(candidates), true, nil
+	case StrategyECPM:
+		return r.best(candidates, r.ecpm), true, nil
+	case StrategyThompson:
+		return r.best(candidates, r.sample), true, nil
+	case StrategyEpsilon:
+		if r.random() < r.epsilon {
+			return candidates[int(r.random()*float64(len(candidates)))], true, nil
+		}
+
+		return r.best(candidates, r.ecpm), true, nil
+	}
+
+	return r.weighted(candidates)
+}
+
+func (r *Rotattion) weighted(candidates []ads.Banner) (ads.Banner, bool, error) {
+	choices := []weightedrand.Choice[ads.Banner, int]{}
+	for _, candidate := range candidates {
+		// вес в копейках, чтобы не терять дробный eCPM
+		weight := int(math.Round(candidate.ECPM() * 100))
+		choices = append(choices, weightedrand.NewChoice(candidate, weight))
 	}
 
 	chooser, err := weightedrand.NewChooser(choices...)
@@ -69,3 +194,30 @@ func (r *Rotattion) rotate(candidates []ads.Banner) (ads.Banner, bool, error) {
 
 	return chooser.Pick(), true, nil
 }
+
+// roundRobin показывает баннеры по очереди. Набор кандидатов
+// от запроса к запросу разный, поэтому очередь по id
+func (r *Rotattion) roundRobin(candidates []ads.Banner) ads.Banner {
+	sorted := slices.SortedFunc(slices.Values(candidates), func(x, y ads.Banner) int {
+		return cmp.Compare(x.ID, y.ID)
+	})
+
+	n := r.turn.Add(1) - 1
+
+	return sorted[n%uint64(len(sorted))]
+}
+
+// best выбирает баннер с наибольшей оценкой, при равных
+// оценках побеждает первый из них
+func (r *Rotattion) best(candidates []ads.Banner, score func(ads.Banner) float64) ads.Banner {
+	winner := candidates[0]
+	top := score(winner)
+
+	for _, candidate := range candidates[1:] {
+		if s := score(candidate); s > top {
+			winner, top = candidate, s
+		}
+	}
+
+	return winner
+}
@@ -1,12 +1,31 @@
 package rotation
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+	"go.ads.coffee/platform/server/internal/stats"
 )
 
+type fakeStats map[string]stats.Counters
+
+func (f fakeStats) Get(id string) (stats.Counters, bool) {
+	c, ok := f[id]
+
+	return c, ok
+}
+
+func stage(s Stats, cfg map[string]any) *Rotattion {
+	r := New(nil).Copy(cfg).(*Rotattion)
+	r.stats = s
+
+	return r
+}
+
 func TestRotattion_rotate(t *testing.T) {
 	r := &Rotattion{}
 
@@ -92,3 +111,207 @@ func TestRotattion_rotate(t *testing.T) {
 		assert.Less(t, zeroCount, 50)
 	})
 }
+
+func TestRotattion_Do_Decisions(t *testing.T) {
+	state := &plugins.State{
+		Candidates: []ads.Banner{{ID: "1", Price: 100}, {ID: "2", Price: 100}},
+		Decisions:  &plugins.DecisionLog{},
+	}
+
+	err := New(nil).Do(context.Background(), state)
+	require.NoError(t, err)
+	require.Len(t, state.Winners, 1)
+
+	// проигравший баннер попадает в журнал
+	items := state.Decisions.Items()
+	require.Len(t, items, 1)
+	assert.NotEqual(t, state.Winners[0].ID, items[0].BannerID)
+	assert.Equal(t, plugins.ReasonRotation, items[0].Reason)
+	assert.Equal(t, "lost to "+state.Winners[0].ID, items[0].Details)
+}
+
+func TestRotattion_Copy(t *testing.T) {
+	r := New(nil).Copy(map[string]any{
+		"strategy":     "epsilon",
+		"epsilon":      0.2,
+		"prior_ctr":    0.02,
+		"prior_weight": 50,
+	}).(*Rotattion)
+
+	assert.Equal(t, StrategyEpsilon, r.strategy)
+	assert.Equal(t, 0.2, r.epsilon)
+	assert.Equal(t, prior{ctr: 0.02, cvr: defaultPriorCVR, weight: 50}, r.prior)
+
+	r = New(nil).Copy(map[string]any{}).(*Rotattion)
+
+	assert.Equal(t, StrategyWeighted, r.strategy)
+
+	assert.NotEmpty(t, New(nil).Schema().Validate(map[string]any{"strategy": "greedy"}))
+}
+
+func TestRotattion_RoundRobin(t *testing.T) {
+	r := stage(fakeStats{}, map[string]any{"strategy": "roundrobin"})
+	candidates := []ads.Banner{{ID: "3"}, {ID: "1"}, {ID: "2"}}
+
+	picked := []string{}
+	for i := 0; i < 6; i++ {
+		banner, ok, err := r.rotate(candidates)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		picked = append(picked, banner.ID)
+	}
+
+	assert.Equal(t, []string{"1", "2", "3", "1", "2", "3"}, picked)
+}
+
+func TestRotattion_ECPM(t *testing.T) {
+	counters := fakeStats{
+		"cpm": {Impressions: 100000, Clicks: 10},
+		"cpc": {Impressions: 100000, Clicks: 1000},
+		"cpa": {Impressions: 100000, Clicks: 1000, Conversions: 500},
+	}
+
+	tests := []struct {
+		name    string
+		banners []ads.Banner
+		winner  string
+	}{
+		// цена CPM не зависит от CTR
+		{
+			name:    "cpm",
+			banners: []ads.Banner{{ID: "cpm", Price: 50}, {ID: "cpc", Price: 1, Pricing: ads.PricingCPC}},
+			winner:  "cpm",
+		},
+		// 10 за клик при CTR 1% - около 100 за тысячу показов
+		{
+			name:    "cpc",
+			banners: []ads.Banner{{ID: "cpm", Price: 50}, {ID: "cpc", Price: 10, Pricing: ads.PricingCPC}},
+			winner:  "cpc",
+		},
+		// 20 за конверсию при CTR 1% и CVR 50% - около 100
+		{
+			name:    "cpa",
+			banners: []ads.Banner{{ID: "cpm", Price: 50}, {ID: "cpa", Price: 20, Pricing: ads.PricingCPA}},
+			winner:  "cpa",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := stage(counters, map[string]any{"strategy": "ecpm"})
+
+			banner, ok, err := r.rotate(tt.banners)
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, tt.winner, banner.ID)
+		})
+	}
+}
+
+func TestRotattion_Do_Estimate(t *testing.T) {
+	r := stage(fakeStats{"cpc": {Impressions: 100000, Clicks: 1000}}, map[string]any{"strategy": "ecpm"})
+
+	state := &plugins.State{
+		Candidates: []ads.Banner{{ID: "cpm", Price: 50}, {ID: "cpc", Price: 10, Pricing: ads.PricingCPC}},
+	}
+
+	require.NoError(t, r.Do(context.Background(), state))
+	require.Len(t, state.Winners, 1)
+
+	// победитель несет прогноз eCPM для медиации и списания
+	winner := state.Winners[0]
+	assert.Equal(t, "cpc", winner.ID)
+	assert.InDelta(t, 100.0, winner.ECPM(), 0.5)
+	assert.InDelta(t, 100.0, winner.Charge(), 0.5)
+}
+
+func TestRotattion_ECPM_ColdStart(t *testing.T) {
+	r := stage(fakeStats{"1": {Impressions: 10000, Clicks: 10}}, map[string]any{"strategy": "ecpm"})
+
+	// у нового баннера априорный CTR 1% лучше, чем 0.1% у старого
+	banner, _, err := r.rotate([]ads.Banner{
+		{ID: "1", Price: 10, Pricing: ads.PricingCPC},
+		{ID: "2", Price: 10, Pricing: ads.PricingCPC},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "2", banner.ID)
+
+	assert.InDelta(t, 100.0, r.ecpm(ads.Banner{ID: "new", Price: 10, Pricing: ads.PricingCPC}), 1e-9)
+}
+
+func TestRotattion_Thompson(t *testing.T) {
+	r := stage(fakeStats{
+		"1": {Impressions: 100000, Clicks: 100},
+		"2": {Impressions: 100000, Clicks: 2000},
+	}, map[string]any{"strategy": "thompson"})
+
+	candidates := []ads.Banner{
+		{ID: "1", Price: 10, Pricing: ads.PricingCPC},
+		{ID: "2", Price: 10, Pricing: ads.PricingCPC},
+		{ID: "new", Price: 10, Pricing: ads.PricingCPC},
+	}
+
+	results := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		banner, ok, err := r.rotate(candidates)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		results[banner.ID]++
+	}
+
+	// лучший баннер выигрывает чаще всего, новый получает
+	// показы за счет разброса, худший - почти никогда
+	assert.Greater(t, results["2"], 700)
+	assert.Greater(t, results["new"], 30)
+	assert.Less(t, results["1"], 10)
+}
+
+func TestRotattion_Epsilon(t *testing.T) {
+	candidates := []ads.Banner{{ID: "1", Price: 10, Pricing: ads.PricingCPC}, {ID: "2", Price: 10, Pricing: ads.PricingCPC}}
+	counters := fakeStats{
+		"1": {Impressions: 100000, Clicks: 100},
+		"2": {Impressions: 100000, Clicks: 2000},
+	}
+
+	r := stage(counters, map[string]any{"strategy": "epsilon", "epsilon": 0.5})
+
+	// меньше epsilon - исследование, случайный баннер
+	values := []float64{0.1, 0.3}
+	r.random = func() float64 {
+		v := values[0]
+		values = values[1:]
+
+		return v
+	}
+
+	banner, _, err := r.rotate(candidates)
+	require.NoError(t, err)
+	assert.Equal(t, "1", banner.ID)
+
+	// иначе лучший eCPM
+	r.random = func() float64 { return 0.9 }
+
+	banner, _, err = r.rotate(candidates)
+	require.NoError(t, err)
+	assert.Equal(t, "2", banner.ID)
+}
+
+func TestRotattion_beta(t *testing.T) {
+	r := New(nil)
+
+	for _, tt := range []struct{ a, b float64 }{{1, 99}, {0.5, 0.5}, {200, 800}} {
+		sum := 0.0
+		for i := 0; i < 20000; i++ {
+			v := r.beta(tt.a, tt.b)
+			require.GreaterOrEqual(t, v, 0.0)
+			require.LessOrEqual(t, v, 1.0)
+
+			sum += v
+		}
+
+		// среднее Beta(a, b) равно a / (a + b)
+		assert.InDelta(t, tt.a/(tt.a+tt.b), sum/20000, 0.01)
+	}
+}
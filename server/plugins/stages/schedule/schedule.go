@@ -0,0 +1,166 @@
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+var Module = fx.Module(
+	"stages.schedule",
+
+	fx.Provide(
+		fx.Annotate(
+			New,
+			fx.As(new(plugins.Stage)),
+			fx.ResultTags(`group:"stages"`),
+		),
+	),
+)
+
+const timezoneKey = "timezone"
+
+const (
+	ReasonTimetable = "schedule.timetable"
+	ReasonStart     = "schedule.start"
+	ReasonEnd       = "schedule.end"
+)
+
+// в базе пустые даты хранятся как 0001-01-01,
+// все что раньше считаем незаданным
+var unset = time.Date(2001, 1, 2, 0, 0, 0, 0, time.UTC)
+
+type Schedule struct {
+	logger   *zap.Logger
+	location *time.Location
+	now      func() time.Time
+}
+
+func New(logger *zap.Logger) *Schedule {
+	return &Schedule{
+		logger:   logger,
+		location: time.UTC,
+		now:      time.Now,
+	}
+}
+
+func (s *Schedule) Name() string {
+	return "stages.schedule"
+}
+
+func (s *Schedule) Copy(cfg map[string]any) plugins.Stage {
+	location := time.UTC
+
+	if tz, _ := cfg[timezoneKey].(string); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			s.logger.Warn("invalid timezone in schedule config", zap.String("timezone", tz), zap.Error(err))
+		} else {
+			location = loc
+		}
+	}
+
+	return &Schedule{
+		logger:   s.logger,
+		location: location,
+		now:      s.now,
+	}
+}
+
+func (s *Schedule) Schema() plugins.Schema {
+	return plugins.Schema{
+		{Name: timezoneKey, Type: plugins.TypeString},
+	}
+}
+
+// Do убирает кандидатов вне расписания и дат показа. Расписание
+// проверяется в часовом поясе рекламодателя, потом плейсмента,
+// потом пайплайна.
+func (s *Schedule) Do(ctx context.Context, state *plugins.State) error {
+	now := s.now()
+	fallback := s.placement(state)
+
+	candidates := make([]ads.Banner, 0, len(state.Candidates))
+
+	for _, banner := range state.Candidates {
+		if ok, reason := Check(banner, now, fallback); !ok {
+			s.logger.Debug("banner filtered by schedule",
+				zap.String("banner", banner.ID),
+				zap.String("reason", reason),
+			)
+
+			state.Drop(s.Name(), banner, plugins.ReasonSchedule, reason)
+
+			continue
+		}
+
+		candidates = append(candidates, banner)
+	}
+
+	state.Candidates = candidates
+
+	return nil
+}
+
+func (s *Schedule) placement(state *plugins.State) *time.Location {
+	if state.Placement == nil || state.Placement.Timezone == "" {
+		return s.location
+	}
+
+	loc, err := time.LoadLocation(state.Placement.Timezone)
+	if err != nil {
+		s.logger.Warn("invalid placement timezone",
+			zap.String("placement", state.Placement.ID),
+			zap.String("timezone", state.Placement.Timezone),
+			zap.Error(err),
+		)
+
+		return s.location
+	}
+
+	return loc
+}
+
+// Check проверяет даты начала и окончания на всех уровнях
+// и расписание по дням недели и часам
+func Check(banner ads.Banner, now time.Time, fallback *time.Location) (bool, string) {
+	for _, start := range []time.Time{banner.BannerStart, banner.GroupStart, banner.CampaignStart, banner.AdvertiserStart} {
+		if start.After(unset) && now.Before(start) {
+			return false, ReasonStart
+		}
+	}
+
+	// кеш обновляется раз в минуту, баннер мог закончиться
+	for _, end := range []time.Time{banner.BannerEnd, banner.GroupEnd, banner.CampaignEnd, banner.AdvertiserEnd} {
+		if end.After(unset) && !now.Before(end) {
+			return false, ReasonEnd
+		}
+	}
+
+	location := fallback
+	if banner.Location != nil {
+		location = banner.Location
+	}
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	local := now.In(location)
+
+	if !banner.Timetable.Validate(Weekday(local), local.Hour()) {
+		return false, ReasonTimetable
+	}
+
+	return true, ""
+}
+
+// Weekday - день недели в формате расписания: 0 понедельник, 6 воскресенье
+func Weekday(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
@@ -0,0 +1,135 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+func TestWeekday(t *testing.T) {
+	// 2025-12-08 - понедельник
+	monday := time.Date(2025, 12, 8, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, 0, Weekday(monday))
+	assert.Equal(t, 6, Weekday(monday.AddDate(0, 0, 6)))
+}
+
+func TestCheck(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	// понедельник 21:30 UTC - вторник 00:30 в Москве
+	now := time.Date(2025, 12, 8, 21, 30, 0, 0, time.UTC)
+
+	tuesdayMidnight := ads.Timetable{1: {0: true}}
+
+	tests := []struct {
+		name     string
+		banner   ads.Banner
+		fallback *time.Location
+		ok       bool
+		reason   string
+	}{
+		{
+			name:   "empty",
+			banner: ads.Banner{},
+			ok:     true,
+		},
+		{
+			name:   "timetable in utc",
+			banner: ads.Banner{Timetable: tuesdayMidnight},
+			reason: ReasonTimetable,
+		},
+		{
+			name:     "timetable in placement timezone",
+			banner:   ads.Banner{Timetable: tuesdayMidnight},
+			fallback: moscow,
+			ok:       true,
+		},
+		{
+			name:     "advertiser timezone wins",
+			banner:   ads.Banner{Timetable: tuesdayMidnight, Location: time.UTC},
+			fallback: moscow,
+			reason:   ReasonTimetable,
+		},
+		{
+			name:   "not started",
+			banner: ads.Banner{CampaignStart: now.Add(time.Hour)},
+			reason: ReasonStart,
+		},
+		{
+			name:   "started",
+			banner: ads.Banner{CampaignStart: now.Add(-time.Hour)},
+			ok:     true,
+		},
+		{
+			name:   "ended",
+			banner: ads.Banner{GroupEnd: now.Add(-time.Minute)},
+			reason: ReasonEnd,
+		},
+		{
+			name:   "unset dates",
+			banner: ads.Banner{BannerStart: time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC), BannerEnd: time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)},
+			ok:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason := Check(tt.banner, now, tt.fallback)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+func TestSchedule_Do(t *testing.T) {
+	now := time.Date(2025, 12, 8, 21, 30, 0, 0, time.UTC)
+
+	stage := New(zap.NewNop())
+	stage.now = func() time.Time { return now }
+
+	s := stage.Copy(map[string]any{"timezone": "Europe/Moscow"})
+
+	state := &plugins.State{
+		Candidates: []ads.Banner{
+			{ID: "1", Timetable: ads.Timetable{1: {0: true}}},
+			{ID: "2", Timetable: ads.Timetable{0: {21: true}}},
+			{ID: "3"},
+		},
+	}
+
+	err := s.Do(context.Background(), state)
+	require.NoError(t, err)
+
+	require.Len(t, state.Candidates, 2)
+	assert.Equal(t, "1", state.Candidates[0].ID)
+	assert.Equal(t, "3", state.Candidates[1].ID)
+}
+
+func TestSchedule_Do_PlacementTimezone(t *testing.T) {
+	now := time.Date(2025, 12, 8, 21, 30, 0, 0, time.UTC)
+
+	stage := New(zap.NewNop())
+	stage.now = func() time.Time { return now }
+
+	state := &plugins.State{
+		Placement:  &plugins.Placement{ID: "p1", Timezone: "Europe/Moscow"},
+		Candidates: []ads.Banner{{ID: "1", Timetable: ads.Timetable{1: {0: true}}}},
+	}
+
+	err := stage.Copy(nil).Do(context.Background(), state)
+	require.NoError(t, err)
+	assert.Len(t, state.Candidates, 1)
+}
+
+func TestSchedule_Copy_InvalidTimezone(t *testing.T) {
+	s := New(zap.NewNop()).Copy(map[string]any{"timezone": "Mars/Olympus"}).(*Schedule)
+	assert.Equal(t, time.UTC, s.location)
+}
@@ -5,6 +5,7 @@ import (
 
 	"go.uber.org/fx"
 
+	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 )
 
@@ -20,7 +21,9 @@ var Module = fx.Module(
 	),
 )
 
-type Targeting struct{}
+type Targeting struct {
+	targetings []plugins.Targeting
+}
 
 func New() *Targeting {
 	return &Targeting{}
@@ -34,13 +37,41 @@ func (t *Targeting) Copy(cfg map[string]any) plugins.Stage {
 	return &Targeting{}
 }
 
+// Schema - у плагина нет настроек
+func (t *Targeting) Schema() plugins.Schema {
+	return plugins.Schema{}
+}
+
 func (t *Targeting) Targetings(tt []plugins.Targeting) {
-	// set targetings
+	t.targetings = tt
 }
 
 func (t *Targeting) Do(ctx context.Context, state *plugins.State) error {
-	// обрабатываются таргетинги
-	state.Candidates = state.Candidates[:]
+	// оставляем только баннеры, которые прошли все таргетинги
+	candidates := make([]ads.Banner, 0, len(state.Candidates))
+
+	for _, banner := range state.Candidates {
+		if ok, details := t.filter(ctx, state, banner); !ok {
+			state.Drop(t.Name(), banner, plugins.ReasonTargeting, details)
+
+			continue
+		}
+
+		candidates = append(candidates, banner)
+	}
+
+	state.Candidates = candidates
 
 	return nil
 }
+
+// filter возвращает имя таргетинга и его причину отказа
+func (t *Targeting) filter(ctx context.Context, state *plugins.State, banner ads.Banner) (bool, string) {
+	for _, targeting := range t.targetings {
+		if ok, reason := targeting.Filter(ctx, state, banner); !ok {
+			return false, targeting.Name() + ": " + reason
+		}
+	}
+
+	return true, ""
+}
@@ -0,0 +1,75 @@
+package targeting
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+type mockTargeting struct {
+	name string
+	drop map[string]bool
+}
+
+func (m *mockTargeting) Name() string {
+	return m.name
+}
+
+func (m *mockTargeting) Copy(cfg map[string]any) plugins.Targeting {
+	return m
+}
+
+func (m *mockTargeting) Filter(ctx context.Context, state *plugins.State, banner ads.Banner) (bool, string) {
+	if m.drop[banner.ID] {
+		return false, m.name
+	}
+
+	return true, ""
+}
+
+func TestTargeting_Name(t *testing.T) {
+	assert.Equal(t, "stages.targeting", New().Name())
+}
+
+func TestTargeting_Do(t *testing.T) {
+	stage := New().Copy(nil).(*Targeting)
+	stage.Targetings([]plugins.Targeting{
+		&mockTargeting{name: "first", drop: map[string]bool{"1": true}},
+		&mockTargeting{name: "second", drop: map[string]bool{"3": true}},
+	})
+
+	state := &plugins.State{
+		Candidates: []ads.Banner{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}},
+		Decisions:  &plugins.DecisionLog{},
+	}
+
+	err := stage.Do(context.Background(), state)
+	require.NoError(t, err)
+
+	require.Len(t, state.Candidates, 2)
+	assert.Equal(t, "2", state.Candidates[0].ID)
+	assert.Equal(t, "4", state.Candidates[1].ID)
+
+	assert.Equal(t, []plugins.Decision{
+		{BannerID: "1", Stage: "stages.targeting", Reason: plugins.ReasonTargeting, Details: "first: first"},
+		{BannerID: "3", Stage: "stages.targeting", Reason: plugins.ReasonTargeting, Details: "second: second"},
+	}, state.Decisions.Items())
+}
+
+func TestTargeting_Do_WithoutTargetings(t *testing.T) {
+	stage := New()
+
+	state := &plugins.State{
+		Candidates: []ads.Banner{{ID: "1"}, {ID: "2"}},
+	}
+
+	err := stage.Do(context.Background(), state)
+	require.NoError(t, err)
+
+	assert.Len(t, state.Candidates, 2)
+}
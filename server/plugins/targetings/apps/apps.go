@@ -1,11 +1,25 @@
 package apps
 
 import (
+	"context"
+	"slices"
+	"strings"
+
 	"go.uber.org/fx"
 
+	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 )
 
+const (
+	ReasonBundle  = "apps.bundle"
+	ReasonBapp    = "apps.bapp"
+	ReasonBlocked = "apps.blocked"
+	ReasonBadv    = "apps.badv"
+	ReasonIP      = "apps.ip"
+	ReasonNetwork = "apps.network"
+)
+
 var Module = fx.Module(
 	"targetings.apps",
 	fx.Provide(
@@ -31,4 +45,78 @@ func (a *Apps) Copy(cfg map[string]any) plugins.Targeting {
 	return &Apps{}
 }
 
-func (a *Apps) Filter() {}
+// Schema - у плагина нет настроек
+func (a *Apps) Schema() plugins.Schema {
+	return plugins.Schema{}
+}
+
+func (a *Apps) Filter(ctx context.Context, state *plugins.State, banner ads.Banner) (bool, string) {
+	bundle := ""
+	if state.App != nil {
+		bundle = state.App.Bundle
+	}
+
+	if !banner.Targeting.Bundle.Validate(values(bundle)) {
+		return false, ReasonBundle
+	}
+
+	// таргетинг по установленным приложениям
+	apps := []string{}
+	if state.User != nil {
+		apps = state.User.Apps
+	}
+
+	if !banner.Targeting.Bapp.Validate(apps) {
+		return false, ReasonBapp
+	}
+
+	// приложение рекламодателя заблокировано в запросе
+	if state.Restrictions != nil && banner.Bundle != "" && slices.Contains(state.Restrictions.Bapp, banner.Bundle) {
+		return false, ReasonBlocked
+	}
+
+	// домен рекламодателя заблокирован в запросе. Bcat передаем
+	// сетям в медиации, у своих баннеров категорий IAB нет
+	if state.Restrictions != nil && blocked(state.Restrictions.Badv, banner.Domain()) {
+		return false, ReasonBadv
+	}
+
+	ip := ""
+	if state.Device != nil {
+		ip = state.Device.IP
+	}
+
+	if !banner.Targeting.IP.Validate(ip) {
+		return false, ReasonIP
+	}
+
+	if !banner.Targeting.Network.Validate(values(state.Network)) {
+		return false, ReasonNetwork
+	}
+
+	return true, ""
+}
+
+// blocked - домен или его поддомен есть в списке badv
+func blocked(badv []string, domain string) bool {
+	if domain == "" {
+		return false
+	}
+
+	for _, d := range badv {
+		d = strings.TrimPrefix(strings.ToLower(d), "www.")
+		if d != "" && (domain == d || strings.HasSuffix(domain, "."+d)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func values(v string) []string {
+	if v == "" {
+		return nil
+	}
+
+	return []string{v}
+}
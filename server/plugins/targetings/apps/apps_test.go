@@ -0,0 +1,121 @@
+package apps
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+func TestApps_Name(t *testing.T) {
+	assert.Equal(t, "targetings.apps", New().Name())
+}
+
+func TestApps_Filter(t *testing.T) {
+	_, local, _ := net.ParseCIDR("10.0.0.0/8")
+
+	state := &plugins.State{
+		Network: "exchange",
+		App:     &plugins.App{Bundle: "com.example.app"},
+		User:    &plugins.User{Apps: []string{"com.installed.app"}},
+		Device:  &plugins.Device{IP: "10.1.2.3"},
+		Restrictions: &plugins.Restrictions{
+			Bapp: []string{"com.blocked.app"},
+			Badv: []string{"blocked.com"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		banner ads.Banner
+		ok     bool
+		reason string
+	}{
+		{
+			name: "empty targeting",
+			ok:   true,
+		},
+		{
+			name: "included bundle",
+			banner: ads.Banner{Targeting: ads.Targeting{
+				Bundle: ads.ExcludeInclude{IncludeOr: []string{"com.example.app"}},
+			}},
+			ok: true,
+		},
+		{
+			name: "excluded bundle",
+			banner: ads.Banner{Targeting: ads.Targeting{
+				Bundle: ads.ExcludeInclude{ExcludeOr: []string{"com.example.app"}},
+			}},
+			reason: ReasonBundle,
+		},
+		{
+			name: "installed app",
+			banner: ads.Banner{Targeting: ads.Targeting{
+				Bapp: ads.ExcludeInclude{ExcludeOr: []string{"com.installed.app"}},
+			}},
+			reason: ReasonBapp,
+		},
+		{
+			name:   "blocked advertiser app",
+			banner: ads.Banner{Bundle: "com.blocked.app"},
+			reason: ReasonBlocked,
+		},
+		{
+			name:   "blocked advertiser domain",
+			banner: ads.Banner{Target: "https://www.blocked.com/landing"},
+			reason: ReasonBadv,
+		},
+		{
+			name:   "blocked advertiser subdomain",
+			banner: ads.Banner{Target: "https://shop.blocked.com/landing"},
+			reason: ReasonBadv,
+		},
+		{
+			name:   "allowed advertiser domain",
+			banner: ads.Banner{Target: "https://notblocked.com/landing"},
+			ok:     true,
+		},
+		{
+			name: "excluded ip",
+			banner: ads.Banner{Targeting: ads.Targeting{
+				IP: ads.ExcludeIncludeIP{Exclude: []*net.IPNet{local}},
+			}},
+			reason: ReasonIP,
+		},
+		{
+			name: "included ip",
+			banner: ads.Banner{Targeting: ads.Targeting{
+				IP: ads.ExcludeIncludeIP{Include: []*net.IPNet{local}},
+			}},
+			ok: true,
+		},
+		{
+			name: "other network",
+			banner: ads.Banner{Targeting: ads.Targeting{
+				Network: ads.ExcludeInclude{IncludeOr: []string{"other"}},
+			}},
+			reason: ReasonNetwork,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason := New().Filter(context.Background(), state, tt.banner)
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+func TestApps_Filter_EmptyState(t *testing.T) {
+	ok, reason := New().Filter(context.Background(), &plugins.State{}, ads.Banner{})
+
+	assert.True(t, ok)
+	assert.Empty(t, reason)
+}
@@ -1,11 +1,20 @@
 package geo
 
 import (
+	"context"
+
 	"go.uber.org/fx"
 
+	"go.ads.coffee/platform/server/internal/domain/ads"
 	"go.ads.coffee/platform/server/internal/domain/plugins"
 )
 
+const (
+	ReasonCountry = "geo.country"
+	ReasonRegion  = "geo.region"
+	ReasonCity    = "geo.city"
+)
+
 var Module = fx.Module(
 	"targetings.geo",
 	fx.Provide(
@@ -32,6 +41,38 @@ func (g *Geo) Copy(cfg map[string]any) plugins.Targeting {
 	return &Geo{}
 }
 
-func (g *Geo) Filter() {
+// Schema - у плагина нет настроек
+func (g *Geo) Schema() plugins.Schema {
+	return plugins.Schema{}
+}
+
+func (g *Geo) Filter(ctx context.Context, state *plugins.State, banner ads.Banner) (bool, string) {
+	geo := plugins.Geo{}
+	if state.Geo != nil {
+		geo = *state.Geo
+	}
+
+	if !banner.Targeting.Country.Validate(values(geo.Country)) {
+		return false, ReasonCountry
+	}
+
+	if !banner.Targeting.Region.Validate(values(geo.Region)) {
+		return false, ReasonRegion
+	}
+
+	if !banner.Targeting.City.Validate(values(geo.City)) {
+		return false, ReasonCity
+	}
+
+	return true, ""
+}
+
+// если значение неизвестно, то баннер с включающим
+// таргетингом не пройдет, а с исключающим - пройдет
+func values(v string) []string {
+	if v == "" {
+		return nil
+	}
 
+	return []string{v}
 }
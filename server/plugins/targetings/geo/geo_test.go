@@ -0,0 +1,96 @@
+package geo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.ads.coffee/platform/server/internal/domain/ads"
+	"go.ads.coffee/platform/server/internal/domain/plugins"
+)
+
+func TestGeo_Name(t *testing.T) {
+	assert.Equal(t, "targetings.geo", New().Name())
+}
+
+func TestGeo_Filter(t *testing.T) {
+	state := &plugins.State{
+		Geo: &plugins.Geo{
+			Country: "RU",
+			Region:  "MOW",
+			City:    "Moscow",
+		},
+	}
+
+	tests := []struct {
+		name      string
+		state     *plugins.State
+		targeting ads.Targeting
+		ok        bool
+		reason    string
+	}{
+		{
+			name:  "empty targeting",
+			state: state,
+			ok:    true,
+		},
+		{
+			name:  "included country",
+			state: state,
+			targeting: ads.Targeting{
+				Country: ads.ExcludeInclude{IncludeOr: []string{"RU", "BY"}},
+			},
+			ok: true,
+		},
+		{
+			name:  "excluded country",
+			state: state,
+			targeting: ads.Targeting{
+				Country: ads.ExcludeInclude{ExcludeOr: []string{"RU"}},
+			},
+			reason: ReasonCountry,
+		},
+		{
+			name:  "other region",
+			state: state,
+			targeting: ads.Targeting{
+				Region: ads.ExcludeInclude{IncludeOr: []string{"SPE"}},
+			},
+			reason: ReasonRegion,
+		},
+		{
+			name:  "excluded city",
+			state: state,
+			targeting: ads.Targeting{
+				City: ads.ExcludeInclude{ExcludeOr: []string{"Moscow"}},
+			},
+			reason: ReasonCity,
+		},
+		{
+			name:  "unknown geo with include",
+			state: &plugins.State{},
+			targeting: ads.Targeting{
+				Country: ads.ExcludeInclude{IncludeOr: []string{"RU"}},
+			},
+			reason: ReasonCountry,
+		},
+		{
+			name:  "unknown geo with exclude",
+			state: &plugins.State{},
+			targeting: ads.Targeting{
+				Country: ads.ExcludeInclude{ExcludeOr: []string{"RU"}},
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason := New().Filter(context.Background(), tt.state, ads.Banner{Targeting: tt.targeting})
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}